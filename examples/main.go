@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/kevindweb/cache/pkg/client"
 	"github.com/kevindweb/cache/pkg/server"
@@ -46,9 +47,11 @@ func customParameters(logger *log.Logger) {
 	logger.Printf("Starting custom server at %s\n", customServer.Address)
 
 	clientOptions := client.Options{
-		Host:    host,
-		Port:    port,
-		Network: network,
+		Host:        host,
+		Port:        port,
+		Network:     network,
+		PoolSize:    4,
+		BatchWindow: 50 * time.Microsecond,
 	}
 	customClient, err := client.StartOptions(clientOptions)
 	if err != nil {
@@ -6,26 +6,67 @@ const (
 	BaseWaitTime         = time.Microsecond * 500
 	DialTimeout          = time.Second * 2
 	ConnRetryWait        = time.Millisecond * 10
+	ReconnectMaxWait     = time.Second * 1
 	ReadTimeout          = time.Second * 1
 	ShutdownTimeout      = time.Millisecond * 500
 	ClientRequestTimeout = time.Second * 2
 
+	NoExpiration      time.Duration = -1
+	ExpireInterval                  = time.Millisecond * 100
+	ExpireCycleBudget               = time.Millisecond * 25
+	ExpireSampleSize                = 20
+
 	DefaultNetwork = "tcp"
 	DefaultHost    = "localhost"
 	DefaultPort    = 6379
 
 	MaxConnectionPool = 20
 	MaxRequestBatch   = 200
+	MaxRetainedBuffer = 1 << 20
+	DefaultScanCount  = 10
+	MaxScanCount      = 1000
+
+	DefaultCompactLogSize = 64 << 20
+
+	ReplicaHeartbeat  = time.Millisecond * 100
+	ReplicaTimeout    = time.Second * 5
+	MaxReplicaBacklog = 64 << 20
+	ReplicaChunkSize  = 1 << 20
+
+	DefaultVirtualNodes = 160
+	DefaultMaxRedirects = 5
 
 	InvalidAddrErr = "address host:port are invalid"
 	InvalidPortErr = "invalid configured port %d"
-	EmptyParamErr  = "parameters cannot be empty on request"
-	EmptyResErr    = "empty response back from %s request"
-	EmptyResArgErr = "empty argument from %s request"
 
-	ClientUninitializedErr  = "client was not initialized"
-	ClientInitTimeoutErr    = "timed out dialing %s for %s"
-	ClientRequestTimeoutErr = "request (%s) timed out after %s"
+	InvalidPoolSizeErr = "invalid connection pool size %d"
+	InvalidMaxBatchErr = "invalid max batch %d, must be between 1 and %d"
+	InvalidDurationErr = "invalid %s %s"
+
+	EmptyParamErr       = "parameters cannot be empty on request"
+	EmptyValueErr       = "empty value for key %s"
+	InvalidTTLErr       = "ttl %s must be at least 1ms"
+	InvalidTimeoutErr   = "timeout %s must be at least 1ms"
+	InvalidIncrementErr = "invalid increment %v"
+	InvalidScoreErr     = "invalid score %v"
+	InvalidIntErr       = "expected integer from %s request, received %s"
+	InvalidFloatErr     = "expected float from %s request, received %s"
+	MismatchedErr       = "%d keys but %d values"
+	MultiResultsErr     = "expected %d results from %s, received %d"
+	InvalidPairsErr     = "expected field and value pairs from %s, received %d values"
+	InvalidScoresErr    = "expected member and score pairs from %s, received %d values"
+	TxTooLargeErr       = "transaction of %d operations exceeds max of %d"
+	PipelineTooLargeErr = "pipeline of %d operations exceeds max of %d"
+
+	NoNodesErr             = "cluster has no nodes"
+	DuplicateNodeErr       = "node %s is already in the cluster"
+	UnknownNodeErr         = "node %s is not in the cluster"
+	InvalidVirtualNodesErr = "invalid number of virtual nodes %d"
+	InvalidRedirectsErr    = "invalid number of redirects %d"
+
+	ClientUninitializedErr    = "client was not initialized"
+	ClientInitTimeoutErr      = "timed out dialing %s for %s"
+	ClientRequestCancelledErr = "request (%s) cancelled: %w"
 
 	UndefinedOpErr = "undefined operation: %s"
 )
@@ -3,19 +3,63 @@ package constants
 const (
 	RequestSizeBytes = 30
 	HeaderSize       = 4
+	MaxFrameSize     = 64 << 20
+
+	FrameTooLargeErr = "frame of %d bytes exceeds max of %d"
 )
 
 const (
 	PONG = "PONG"
 	OK   = "OK"
 
-	ERR = '-'
+	PERSISTENT = "PERSISTENT"
 
 	PING = "ping"
 	ECHO = "echo"
 	GET  = "get"
 	DEL  = "del"
 	SET  = "set"
+
+	SETEX   = "setex"
+	EXPIRE  = "expire"
+	TTL     = "ttl"
+	PERSIST = "persist"
+
+	MGET = "mget"
+	MSET = "mset"
+	MDEL = "mdel"
+
+	INCR        = "incr"
+	DECR        = "decr"
+	INCRBY      = "incrby"
+	INCRBYFLOAT = "incrbyfloat"
+
+	SETNX = "setnx"
+	SETXX = "setxx"
+	CAS   = "cas"
+
+	HSET    = "hset"
+	HDEL    = "hdel"
+	HGETALL = "hgetall"
+
+	LPUSH = "lpush"
+	RPUSH = "rpush"
+	LLEN  = "llen"
+
+	ZADD          = "zadd"
+	ZREM          = "zrem"
+	ZSCORE        = "zscore"
+	ZINCRBY       = "zincrby"
+	ZRANGE        = "zrange"
+	ZRANGEBYSCORE = "zrangebyscore"
+
+	PIPELINE = "pipeline"
+	SCAN     = "scan"
+	SAVE     = "save"
+
+	REPLICATION  = "replication"
+	CLUSTERSLOTS = "clusterslots"
+	SETSLOT      = "setslot"
 )
 
 func Pong() []byte {
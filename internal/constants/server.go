@@ -0,0 +1,62 @@
+package constants
+
+const (
+	InvalidMaxMemoryErr = "invalid max memory %d bytes"
+	InvalidEvictionErr  = "invalid eviction policy %s"
+	InvalidLoopsErr     = "invalid number of event loops %d"
+
+	InvalidSnapshotIntervalErr = "invalid snapshot interval %s"
+	MissingSnapshotPathErr     = "snapshot interval %s needs a snapshot path"
+	NoSnapshotPathErr          = "no snapshot path configured"
+	LoadSnapshotErr            = "loading snapshot %s: %w"
+	SaveSnapshotErr            = "saving snapshot %s: %v"
+
+	InvalidSyncPolicyErr = "invalid append log sync policy %s"
+	InvalidCompactErr    = "invalid append log compaction size %d bytes"
+	ReplayLogErr         = "replaying append log %s: %w"
+	CompactLogErr        = "compacting append log %s: %v"
+
+	MismatchedWatchErr = "%d watched keys but %d versions"
+	NestedTxErr        = "transactions cannot be nested"
+	TxSaveErr          = "snapshots cannot be saved inside a transaction"
+	TxAbortedErr       = "transaction aborted by %s at operation %d: %s"
+	WatchConflictErr   = "watched key %s is at version %d, expected %d"
+
+	MismatchedFieldsErr = "%d fields but %d values"
+
+	EmptyPushErr           = "no values to push onto key %s"
+	InvalidBlockTimeoutErr = "blocking pop timeout %dms must be positive"
+
+	BlockTimeoutErr = "timed out after %s waiting on key %s"
+
+	MismatchedScoresErr = "%d members but %d scores"
+	SingleMemberErr     = "%s takes one member, received %d"
+	ScoreBoundsErr      = "expected min and max scores, received %d"
+
+	ReadOnlyErr         = "%s is not allowed on a read-only replica"
+	ReplicaChainErr     = "replicas cannot stream to other replicas"
+	ReplicateNestedErr  = "replication cannot start inside a transaction"
+	ReplicaLogErr       = "replicas cannot keep an append log"
+	ReplicaMemoryErr    = "replicas cannot evict keys, the leader's evictions reach them as deletes"
+	ReplicaSyncErr      = "replicating from %s: %v"
+	ReplicaDroppedErr   = "dropped a replica past the %d byte backlog"
+	UnexpectedSyncErr   = "expected a snapshot from the leader, received %s: %s"
+	UnexpectedStreamErr = "expected a replication stream from the leader, received %s"
+	SnapshotChunkErr    = "expected a snapshot chunk from the leader, received %d operations"
+
+	MovedErr            = "slot %d of key %s is served by %s"
+	AskErr              = "slot %d of key %s is migrating to %s"
+	CrossSlotErr        = "keys of %s span more than one slot"
+	UnservedSlotErr     = "slot %d is not served by any node"
+	NotClusterErr       = "server is not in cluster mode"
+	MissingClusterErr   = "cluster slots need the address of this node"
+	ClusterReplicaErr   = "cluster nodes cannot be replicas"
+	InvalidSlotStateErr = "invalid slot state %q, expected node, migrating, importing or stable"
+	SlotNotOwnedErr     = "slot %d is not served by this node"
+
+	InvalidSlotRangeErr   = "invalid slot range %d-%d, slots are 0-%d"
+	MissingSlotAddrErr    = "slot range %d-%d has no node address"
+	OverlappingSlotErr    = "slot %d is assigned to both %s and %s"
+	InvalidSlotTriplesErr = "expected start, end and address triples, received %d values"
+	InvalidSlotErr        = "invalid slot %q"
+)
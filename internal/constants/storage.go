@@ -0,0 +1,37 @@
+package constants
+
+const (
+	KeyTTLErr = "invalid ttl %s for key %s"
+
+	OutOfMemoryErr = "out of memory setting key %s, %d of %d bytes used"
+
+	NotIntegerErr = "value of key %s is not an integer"
+	NotFloatErr   = "value of key %s is not a float"
+	OverflowErr   = "incrementing key %s by %v overflows"
+
+	KeyExistsErr       = "key %s already set"
+	VersionMismatchErr = "key %s is at version %d, expected %d"
+
+	InvalidPatternErr = "invalid match pattern %q"
+
+	WrongTypeErr = "key %s holds a %s value"
+
+	UnsetFieldErr = "field %s not set in key %s"
+
+	UnsetMemberErr = "member %s not in key %s"
+
+	SnapshotTruncatedErr = "snapshot of %d bytes is truncated"
+	SnapshotMagicErr     = "file is not a snapshot"
+	SnapshotChecksumErr  = "snapshot checksum %08x does not match contents %08x"
+	SnapshotVersionErr   = "snapshot format version %d is not supported, expected %d"
+	SnapshotKindErr      = "unknown kind %d for key %s"
+	SnapshotObjectErr    = "cannot snapshot %s value of key %s"
+	RestoreKeyErr        = "restoring key %s: %w"
+	SnapshotFieldsErr    = "%d fields but %d values for key %s"
+
+	LogMagicErr   = "file %s is not an operation log"
+	LogVersionErr = "operation log format version %d is not supported, expected %d"
+	LogCorruptErr = "operation log %s is corrupt at offset %d"
+	LogKindErr    = "unknown record kind %d at offset %d"
+	LogSyncErr    = "invalid sync policy %d"
+)
@@ -0,0 +1,358 @@
+package oplog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/util"
+)
+
+const (
+	FormatVersion = 1
+
+	magic      = "COPL"
+	headerSize = len(magic) + 1
+
+	recordHeaderSize = 5
+	checksumSize     = 4
+	timestampSize    = 8
+
+	syncInterval = time.Second
+)
+
+// SyncPolicy trades how many writes a crash can lose for write throughput.
+type SyncPolicy int
+
+const (
+	EverySecond SyncPolicy = iota
+	Always
+	Never
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case EverySecond:
+		return "everysec"
+	case Always:
+		return "always"
+	case Never:
+		return "never"
+	default:
+		return fmt.Sprintf("SyncPolicy(%d)", int(p))
+	}
+}
+
+func (p SyncPolicy) Valid() bool {
+	return p >= EverySecond && p <= Never
+}
+
+type kind byte
+
+const (
+	kindBase kind = iota
+	kindOp
+)
+
+var table = crc32.MakeTable(crc32.Castagnoli)
+
+// Log appends mutating operations to a file so they can be replayed after a
+// restart.
+type Log struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	policy     SyncPolicy
+	size       int64
+	baseSize   int64
+	dirty      bool
+	compacting bool
+	pending    []byte
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func Open(path string, policy SyncPolicy) (*Log, error) {
+	if !policy.Valid() {
+		return nil, fmt.Errorf(constants.LogSyncErr, policy)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := validLength(file, path)
+	if err == nil {
+		err = file.Truncate(size)
+	}
+	if err == nil {
+		_, err = file.Seek(size, io.SeekStart)
+	}
+	if err == nil && size == 0 {
+		size, err = writeHeader(file)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	l := &Log{
+		path:     path,
+		file:     file,
+		policy:   policy,
+		size:     size,
+		baseSize: size,
+		done:     make(chan struct{}),
+	}
+	if policy == EverySecond {
+		l.wg.Add(1)
+		go l.syncEvery(syncInterval)
+	}
+	return l, nil
+}
+
+func writeHeader(w io.Writer) (int64, error) {
+	n, err := w.Write(append([]byte(magic), FormatVersion))
+	return int64(n), err
+}
+
+func validLength(file *os.File, path string) (int64, error) {
+	data, err := io.ReadAll(file)
+	if err != nil || len(data) == 0 {
+		return 0, err
+	}
+
+	valid, err := scan(data, path, func(kind, []byte, int) error { return nil })
+	return int64(valid), err
+}
+
+func AppendOp(dst []byte, op protocol.Operation, at time.Time) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, make([]byte, recordHeaderSize+timestampSize)...)
+	binary.BigEndian.PutUint64(dst[start+recordHeaderSize:], uint64(at.UnixMilli()))
+	dst, err := op.MarshalMsg(dst)
+	if err != nil {
+		return dst[:start], err
+	}
+	return seal(dst, start, kindOp), nil
+}
+
+func appendBase(dst []byte, base []byte) []byte {
+	start := len(dst)
+	dst = append(dst, make([]byte, recordHeaderSize)...)
+	dst = append(dst, base...)
+	return seal(dst, start, kindBase)
+}
+
+func seal(dst []byte, start int, k kind) []byte {
+	dst[start] = byte(k)
+	binary.BigEndian.PutUint32(dst[start+1:], uint32(len(dst)-start-recordHeaderSize))
+	return binary.BigEndian.AppendUint32(dst, crc32.Checksum(dst[start:], table))
+}
+
+func (l *Log) Write(records []byte) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(records); err != nil {
+		return err
+	}
+
+	l.size += int64(len(records))
+	if l.compacting {
+		l.pending = append(l.pending, records...)
+	}
+
+	if l.policy == Always {
+		return l.file.Sync()
+	}
+	l.dirty = true
+	return nil
+}
+
+func (l *Log) syncEvery(interval time.Duration) {
+	defer l.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.dirty {
+				l.dirty = false
+				if err := l.file.Sync(); err != nil {
+					l.dirty = true
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *Log) Size() (int64, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size, l.baseSize
+}
+
+func (l *Log) StartCompaction() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.compacting {
+		return false
+	}
+
+	l.compacting = true
+	l.pending = nil
+	return true
+}
+
+// FinishCompaction swaps in base followed by the records appended since
+// StartCompaction, only the swap blocks writers.
+func (l *Log) FinishCompaction(base []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".tmp-*")
+	if err != nil {
+		l.AbortCompaction()
+		return err
+	}
+
+	size, err := writeHeader(tmp)
+	if err == nil {
+		record := appendBase(nil, base)
+		_, err = tmp.Write(record)
+		size += int64(len(record))
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		l.AbortCompaction()
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.compacting = false
+	pending := l.pending
+	l.pending = nil
+
+	if _, err = tmp.Write(pending); err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	l.file.Close()
+	l.file = tmp
+	l.baseSize = size
+	l.size = size + int64(len(pending))
+	l.dirty = false
+	return util.SyncDir(filepath.Dir(l.path))
+}
+
+func (l *Log) AbortCompaction() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.compacting = false
+	l.pending = nil
+}
+
+func (l *Log) Close() error {
+	close(l.done)
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.file.Sync()
+	return errors.Join(err, l.file.Close())
+}
+
+// Replay stops at a record cut short at the end of the file by a crash.
+func Replay(
+	path string, base func([]byte) error, op func(protocol.Operation, time.Time) error,
+) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	ops := 0
+	_, err = scan(data, path, func(k kind, payload []byte, offset int) error {
+		switch k {
+		case kindBase:
+			return base(payload)
+		case kindOp:
+			var decoded protocol.Operation
+			at := time.UnixMilli(int64(binary.BigEndian.Uint64(payload)))
+			if _, err := decoded.UnmarshalMsg(payload[timestampSize:]); err != nil {
+				return fmt.Errorf(constants.LogCorruptErr+": %w", path, offset, err)
+			}
+			ops++
+			return op(decoded, at)
+		default:
+			return fmt.Errorf(constants.LogKindErr, k, offset)
+		}
+	})
+	return ops, err
+}
+
+func scan(data []byte, path string, fn func(kind, []byte, int) error) (int, error) {
+	if len(data) < headerSize {
+		return 0, nil
+	}
+
+	if string(data[:len(magic)]) != magic {
+		return 0, fmt.Errorf(constants.LogMagicErr, path)
+	}
+
+	if version := int(data[len(magic)]); version != FormatVersion {
+		return 0, fmt.Errorf(constants.LogVersionErr, version, FormatVersion)
+	}
+
+	offset := headerSize
+	for len(data)-offset >= recordHeaderSize {
+		length := int(binary.BigEndian.Uint32(data[offset+1:]))
+		end := offset + recordHeaderSize + length + checksumSize
+		if end > len(data) {
+			break
+		}
+
+		body := data[offset : end-checksumSize]
+		if crc32.Checksum(body, table) != binary.BigEndian.Uint32(data[end-checksumSize:]) {
+			return offset, fmt.Errorf(constants.LogCorruptErr, path, offset)
+		}
+
+		k := kind(data[offset])
+		if k == kindOp && length < timestampSize {
+			return offset, fmt.Errorf(constants.LogCorruptErr, path, offset)
+		}
+
+		if err := fn(k, body[recordHeaderSize:], offset); err != nil {
+			return offset, err
+		}
+		offset = end
+	}
+	return offset, nil
+}
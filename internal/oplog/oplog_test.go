@@ -0,0 +1,168 @@
+package oplog
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func set(i int) protocol.Operation {
+	return protocol.Operation{Type: protocol.SET, Key: []byte("key" + strconv.Itoa(i)), Value: []byte("val")}
+}
+
+func appendOps(t *testing.T, l *Log, from, to int) {
+	var records []byte
+	for i := from; i < to; i++ {
+		var err error
+		records, err = AppendOp(records, set(i), time.UnixMilli(int64(i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, l.Write(records))
+}
+
+type replayed struct {
+	bases [][]byte
+	ops   []protocol.Operation
+	times []time.Time
+}
+
+func replay(t *testing.T, path string) (replayed, error) {
+	var r replayed
+	n, err := Replay(path, func(base []byte) error {
+		r.bases = append(r.bases, append([]byte{}, base...))
+		return nil
+	}, func(op protocol.Operation, at time.Time) error {
+		r.ops = append(r.ops, op)
+		r.times = append(r.times, at)
+		return nil
+	})
+	assert.Equal(t, len(r.ops), n)
+	return r, err
+}
+
+func TestAppendAndReplay(t *testing.T) {
+	t.Parallel()
+	for _, policy := range []SyncPolicy{EverySecond, Always, Never} {
+		path := filepath.Join(t.TempDir(), "ops.log")
+		_, err := replay(t, path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		l, err := Open(path, policy)
+		require.NoError(t, err)
+		appendOps(t, l, 0, 3)
+		require.NoError(t, l.Close())
+
+		// reopening appends after the existing records
+		l, err = Open(path, policy)
+		require.NoError(t, err)
+		appendOps(t, l, 3, 5)
+		require.NoError(t, l.Close())
+
+		r, err := replay(t, path)
+		require.NoError(t, err, policy.String())
+		assert.Empty(t, r.bases)
+		require.Len(t, r.ops, 5)
+		for i, op := range r.ops {
+			assert.Equal(t, "key"+strconv.Itoa(i), string(op.Key))
+			assert.Equal(t, int64(i), r.times[i].UnixMilli())
+		}
+	}
+
+	_, err := Open(filepath.Join(t.TempDir(), "ops.log"), SyncPolicy(7))
+	assert.Error(t, err)
+}
+
+func TestTruncatedTail(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "ops.log")
+	l, err := Open(path, Never)
+	require.NoError(t, err)
+	appendOps(t, l, 0, 3)
+	require.NoError(t, l.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	r, err := replay(t, path)
+	require.NoError(t, err, "a record cut short ends the replay")
+	assert.Len(t, r.ops, 2)
+
+	l, err = Open(path, Never)
+	require.NoError(t, err)
+	appendOps(t, l, 3, 4)
+	require.NoError(t, l.Close())
+
+	r, err = replay(t, path)
+	require.NoError(t, err)
+	require.Len(t, r.ops, 3)
+	assert.Equal(t, "key3", string(r.ops[2].Key))
+}
+
+func TestCorruptRecord(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "ops.log")
+	l, err := Open(path, Never)
+	require.NoError(t, err)
+	appendOps(t, l, 0, 3)
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[headerSize+recordHeaderSize+timestampSize+2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err = replay(t, path)
+	assert.ErrorContains(t, err, "corrupt")
+	_, err = Open(path, Never)
+	assert.ErrorContains(t, err, "corrupt")
+
+	require.NoError(t, os.WriteFile(path, []byte("not a log"), 0o644))
+	_, err = replay(t, path)
+	assert.ErrorContains(t, err, "not an operation log")
+}
+
+func TestCompaction(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ops.log")
+	l, err := Open(path, Always)
+	require.NoError(t, err)
+	appendOps(t, l, 0, 10)
+
+	assert.True(t, l.StartCompaction())
+	assert.False(t, l.StartCompaction(), "one compaction runs at a time")
+	// writes racing the compaction land in both files
+	appendOps(t, l, 10, 12)
+	require.NoError(t, l.FinishCompaction([]byte("base")))
+	appendOps(t, l, 12, 13)
+
+	size, base := l.Size()
+	assert.Greater(t, size, base)
+	require.NoError(t, l.Close())
+
+	r, err := replay(t, path)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("base")}, r.bases)
+	require.Len(t, r.ops, 3)
+	assert.Equal(t, "key10", string(r.ops[0].Key))
+	assert.Equal(t, "key12", string(r.ops[2].Key))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+
+	l, err = Open(path, Never)
+	require.NoError(t, err)
+	assert.True(t, l.StartCompaction())
+	l.AbortCompaction()
+	assert.True(t, l.StartCompaction())
+	require.NoError(t, l.Close())
+}
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"strconv"
+	"strings"
 )
 
 //go:generate msgp
@@ -17,6 +18,47 @@ const (
 	GET
 	DELETE
 	PING
+	SETEX  // TTL in milliseconds
+	EXPIRE // TTL in milliseconds
+	TTL
+	PERSIST
+	MGET // Keys
+	MSET // Keys, with the value of Keys[i] in Values[i]
+	MDEL // Keys
+	INCR
+	DECR
+	INCRBY      // adds Delta
+	INCRBYFLOAT // adds the decimal text in Value
+	SETNX       // optional TTL
+	SETXX       // optional TTL
+	CAS         // writes only while Key is still at Version, optional TTL
+	GETVERSION
+	TX   // runs Ops once every watched key in Keys is still at Versions[i], zero meaning absent
+	SCAN // keys matching the glob in Pattern from Cursor, examining about Count keys
+	HSET // stores Values[i] under field Keys[i]
+	HGET // the field in Member
+	HDEL // the fields in Keys
+	HGETALL
+	LPUSH // Values
+	RPUSH // Values
+	LPOP
+	RPOP
+	LRANGE // Start to Stop
+	LLEN
+	BLPOP // waits up to TTL
+	BRPOP // waits up to TTL
+	ZADD  // the members in Keys with Scores
+	ZREM  // the members in Keys
+	ZSCORE
+	ZINCRBY // adds Scores[0] to the member in Keys[0]
+	ZRANGE  // ranks Start to Stop
+	ZRANGEBYSCORE
+	SAVE
+	REPLICATE // Ops up to offset Version sent at SentAt, or a snapshot chunk in Value of Count bytes
+	REPLICATION
+	CLUSTERSLOTS
+	ASKING  // lets the next operation use a slot being imported
+	SETSLOT // sets slots Start to Stop to State for the node in Value
 )
 
 func (op OperationType) String() string {
@@ -29,19 +71,206 @@ func (op OperationType) String() string {
 		return "DELETE"
 	case PING:
 		return "PING"
+	case SETEX:
+		return "SETEX"
+	case EXPIRE:
+		return "EXPIRE"
+	case TTL:
+		return "TTL"
+	case PERSIST:
+		return "PERSIST"
+	case MGET:
+		return "MGET"
+	case MSET:
+		return "MSET"
+	case MDEL:
+		return "MDEL"
+	case INCR:
+		return "INCR"
+	case DECR:
+		return "DECR"
+	case INCRBY:
+		return "INCRBY"
+	case INCRBYFLOAT:
+		return "INCRBYFLOAT"
+	case SETNX:
+		return "SETNX"
+	case SETXX:
+		return "SETXX"
+	case CAS:
+		return "CAS"
+	case GETVERSION:
+		return "GETVERSION"
+	case TX:
+		return "TX"
+	case SCAN:
+		return "SCAN"
+	case HSET:
+		return "HSET"
+	case HGET:
+		return "HGET"
+	case HDEL:
+		return "HDEL"
+	case HGETALL:
+		return "HGETALL"
+	case LPUSH:
+		return "LPUSH"
+	case RPUSH:
+		return "RPUSH"
+	case LPOP:
+		return "LPOP"
+	case RPOP:
+		return "RPOP"
+	case LRANGE:
+		return "LRANGE"
+	case LLEN:
+		return "LLEN"
+	case BLPOP:
+		return "BLPOP"
+	case BRPOP:
+		return "BRPOP"
+	case ZADD:
+		return "ZADD"
+	case ZREM:
+		return "ZREM"
+	case ZSCORE:
+		return "ZSCORE"
+	case ZINCRBY:
+		return "ZINCRBY"
+	case ZRANGE:
+		return "ZRANGE"
+	case ZRANGEBYSCORE:
+		return "ZRANGEBYSCORE"
+	case SAVE:
+		return "SAVE"
+	case REPLICATE:
+		return "REPLICATE"
+	case REPLICATION:
+		return "REPLICATION"
+	case CLUSTERSLOTS:
+		return "CLUSTERSLOTS"
+	case ASKING:
+		return "ASKING"
+	case SETSLOT:
+		return "SETSLOT"
 	default:
 		return strconv.Itoa(int(op))
 	}
 }
 
+// Idempotent operations give the same result when repeated, so identical
+// ones in a batch can be sent once.
+func (op OperationType) Idempotent() bool {
+	switch op {
+	case INCR, DECR, INCRBY, INCRBYFLOAT, SETNX, SETXX, CAS, TX, HSET, HDEL,
+		LPUSH, RPUSH, LPOP, RPOP, BLPOP, BRPOP, ZADD, ZREM, ZINCRBY:
+		return false
+	default:
+		return true
+	}
+}
+
+// ReadOnly operations change neither the keyspace nor the server.
+func (op OperationType) ReadOnly() bool {
+	switch op {
+	case PING, GET, TTL, MGET, GETVERSION, SCAN, HGET, HGETALL, LRANGE, LLEN,
+		ZSCORE, ZRANGE, ZRANGEBYSCORE, REPLICATION, CLUSTERSLOTS, ASKING:
+		return true
+	default:
+		return false
+	}
+}
+
+// Recorded operations modify the keyspace, so they are logged and sent to
+// replicas. SAVE, REPLICATE and SETSLOT change the server but not its keys.
+func (op OperationType) Recorded() bool {
+	switch op {
+	case SAVE, REPLICATE, SETSLOT:
+		return false
+	default:
+		return !op.ReadOnly()
+	}
+}
+
+// Blocking operations may wait on the server for a key to change.
+func (op OperationType) Blocking() bool {
+	return op == BLPOP || op == BRPOP
+}
+
+// Operation is a single command, each OperationType notes the fields it
+// uses beyond Key. ZSCORE reads Member and ZRANGEBYSCORE the scores between
+// Scores[0] and Scores[1]. SentAt is in unix milliseconds.
 type Operation struct {
-	Type  OperationType `msg:"type"`
-	Key   []byte        `msg:"key"`
-	Value []byte        `msg:"value"`
+	Type     OperationType `msg:"type"`
+	Key      []byte        `msg:"key"`
+	Value    []byte        `msg:"value"`
+	TTL      int64         `msg:"ttl"`
+	Keys     [][]byte      `msg:"keys,omitempty"`
+	Values   [][]byte      `msg:"values,omitempty"`
+	Delta    int64         `msg:"delta,omitempty"`
+	Version  uint64        `msg:"version,omitempty"`
+	Ops      []Operation   `msg:"ops,omitempty"`
+	Versions []uint64      `msg:"versions,omitempty"`
+	Start    int64         `msg:"start,omitempty"`
+	Stop     int64         `msg:"stop,omitempty"`
+	Pattern  []byte        `msg:"pattern,omitempty"`
+	Cursor   uint64        `msg:"cursor,omitempty"`
+	Count    int64         `msg:"count,omitempty"`
+	Member   []byte        `msg:"member,omitempty"`
+	Scores   []float64     `msg:"scores,omitempty"`
+	State    string        `msg:"state,omitempty"`
+	SentAt   int64         `msg:"sent_at,omitempty"`
+}
+
+// ClearOptional zeroes the omitempty fields, which msgp leaves untouched
+// when a reused Operation is decoded from a frame that omits them.
+func (op *Operation) ClearOptional() {
+	op.Keys = op.Keys[:0]
+	op.Values = op.Values[:0]
+	op.Delta = 0
+	op.Version = 0
+	op.Versions = op.Versions[:0]
+	op.Start = 0
+	op.Stop = 0
+	op.Pattern = op.Pattern[:0]
+	op.Cursor = 0
+	op.Count = 0
+	op.Member = op.Member[:0]
+	op.Scores = op.Scores[:0]
+	op.State = ""
+	op.SentAt = 0
+	op.Ops = nil
 }
 
 func (op Operation) Index() string {
-	return op.Type.String() + "-" + string(op.Key) + "-" + string(op.Value)
+	index := op.Type.String() + "-" + string(op.Key) + "-" + string(op.Value) +
+		"-" + strconv.FormatInt(op.TTL, 10) + "-" + strconv.FormatInt(op.Delta, 10) +
+		"-" + strconv.FormatUint(op.Version, 10) + "-" + strconv.FormatInt(op.Start, 10) +
+		"-" + strconv.FormatInt(op.Stop, 10) + "-" + strconv.FormatUint(op.Cursor, 10) +
+		"-" + strconv.FormatInt(op.Count, 10) + "-" + op.State
+	if len(op.Keys) == 0 && len(op.Values) == 0 && len(op.Pattern) == 0 && len(op.Member) == 0 &&
+		len(op.Scores) == 0 {
+		return index
+	}
+
+	var b strings.Builder
+	b.WriteString(index)
+	b.WriteString("-p" + strconv.Itoa(len(op.Pattern)) + ":")
+	b.Write(op.Pattern)
+	b.WriteString("-m" + strconv.Itoa(len(op.Member)) + ":")
+	b.Write(op.Member)
+	for _, score := range op.Scores {
+		b.WriteString("-s" + strconv.FormatFloat(score, 'g', -1, 64))
+	}
+	for _, key := range op.Keys {
+		b.WriteString("-k" + strconv.Itoa(len(key)) + ":")
+		b.Write(key)
+	}
+	for _, val := range op.Values {
+		b.WriteString("-v" + strconv.Itoa(len(val)) + ":")
+		b.Write(val)
+	}
+	return b.String()
 }
 
 type BatchedResponse struct {
@@ -53,6 +282,18 @@ type ResultStatus int
 const (
 	SUCCESS ResultStatus = iota
 	FAILURE
+	NOT_FOUND
+	WRONG_TYPE
+	OUT_OF_MEMORY
+	BATCH_TOO_LARGE
+	UNAUTHORIZED
+	INVALID_ARGUMENT
+	UNKNOWN_OPERATION
+	OVERFLOW
+	CONFLICT
+	READ_ONLY
+	MOVED
+	ASK
 )
 
 func (status ResultStatus) String() string {
@@ -61,12 +302,41 @@ func (status ResultStatus) String() string {
 		return "SUCCESS"
 	case FAILURE:
 		return "FAILURE"
+	case NOT_FOUND:
+		return "NOT_FOUND"
+	case WRONG_TYPE:
+		return "WRONG_TYPE"
+	case OUT_OF_MEMORY:
+		return "OUT_OF_MEMORY"
+	case BATCH_TOO_LARGE:
+		return "BATCH_TOO_LARGE"
+	case UNAUTHORIZED:
+		return "UNAUTHORIZED"
+	case INVALID_ARGUMENT:
+		return "INVALID_ARGUMENT"
+	case UNKNOWN_OPERATION:
+		return "UNKNOWN_OPERATION"
+	case OVERFLOW:
+		return "OVERFLOW"
+	case CONFLICT:
+		return "CONFLICT"
+	case READ_ONLY:
+		return "READ_ONLY"
+	case MOVED:
+		return "MOVED"
+	case ASK:
+		return "ASK"
 	default:
 		return strconv.Itoa(int(status))
 	}
 }
 
+// Result reports each key of a multi-key operation in Results, in the order
+// of its keys.
 type Result struct {
 	Status  ResultStatus `msg:"status"`
 	Message []byte       `msg:"message"`
+	Results []Result     `msg:"results,omitempty"`
+	Version uint64       `msg:"version,omitempty"`
+	Values  [][]byte     `msg:"values,omitempty"`
 }
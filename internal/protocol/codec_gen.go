@@ -37,50 +37,11 @@ func (z *BatchedRequest) DecodeMsg(dc *msgp.Reader) (err error) {
 				z.Operations = make([]Operation, zb0002)
 			}
 			for za0001 := range z.Operations {
-				var zb0003 uint32
-				zb0003, err = dc.ReadMapHeader()
+				err = z.Operations[za0001].DecodeMsg(dc)
 				if err != nil {
 					err = msgp.WrapError(err, "Operations", za0001)
 					return
 				}
-				for zb0003 > 0 {
-					zb0003--
-					field, err = dc.ReadMapKeyPtr()
-					if err != nil {
-						err = msgp.WrapError(err, "Operations", za0001)
-						return
-					}
-					switch msgp.UnsafeString(field) {
-					case "type":
-						{
-							var zb0004 int
-							zb0004, err = dc.ReadInt()
-							if err != nil {
-								err = msgp.WrapError(err, "Operations", za0001, "Type")
-								return
-							}
-							z.Operations[za0001].Type = OperationType(zb0004)
-						}
-					case "key":
-						z.Operations[za0001].Key, err = dc.ReadBytes(z.Operations[za0001].Key)
-						if err != nil {
-							err = msgp.WrapError(err, "Operations", za0001, "Key")
-							return
-						}
-					case "value":
-						z.Operations[za0001].Value, err = dc.ReadBytes(z.Operations[za0001].Value)
-						if err != nil {
-							err = msgp.WrapError(err, "Operations", za0001, "Value")
-							return
-						}
-					default:
-						err = dc.Skip()
-						if err != nil {
-							err = msgp.WrapError(err, "Operations", za0001)
-							return
-						}
-					}
-				}
 			}
 		default:
 			err = dc.Skip()
@@ -107,35 +68,9 @@ func (z *BatchedRequest) EncodeMsg(en *msgp.Writer) (err error) {
 		return
 	}
 	for za0001 := range z.Operations {
-		// map header, size 3
-		// write "type"
-		err = en.Append(0x83, 0xa4, 0x74, 0x79, 0x70, 0x65)
-		if err != nil {
-			return
-		}
-		err = en.WriteInt(int(z.Operations[za0001].Type))
-		if err != nil {
-			err = msgp.WrapError(err, "Operations", za0001, "Type")
-			return
-		}
-		// write "key"
-		err = en.Append(0xa3, 0x6b, 0x65, 0x79)
-		if err != nil {
-			return
-		}
-		err = en.WriteBytes(z.Operations[za0001].Key)
-		if err != nil {
-			err = msgp.WrapError(err, "Operations", za0001, "Key")
-			return
-		}
-		// write "value"
-		err = en.Append(0xa5, 0x76, 0x61, 0x6c, 0x75, 0x65)
-		if err != nil {
-			return
-		}
-		err = en.WriteBytes(z.Operations[za0001].Value)
+		err = z.Operations[za0001].EncodeMsg(en)
 		if err != nil {
-			err = msgp.WrapError(err, "Operations", za0001, "Value")
+			err = msgp.WrapError(err, "Operations", za0001)
 			return
 		}
 	}
@@ -150,16 +85,11 @@ func (z *BatchedRequest) MarshalMsg(b []byte) (o []byte, err error) {
 	o = append(o, 0x81, 0xaa, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73)
 	o = msgp.AppendArrayHeader(o, uint32(len(z.Operations)))
 	for za0001 := range z.Operations {
-		// map header, size 3
-		// string "type"
-		o = append(o, 0x83, 0xa4, 0x74, 0x79, 0x70, 0x65)
-		o = msgp.AppendInt(o, int(z.Operations[za0001].Type))
-		// string "key"
-		o = append(o, 0xa3, 0x6b, 0x65, 0x79)
-		o = msgp.AppendBytes(o, z.Operations[za0001].Key)
-		// string "value"
-		o = append(o, 0xa5, 0x76, 0x61, 0x6c, 0x75, 0x65)
-		o = msgp.AppendBytes(o, z.Operations[za0001].Value)
+		o, err = z.Operations[za0001].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Operations", za0001)
+			return
+		}
 	}
 	return
 }
@@ -195,50 +125,11 @@ func (z *BatchedRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				z.Operations = make([]Operation, zb0002)
 			}
 			for za0001 := range z.Operations {
-				var zb0003 uint32
-				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				bts, err = z.Operations[za0001].UnmarshalMsg(bts)
 				if err != nil {
 					err = msgp.WrapError(err, "Operations", za0001)
 					return
 				}
-				for zb0003 > 0 {
-					zb0003--
-					field, bts, err = msgp.ReadMapKeyZC(bts)
-					if err != nil {
-						err = msgp.WrapError(err, "Operations", za0001)
-						return
-					}
-					switch msgp.UnsafeString(field) {
-					case "type":
-						{
-							var zb0004 int
-							zb0004, bts, err = msgp.ReadIntBytes(bts)
-							if err != nil {
-								err = msgp.WrapError(err, "Operations", za0001, "Type")
-								return
-							}
-							z.Operations[za0001].Type = OperationType(zb0004)
-						}
-					case "key":
-						z.Operations[za0001].Key, bts, err = msgp.ReadBytesBytes(bts, z.Operations[za0001].Key)
-						if err != nil {
-							err = msgp.WrapError(err, "Operations", za0001, "Key")
-							return
-						}
-					case "value":
-						z.Operations[za0001].Value, bts, err = msgp.ReadBytesBytes(bts, z.Operations[za0001].Value)
-						if err != nil {
-							err = msgp.WrapError(err, "Operations", za0001, "Value")
-							return
-						}
-					default:
-						bts, err = msgp.Skip(bts)
-						if err != nil {
-							err = msgp.WrapError(err, "Operations", za0001)
-							return
-						}
-					}
-				}
 			}
 		default:
 			bts, err = msgp.Skip(bts)
@@ -256,7 +147,7 @@ func (z *BatchedRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
 func (z *BatchedRequest) Msgsize() (s int) {
 	s = 1 + 11 + msgp.ArrayHeaderSize
 	for za0001 := range z.Operations {
-		s += 1 + 5 + msgp.IntSize + 4 + msgp.BytesPrefixSize + len(z.Operations[za0001].Key) + 6 + msgp.BytesPrefixSize + len(z.Operations[za0001].Value)
+		s += z.Operations[za0001].Msgsize()
 	}
 	return
 }
@@ -292,44 +183,11 @@ func (z *BatchedResponse) DecodeMsg(dc *msgp.Reader) (err error) {
 				z.Results = make([]Result, zb0002)
 			}
 			for za0001 := range z.Results {
-				var zb0003 uint32
-				zb0003, err = dc.ReadMapHeader()
+				err = z.Results[za0001].DecodeMsg(dc)
 				if err != nil {
 					err = msgp.WrapError(err, "Results", za0001)
 					return
 				}
-				for zb0003 > 0 {
-					zb0003--
-					field, err = dc.ReadMapKeyPtr()
-					if err != nil {
-						err = msgp.WrapError(err, "Results", za0001)
-						return
-					}
-					switch msgp.UnsafeString(field) {
-					case "status":
-						{
-							var zb0004 int
-							zb0004, err = dc.ReadInt()
-							if err != nil {
-								err = msgp.WrapError(err, "Results", za0001, "Status")
-								return
-							}
-							z.Results[za0001].Status = ResultStatus(zb0004)
-						}
-					case "message":
-						z.Results[za0001].Message, err = dc.ReadBytes(z.Results[za0001].Message)
-						if err != nil {
-							err = msgp.WrapError(err, "Results", za0001, "Message")
-							return
-						}
-					default:
-						err = dc.Skip()
-						if err != nil {
-							err = msgp.WrapError(err, "Results", za0001)
-							return
-						}
-					}
-				}
 			}
 		default:
 			err = dc.Skip()
@@ -356,25 +214,9 @@ func (z *BatchedResponse) EncodeMsg(en *msgp.Writer) (err error) {
 		return
 	}
 	for za0001 := range z.Results {
-		// map header, size 2
-		// write "status"
-		err = en.Append(0x82, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
-		if err != nil {
-			return
-		}
-		err = en.WriteInt(int(z.Results[za0001].Status))
+		err = z.Results[za0001].EncodeMsg(en)
 		if err != nil {
-			err = msgp.WrapError(err, "Results", za0001, "Status")
-			return
-		}
-		// write "message"
-		err = en.Append(0xa7, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65)
-		if err != nil {
-			return
-		}
-		err = en.WriteBytes(z.Results[za0001].Message)
-		if err != nil {
-			err = msgp.WrapError(err, "Results", za0001, "Message")
+			err = msgp.WrapError(err, "Results", za0001)
 			return
 		}
 	}
@@ -389,13 +231,11 @@ func (z *BatchedResponse) MarshalMsg(b []byte) (o []byte, err error) {
 	o = append(o, 0x81, 0xa7, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73)
 	o = msgp.AppendArrayHeader(o, uint32(len(z.Results)))
 	for za0001 := range z.Results {
-		// map header, size 2
-		// string "status"
-		o = append(o, 0x82, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
-		o = msgp.AppendInt(o, int(z.Results[za0001].Status))
-		// string "message"
-		o = append(o, 0xa7, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65)
-		o = msgp.AppendBytes(o, z.Results[za0001].Message)
+		o, err = z.Results[za0001].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Results", za0001)
+			return
+		}
 	}
 	return
 }
@@ -431,44 +271,11 @@ func (z *BatchedResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				z.Results = make([]Result, zb0002)
 			}
 			for za0001 := range z.Results {
-				var zb0003 uint32
-				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				bts, err = z.Results[za0001].UnmarshalMsg(bts)
 				if err != nil {
 					err = msgp.WrapError(err, "Results", za0001)
 					return
 				}
-				for zb0003 > 0 {
-					zb0003--
-					field, bts, err = msgp.ReadMapKeyZC(bts)
-					if err != nil {
-						err = msgp.WrapError(err, "Results", za0001)
-						return
-					}
-					switch msgp.UnsafeString(field) {
-					case "status":
-						{
-							var zb0004 int
-							zb0004, bts, err = msgp.ReadIntBytes(bts)
-							if err != nil {
-								err = msgp.WrapError(err, "Results", za0001, "Status")
-								return
-							}
-							z.Results[za0001].Status = ResultStatus(zb0004)
-						}
-					case "message":
-						z.Results[za0001].Message, bts, err = msgp.ReadBytesBytes(bts, z.Results[za0001].Message)
-						if err != nil {
-							err = msgp.WrapError(err, "Results", za0001, "Message")
-							return
-						}
-					default:
-						bts, err = msgp.Skip(bts)
-						if err != nil {
-							err = msgp.WrapError(err, "Results", za0001)
-							return
-						}
-					}
-				}
 			}
 		default:
 			bts, err = msgp.Skip(bts)
@@ -486,7 +293,7 @@ func (z *BatchedResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
 func (z *BatchedResponse) Msgsize() (s int) {
 	s = 1 + 8 + msgp.ArrayHeaderSize
 	for za0001 := range z.Results {
-		s += 1 + 7 + msgp.IntSize + 8 + msgp.BytesPrefixSize + len(z.Results[za0001].Message)
+		s += z.Results[za0001].Msgsize()
 	}
 	return
 }
@@ -531,6 +338,167 @@ func (z *Operation) DecodeMsg(dc *msgp.Reader) (err error) {
 				err = msgp.WrapError(err, "Value")
 				return
 			}
+		case "ttl":
+			z.TTL, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "TTL")
+				return
+			}
+		case "keys":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Keys")
+				return
+			}
+			if cap(z.Keys) >= int(zb0003) {
+				z.Keys = (z.Keys)[:zb0003]
+			} else {
+				z.Keys = make([][]byte, zb0003)
+			}
+			for za0001 := range z.Keys {
+				z.Keys[za0001], err = dc.ReadBytes(z.Keys[za0001])
+				if err != nil {
+					err = msgp.WrapError(err, "Keys", za0001)
+					return
+				}
+			}
+		case "values":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Values")
+				return
+			}
+			if cap(z.Values) >= int(zb0004) {
+				z.Values = (z.Values)[:zb0004]
+			} else {
+				z.Values = make([][]byte, zb0004)
+			}
+			for za0002 := range z.Values {
+				z.Values[za0002], err = dc.ReadBytes(z.Values[za0002])
+				if err != nil {
+					err = msgp.WrapError(err, "Values", za0002)
+					return
+				}
+			}
+		case "delta":
+			z.Delta, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Delta")
+				return
+			}
+		case "version":
+			z.Version, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "ops":
+			var zb0005 uint32
+			zb0005, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Ops")
+				return
+			}
+			if cap(z.Ops) >= int(zb0005) {
+				z.Ops = (z.Ops)[:zb0005]
+			} else {
+				z.Ops = make([]Operation, zb0005)
+			}
+			for za0003 := range z.Ops {
+				err = z.Ops[za0003].DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Ops", za0003)
+					return
+				}
+			}
+		case "versions":
+			var zb0006 uint32
+			zb0006, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Versions")
+				return
+			}
+			if cap(z.Versions) >= int(zb0006) {
+				z.Versions = (z.Versions)[:zb0006]
+			} else {
+				z.Versions = make([]uint64, zb0006)
+			}
+			for za0004 := range z.Versions {
+				z.Versions[za0004], err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Versions", za0004)
+					return
+				}
+			}
+		case "start":
+			z.Start, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Start")
+				return
+			}
+		case "stop":
+			z.Stop, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Stop")
+				return
+			}
+		case "pattern":
+			z.Pattern, err = dc.ReadBytes(z.Pattern)
+			if err != nil {
+				err = msgp.WrapError(err, "Pattern")
+				return
+			}
+		case "cursor":
+			z.Cursor, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Cursor")
+				return
+			}
+		case "count":
+			z.Count, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Count")
+				return
+			}
+		case "member":
+			z.Member, err = dc.ReadBytes(z.Member)
+			if err != nil {
+				err = msgp.WrapError(err, "Member")
+				return
+			}
+		case "scores":
+			var zb0007 uint32
+			zb0007, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Scores")
+				return
+			}
+			if cap(z.Scores) >= int(zb0007) {
+				z.Scores = (z.Scores)[:zb0007]
+			} else {
+				z.Scores = make([]float64, zb0007)
+			}
+			for za0005 := range z.Scores {
+				z.Scores[za0005], err = dc.ReadFloat64()
+				if err != nil {
+					err = msgp.WrapError(err, "Scores", za0005)
+					return
+				}
+			}
+		case "state":
+			z.State, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "State")
+				return
+			}
+		case "sent_at":
+			z.SentAt, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "SentAt")
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -544,9 +512,80 @@ func (z *Operation) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Operation) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 3
+	// omitempty: check for empty values
+	zb0001Len := uint32(19)
+	var zb0001Mask uint32 /* 19 bits */
+	_ = zb0001Mask
+	if z.Keys == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	if z.Values == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	if z.Delta == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x40
+	}
+	if z.Version == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x80
+	}
+	if z.Ops == nil {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.Versions == nil {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
+	if z.Start == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x400
+	}
+	if z.Stop == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x800
+	}
+	if z.Pattern == nil {
+		zb0001Len--
+		zb0001Mask |= 0x1000
+	}
+	if z.Cursor == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x2000
+	}
+	if z.Count == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x4000
+	}
+	if z.Member == nil {
+		zb0001Len--
+		zb0001Mask |= 0x8000
+	}
+	if z.Scores == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10000
+	}
+	if z.State == "" {
+		zb0001Len--
+		zb0001Mask |= 0x20000
+	}
+	if z.SentAt == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x40000
+	}
+	// variable map header, size zb0001Len
+	err = en.WriteMapHeader(zb0001Len)
+	if err != nil {
+		return
+	}
+	if zb0001Len == 0 {
+		return
+	}
 	// write "type"
-	err = en.Append(0x83, 0xa4, 0x74, 0x79, 0x70, 0x65)
+	err = en.Append(0xa4, 0x74, 0x79, 0x70, 0x65)
 	if err != nil {
 		return
 	}
@@ -575,15 +614,308 @@ func (z *Operation) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err, "Value")
 		return
 	}
+	// write "ttl"
+	err = en.Append(0xa3, 0x74, 0x74, 0x6c)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.TTL)
+	if err != nil {
+		err = msgp.WrapError(err, "TTL")
+		return
+	}
+	if (zb0001Mask & 0x10) == 0 { // if not empty
+		// write "keys"
+		err = en.Append(0xa4, 0x6b, 0x65, 0x79, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Keys)))
+		if err != nil {
+			err = msgp.WrapError(err, "Keys")
+			return
+		}
+		for za0001 := range z.Keys {
+			err = en.WriteBytes(z.Keys[za0001])
+			if err != nil {
+				err = msgp.WrapError(err, "Keys", za0001)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x20) == 0 { // if not empty
+		// write "values"
+		err = en.Append(0xa6, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Values)))
+		if err != nil {
+			err = msgp.WrapError(err, "Values")
+			return
+		}
+		for za0002 := range z.Values {
+			err = en.WriteBytes(z.Values[za0002])
+			if err != nil {
+				err = msgp.WrapError(err, "Values", za0002)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x40) == 0 { // if not empty
+		// write "delta"
+		err = en.Append(0xa5, 0x64, 0x65, 0x6c, 0x74, 0x61)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Delta)
+		if err != nil {
+			err = msgp.WrapError(err, "Delta")
+			return
+		}
+	}
+	if (zb0001Mask & 0x80) == 0 { // if not empty
+		// write "version"
+		err = en.Append(0xa7, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+		if err != nil {
+			return
+		}
+		err = en.WriteUint64(z.Version)
+		if err != nil {
+			err = msgp.WrapError(err, "Version")
+			return
+		}
+	}
+	if (zb0001Mask & 0x100) == 0 { // if not empty
+		// write "ops"
+		err = en.Append(0xa3, 0x6f, 0x70, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Ops)))
+		if err != nil {
+			err = msgp.WrapError(err, "Ops")
+			return
+		}
+		for za0003 := range z.Ops {
+			err = z.Ops[za0003].EncodeMsg(en)
+			if err != nil {
+				err = msgp.WrapError(err, "Ops", za0003)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x200) == 0 { // if not empty
+		// write "versions"
+		err = en.Append(0xa8, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Versions)))
+		if err != nil {
+			err = msgp.WrapError(err, "Versions")
+			return
+		}
+		for za0004 := range z.Versions {
+			err = en.WriteUint64(z.Versions[za0004])
+			if err != nil {
+				err = msgp.WrapError(err, "Versions", za0004)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x400) == 0 { // if not empty
+		// write "start"
+		err = en.Append(0xa5, 0x73, 0x74, 0x61, 0x72, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Start)
+		if err != nil {
+			err = msgp.WrapError(err, "Start")
+			return
+		}
+	}
+	if (zb0001Mask & 0x800) == 0 { // if not empty
+		// write "stop"
+		err = en.Append(0xa4, 0x73, 0x74, 0x6f, 0x70)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Stop)
+		if err != nil {
+			err = msgp.WrapError(err, "Stop")
+			return
+		}
+	}
+	if (zb0001Mask & 0x1000) == 0 { // if not empty
+		// write "pattern"
+		err = en.Append(0xa7, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Pattern)
+		if err != nil {
+			err = msgp.WrapError(err, "Pattern")
+			return
+		}
+	}
+	if (zb0001Mask & 0x2000) == 0 { // if not empty
+		// write "cursor"
+		err = en.Append(0xa6, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72)
+		if err != nil {
+			return
+		}
+		err = en.WriteUint64(z.Cursor)
+		if err != nil {
+			err = msgp.WrapError(err, "Cursor")
+			return
+		}
+	}
+	if (zb0001Mask & 0x4000) == 0 { // if not empty
+		// write "count"
+		err = en.Append(0xa5, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.Count)
+		if err != nil {
+			err = msgp.WrapError(err, "Count")
+			return
+		}
+	}
+	if (zb0001Mask & 0x8000) == 0 { // if not empty
+		// write "member"
+		err = en.Append(0xa6, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72)
+		if err != nil {
+			return
+		}
+		err = en.WriteBytes(z.Member)
+		if err != nil {
+			err = msgp.WrapError(err, "Member")
+			return
+		}
+	}
+	if (zb0001Mask & 0x10000) == 0 { // if not empty
+		// write "scores"
+		err = en.Append(0xa6, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Scores)))
+		if err != nil {
+			err = msgp.WrapError(err, "Scores")
+			return
+		}
+		for za0005 := range z.Scores {
+			err = en.WriteFloat64(z.Scores[za0005])
+			if err != nil {
+				err = msgp.WrapError(err, "Scores", za0005)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x20000) == 0 { // if not empty
+		// write "state"
+		err = en.Append(0xa5, 0x73, 0x74, 0x61, 0x74, 0x65)
+		if err != nil {
+			return
+		}
+		err = en.WriteString(z.State)
+		if err != nil {
+			err = msgp.WrapError(err, "State")
+			return
+		}
+	}
+	if (zb0001Mask & 0x40000) == 0 { // if not empty
+		// write "sent_at"
+		err = en.Append(0xa7, 0x73, 0x65, 0x6e, 0x74, 0x5f, 0x61, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt64(z.SentAt)
+		if err != nil {
+			err = msgp.WrapError(err, "SentAt")
+			return
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Operation) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 3
+	// omitempty: check for empty values
+	zb0001Len := uint32(19)
+	var zb0001Mask uint32 /* 19 bits */
+	_ = zb0001Mask
+	if z.Keys == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	if z.Values == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	if z.Delta == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x40
+	}
+	if z.Version == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x80
+	}
+	if z.Ops == nil {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.Versions == nil {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
+	if z.Start == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x400
+	}
+	if z.Stop == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x800
+	}
+	if z.Pattern == nil {
+		zb0001Len--
+		zb0001Mask |= 0x1000
+	}
+	if z.Cursor == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x2000
+	}
+	if z.Count == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x4000
+	}
+	if z.Member == nil {
+		zb0001Len--
+		zb0001Mask |= 0x8000
+	}
+	if z.Scores == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10000
+	}
+	if z.State == "" {
+		zb0001Len--
+		zb0001Mask |= 0x20000
+	}
+	if z.SentAt == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x40000
+	}
+	// variable map header, size zb0001Len
+	o = msgp.AppendMapHeader(o, zb0001Len)
+	if zb0001Len == 0 {
+		return
+	}
 	// string "type"
-	o = append(o, 0x83, 0xa4, 0x74, 0x79, 0x70, 0x65)
+	o = append(o, 0xa4, 0x74, 0x79, 0x70, 0x65)
 	o = msgp.AppendInt(o, int(z.Type))
 	// string "key"
 	o = append(o, 0xa3, 0x6b, 0x65, 0x79)
@@ -591,6 +923,103 @@ func (z *Operation) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "value"
 	o = append(o, 0xa5, 0x76, 0x61, 0x6c, 0x75, 0x65)
 	o = msgp.AppendBytes(o, z.Value)
+	// string "ttl"
+	o = append(o, 0xa3, 0x74, 0x74, 0x6c)
+	o = msgp.AppendInt64(o, z.TTL)
+	if (zb0001Mask & 0x10) == 0 { // if not empty
+		// string "keys"
+		o = append(o, 0xa4, 0x6b, 0x65, 0x79, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Keys)))
+		for za0001 := range z.Keys {
+			o = msgp.AppendBytes(o, z.Keys[za0001])
+		}
+	}
+	if (zb0001Mask & 0x20) == 0 { // if not empty
+		// string "values"
+		o = append(o, 0xa6, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Values)))
+		for za0002 := range z.Values {
+			o = msgp.AppendBytes(o, z.Values[za0002])
+		}
+	}
+	if (zb0001Mask & 0x40) == 0 { // if not empty
+		// string "delta"
+		o = append(o, 0xa5, 0x64, 0x65, 0x6c, 0x74, 0x61)
+		o = msgp.AppendInt64(o, z.Delta)
+	}
+	if (zb0001Mask & 0x80) == 0 { // if not empty
+		// string "version"
+		o = append(o, 0xa7, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+		o = msgp.AppendUint64(o, z.Version)
+	}
+	if (zb0001Mask & 0x100) == 0 { // if not empty
+		// string "ops"
+		o = append(o, 0xa3, 0x6f, 0x70, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Ops)))
+		for za0003 := range z.Ops {
+			o, err = z.Ops[za0003].MarshalMsg(o)
+			if err != nil {
+				err = msgp.WrapError(err, "Ops", za0003)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x200) == 0 { // if not empty
+		// string "versions"
+		o = append(o, 0xa8, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Versions)))
+		for za0004 := range z.Versions {
+			o = msgp.AppendUint64(o, z.Versions[za0004])
+		}
+	}
+	if (zb0001Mask & 0x400) == 0 { // if not empty
+		// string "start"
+		o = append(o, 0xa5, 0x73, 0x74, 0x61, 0x72, 0x74)
+		o = msgp.AppendInt64(o, z.Start)
+	}
+	if (zb0001Mask & 0x800) == 0 { // if not empty
+		// string "stop"
+		o = append(o, 0xa4, 0x73, 0x74, 0x6f, 0x70)
+		o = msgp.AppendInt64(o, z.Stop)
+	}
+	if (zb0001Mask & 0x1000) == 0 { // if not empty
+		// string "pattern"
+		o = append(o, 0xa7, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e)
+		o = msgp.AppendBytes(o, z.Pattern)
+	}
+	if (zb0001Mask & 0x2000) == 0 { // if not empty
+		// string "cursor"
+		o = append(o, 0xa6, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72)
+		o = msgp.AppendUint64(o, z.Cursor)
+	}
+	if (zb0001Mask & 0x4000) == 0 { // if not empty
+		// string "count"
+		o = append(o, 0xa5, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+		o = msgp.AppendInt64(o, z.Count)
+	}
+	if (zb0001Mask & 0x8000) == 0 { // if not empty
+		// string "member"
+		o = append(o, 0xa6, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72)
+		o = msgp.AppendBytes(o, z.Member)
+	}
+	if (zb0001Mask & 0x10000) == 0 { // if not empty
+		// string "scores"
+		o = append(o, 0xa6, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Scores)))
+		for za0005 := range z.Scores {
+			o = msgp.AppendFloat64(o, z.Scores[za0005])
+		}
+	}
+	if (zb0001Mask & 0x20000) == 0 { // if not empty
+		// string "state"
+		o = append(o, 0xa5, 0x73, 0x74, 0x61, 0x74, 0x65)
+		o = msgp.AppendString(o, z.State)
+	}
+	if (zb0001Mask & 0x40000) == 0 { // if not empty
+		// string "sent_at"
+		o = append(o, 0xa7, 0x73, 0x65, 0x6e, 0x74, 0x5f, 0x61, 0x74)
+		o = msgp.AppendInt64(o, z.SentAt)
+	}
 	return
 }
 
@@ -634,6 +1063,167 @@ func (z *Operation) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "Value")
 				return
 			}
+		case "ttl":
+			z.TTL, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TTL")
+				return
+			}
+		case "keys":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Keys")
+				return
+			}
+			if cap(z.Keys) >= int(zb0003) {
+				z.Keys = (z.Keys)[:zb0003]
+			} else {
+				z.Keys = make([][]byte, zb0003)
+			}
+			for za0001 := range z.Keys {
+				z.Keys[za0001], bts, err = msgp.ReadBytesBytes(bts, z.Keys[za0001])
+				if err != nil {
+					err = msgp.WrapError(err, "Keys", za0001)
+					return
+				}
+			}
+		case "values":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Values")
+				return
+			}
+			if cap(z.Values) >= int(zb0004) {
+				z.Values = (z.Values)[:zb0004]
+			} else {
+				z.Values = make([][]byte, zb0004)
+			}
+			for za0002 := range z.Values {
+				z.Values[za0002], bts, err = msgp.ReadBytesBytes(bts, z.Values[za0002])
+				if err != nil {
+					err = msgp.WrapError(err, "Values", za0002)
+					return
+				}
+			}
+		case "delta":
+			z.Delta, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Delta")
+				return
+			}
+		case "version":
+			z.Version, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "ops":
+			var zb0005 uint32
+			zb0005, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Ops")
+				return
+			}
+			if cap(z.Ops) >= int(zb0005) {
+				z.Ops = (z.Ops)[:zb0005]
+			} else {
+				z.Ops = make([]Operation, zb0005)
+			}
+			for za0003 := range z.Ops {
+				bts, err = z.Ops[za0003].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Ops", za0003)
+					return
+				}
+			}
+		case "versions":
+			var zb0006 uint32
+			zb0006, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Versions")
+				return
+			}
+			if cap(z.Versions) >= int(zb0006) {
+				z.Versions = (z.Versions)[:zb0006]
+			} else {
+				z.Versions = make([]uint64, zb0006)
+			}
+			for za0004 := range z.Versions {
+				z.Versions[za0004], bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Versions", za0004)
+					return
+				}
+			}
+		case "start":
+			z.Start, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Start")
+				return
+			}
+		case "stop":
+			z.Stop, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Stop")
+				return
+			}
+		case "pattern":
+			z.Pattern, bts, err = msgp.ReadBytesBytes(bts, z.Pattern)
+			if err != nil {
+				err = msgp.WrapError(err, "Pattern")
+				return
+			}
+		case "cursor":
+			z.Cursor, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Cursor")
+				return
+			}
+		case "count":
+			z.Count, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Count")
+				return
+			}
+		case "member":
+			z.Member, bts, err = msgp.ReadBytesBytes(bts, z.Member)
+			if err != nil {
+				err = msgp.WrapError(err, "Member")
+				return
+			}
+		case "scores":
+			var zb0007 uint32
+			zb0007, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Scores")
+				return
+			}
+			if cap(z.Scores) >= int(zb0007) {
+				z.Scores = (z.Scores)[:zb0007]
+			} else {
+				z.Scores = make([]float64, zb0007)
+			}
+			for za0005 := range z.Scores {
+				z.Scores[za0005], bts, err = msgp.ReadFloat64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Scores", za0005)
+					return
+				}
+			}
+		case "state":
+			z.State, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "State")
+				return
+			}
+		case "sent_at":
+			z.SentAt, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SentAt")
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -648,7 +1238,19 @@ func (z *Operation) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *Operation) Msgsize() (s int) {
-	s = 1 + 5 + msgp.IntSize + 4 + msgp.BytesPrefixSize + len(z.Key) + 6 + msgp.BytesPrefixSize + len(z.Value)
+	s = 3 + 5 + msgp.IntSize + 4 + msgp.BytesPrefixSize + len(z.Key) + 6 + msgp.BytesPrefixSize + len(z.Value) + 4 + msgp.Int64Size + 5 + msgp.ArrayHeaderSize
+	for za0001 := range z.Keys {
+		s += msgp.BytesPrefixSize + len(z.Keys[za0001])
+	}
+	s += 7 + msgp.ArrayHeaderSize
+	for za0002 := range z.Values {
+		s += msgp.BytesPrefixSize + len(z.Values[za0002])
+	}
+	s += 6 + msgp.Int64Size + 8 + msgp.Uint64Size + 4 + msgp.ArrayHeaderSize
+	for za0003 := range z.Ops {
+		s += z.Ops[za0003].Msgsize()
+	}
+	s += 9 + msgp.ArrayHeaderSize + (len(z.Versions) * (msgp.Uint64Size)) + 6 + msgp.Int64Size + 5 + msgp.Int64Size + 8 + msgp.BytesPrefixSize + len(z.Pattern) + 7 + msgp.Uint64Size + 6 + msgp.Int64Size + 7 + msgp.BytesPrefixSize + len(z.Member) + 7 + msgp.ArrayHeaderSize + (len(z.Scores) * (msgp.Float64Size)) + 6 + msgp.StringPrefixSize + len(z.State) + 8 + msgp.Int64Size
 	return
 }
 
@@ -738,6 +1340,50 @@ func (z *Result) DecodeMsg(dc *msgp.Reader) (err error) {
 				err = msgp.WrapError(err, "Message")
 				return
 			}
+		case "results":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Results")
+				return
+			}
+			if cap(z.Results) >= int(zb0003) {
+				z.Results = (z.Results)[:zb0003]
+			} else {
+				z.Results = make([]Result, zb0003)
+			}
+			for za0001 := range z.Results {
+				err = z.Results[za0001].DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Results", za0001)
+					return
+				}
+			}
+		case "version":
+			z.Version, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "values":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Values")
+				return
+			}
+			if cap(z.Values) >= int(zb0004) {
+				z.Values = (z.Values)[:zb0004]
+			} else {
+				z.Values = make([][]byte, zb0004)
+			}
+			for za0002 := range z.Values {
+				z.Values[za0002], err = dc.ReadBytes(z.Values[za0002])
+				if err != nil {
+					err = msgp.WrapError(err, "Values", za0002)
+					return
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -751,9 +1397,32 @@ func (z *Result) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Result) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 2
+	// omitempty: check for empty values
+	zb0001Len := uint32(5)
+	var zb0001Mask uint8 /* 5 bits */
+	_ = zb0001Mask
+	if z.Results == nil {
+		zb0001Len--
+		zb0001Mask |= 0x4
+	}
+	if z.Version == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x8
+	}
+	if z.Values == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	// variable map header, size zb0001Len
+	err = en.Append(0x80 | uint8(zb0001Len))
+	if err != nil {
+		return
+	}
+	if zb0001Len == 0 {
+		return
+	}
 	// write "status"
-	err = en.Append(0x82, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
+	err = en.Append(0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
 	if err != nil {
 		return
 	}
@@ -772,19 +1441,114 @@ func (z *Result) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err, "Message")
 		return
 	}
+	if (zb0001Mask & 0x4) == 0 { // if not empty
+		// write "results"
+		err = en.Append(0xa7, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Results)))
+		if err != nil {
+			err = msgp.WrapError(err, "Results")
+			return
+		}
+		for za0001 := range z.Results {
+			err = z.Results[za0001].EncodeMsg(en)
+			if err != nil {
+				err = msgp.WrapError(err, "Results", za0001)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x8) == 0 { // if not empty
+		// write "version"
+		err = en.Append(0xa7, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+		if err != nil {
+			return
+		}
+		err = en.WriteUint64(z.Version)
+		if err != nil {
+			err = msgp.WrapError(err, "Version")
+			return
+		}
+	}
+	if (zb0001Mask & 0x10) == 0 { // if not empty
+		// write "values"
+		err = en.Append(0xa6, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73)
+		if err != nil {
+			return
+		}
+		err = en.WriteArrayHeader(uint32(len(z.Values)))
+		if err != nil {
+			err = msgp.WrapError(err, "Values")
+			return
+		}
+		for za0002 := range z.Values {
+			err = en.WriteBytes(z.Values[za0002])
+			if err != nil {
+				err = msgp.WrapError(err, "Values", za0002)
+				return
+			}
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Result) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 2
+	// omitempty: check for empty values
+	zb0001Len := uint32(5)
+	var zb0001Mask uint8 /* 5 bits */
+	_ = zb0001Mask
+	if z.Results == nil {
+		zb0001Len--
+		zb0001Mask |= 0x4
+	}
+	if z.Version == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x8
+	}
+	if z.Values == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	// variable map header, size zb0001Len
+	o = append(o, 0x80|uint8(zb0001Len))
+	if zb0001Len == 0 {
+		return
+	}
 	// string "status"
-	o = append(o, 0x82, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
+	o = append(o, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
 	o = msgp.AppendInt(o, int(z.Status))
 	// string "message"
 	o = append(o, 0xa7, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65)
 	o = msgp.AppendBytes(o, z.Message)
+	if (zb0001Mask & 0x4) == 0 { // if not empty
+		// string "results"
+		o = append(o, 0xa7, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Results)))
+		for za0001 := range z.Results {
+			o, err = z.Results[za0001].MarshalMsg(o)
+			if err != nil {
+				err = msgp.WrapError(err, "Results", za0001)
+				return
+			}
+		}
+	}
+	if (zb0001Mask & 0x8) == 0 { // if not empty
+		// string "version"
+		o = append(o, 0xa7, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+		o = msgp.AppendUint64(o, z.Version)
+	}
+	if (zb0001Mask & 0x10) == 0 { // if not empty
+		// string "values"
+		o = append(o, 0xa6, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Values)))
+		for za0002 := range z.Values {
+			o = msgp.AppendBytes(o, z.Values[za0002])
+		}
+	}
 	return
 }
 
@@ -822,6 +1586,50 @@ func (z *Result) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "Message")
 				return
 			}
+		case "results":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Results")
+				return
+			}
+			if cap(z.Results) >= int(zb0003) {
+				z.Results = (z.Results)[:zb0003]
+			} else {
+				z.Results = make([]Result, zb0003)
+			}
+			for za0001 := range z.Results {
+				bts, err = z.Results[za0001].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Results", za0001)
+					return
+				}
+			}
+		case "version":
+			z.Version, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "values":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Values")
+				return
+			}
+			if cap(z.Values) >= int(zb0004) {
+				z.Values = (z.Values)[:zb0004]
+			} else {
+				z.Values = make([][]byte, zb0004)
+			}
+			for za0002 := range z.Values {
+				z.Values[za0002], bts, err = msgp.ReadBytesBytes(bts, z.Values[za0002])
+				if err != nil {
+					err = msgp.WrapError(err, "Values", za0002)
+					return
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -836,7 +1644,14 @@ func (z *Result) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *Result) Msgsize() (s int) {
-	s = 1 + 7 + msgp.IntSize + 8 + msgp.BytesPrefixSize + len(z.Message)
+	s = 1 + 7 + msgp.IntSize + 8 + msgp.BytesPrefixSize + len(z.Message) + 8 + msgp.ArrayHeaderSize
+	for za0001 := range z.Results {
+		s += z.Results[za0001].Msgsize()
+	}
+	s += 8 + msgp.Uint64Size + 7 + msgp.ArrayHeaderSize
+	for za0002 := range z.Values {
+		s += msgp.BytesPrefixSize + len(z.Values[za0002])
+	}
 	return
 }
 
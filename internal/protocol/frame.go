@@ -0,0 +1,32 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+func AppendFrame(dst []byte, payload []byte) []byte {
+	var header [constants.HeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(payload)))
+	dst = append(dst, header[:]...)
+	return append(dst, payload...)
+}
+
+func NextFrame(buf []byte) ([]byte, bool, error) {
+	if len(buf) < constants.HeaderSize {
+		return nil, false, nil
+	}
+
+	length := binary.LittleEndian.Uint32(buf[:constants.HeaderSize])
+	if length > constants.MaxFrameSize {
+		return nil, false, fmt.Errorf(constants.FrameTooLargeErr, length, constants.MaxFrameSize)
+	}
+
+	end := constants.HeaderSize + int(length)
+	if len(buf) < end {
+		return nil, false, nil
+	}
+	return buf[constants.HeaderSize:end], true, nil
+}
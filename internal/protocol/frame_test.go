@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kevindweb/cache/internal/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrames(t *testing.T) {
+	t.Parallel()
+	stream := AppendFrame(nil, []byte("first"))
+	stream = AppendFrame(stream, []byte{})
+	stream = AppendFrame(stream, []byte("third"))
+
+	payloads := []string{}
+	for {
+		payload, ok, err := NextFrame(stream)
+		assert.NoError(t, err)
+		if !ok {
+			break
+		}
+		payloads = append(payloads, string(payload))
+		stream = stream[constants.HeaderSize+len(payload):]
+	}
+	assert.Equal(t, []string{"first", "", "third"}, payloads)
+	assert.Empty(t, stream)
+}
+
+func TestPartialFrame(t *testing.T) {
+	t.Parallel()
+	frame := AppendFrame(nil, []byte("payload"))
+	for i := 0; i < len(frame); i++ {
+		_, ok, err := NextFrame(frame[:i])
+		assert.NoError(t, err)
+		assert.False(t, ok, "frame should be incomplete after %d bytes", i)
+	}
+}
+
+func TestFrameTooLarge(t *testing.T) {
+	t.Parallel()
+	frame := AppendFrame(nil, nil)
+	frame[0], frame[1], frame[2], frame[3] = 0xff, 0xff, 0xff, 0xff
+	_, ok, err := NextFrame(frame)
+	assert.False(t, ok)
+	assert.EqualError(t, err, fmt.Sprintf(constants.FrameTooLargeErr, 1<<32-1, constants.MaxFrameSize))
+}
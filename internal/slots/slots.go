@@ -0,0 +1,145 @@
+package slots
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+const Count = 16384
+
+const (
+	StateNode      = "node"
+	StateMigrating = "migrating"
+	StateImporting = "importing"
+	StateStable    = "stable"
+)
+
+const (
+	hashTagOpen  = '{'
+	hashTagClose = '}'
+	polynomial   = 0x1021
+)
+
+var table = func() [256]uint16 { //nolint:gochecknoglobals // computed once like a const
+	var t [256]uint16
+	for i := range t {
+		crc := uint16(i) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ polynomial
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}()
+
+// crc16 is CRC-16/XMODEM, so keys land in the same slots as on Redis.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = crc<<8 ^ table[byte(crc>>8)^b]
+	}
+	return crc
+}
+
+// Slot hashes only the {tag} of key when it has one.
+func Slot(key []byte) uint16 {
+	if start := bytes.IndexByte(key, hashTagOpen); start >= 0 {
+		if end := bytes.IndexByte(key[start+1:], hashTagClose); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return crc16(key) % Count
+}
+
+type Range struct {
+	Start uint16
+	End   uint16
+	Addr  string
+}
+
+type Table [Count]string
+
+func NewTable(ranges []Range) (*Table, error) {
+	var t Table
+	for _, r := range ranges {
+		if r.Start > r.End || r.End >= Count {
+			return nil, fmt.Errorf(constants.InvalidSlotRangeErr, r.Start, r.End, Count-1)
+		}
+
+		if r.Addr == "" {
+			return nil, fmt.Errorf(constants.MissingSlotAddrErr, r.Start, r.End)
+		}
+
+		for slot := int(r.Start); slot <= int(r.End); slot++ {
+			if t[slot] != "" && t[slot] != r.Addr {
+				return nil, fmt.Errorf(constants.OverlappingSlotErr, slot, t[slot], r.Addr)
+			}
+			t[slot] = r.Addr
+		}
+	}
+	return &t, nil
+}
+
+func (t *Table) Ranges() []Range {
+	var ranges []Range
+	for slot := 0; slot < Count; slot++ {
+		addr := t[slot]
+		if addr == "" {
+			continue
+		}
+
+		if n := len(ranges); n > 0 && ranges[n-1].Addr == addr && int(ranges[n-1].End) == slot-1 {
+			ranges[n-1].End = uint16(slot)
+			continue
+		}
+		ranges = append(ranges, Range{Start: uint16(slot), End: uint16(slot), Addr: addr})
+	}
+	return ranges
+}
+
+func AppendRanges(dst [][]byte, ranges []Range) [][]byte {
+	for _, r := range ranges {
+		dst = append(dst,
+			strconv.AppendUint(nil, uint64(r.Start), 10),
+			strconv.AppendUint(nil, uint64(r.End), 10),
+			[]byte(r.Addr),
+		)
+	}
+	return dst
+}
+
+func ParseRanges(values [][]byte) ([]Range, error) {
+	if len(values)%3 != 0 {
+		return nil, fmt.Errorf(constants.InvalidSlotTriplesErr, len(values))
+	}
+
+	ranges := make([]Range, 0, len(values)/3)
+	for i := 0; i < len(values); i += 3 {
+		start, err := parseSlot(values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseSlot(values[i+1])
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, Range{Start: start, End: end, Addr: string(values[i+2])})
+	}
+	return ranges, nil
+}
+
+func parseSlot(value []byte) (uint16, error) {
+	slot, err := strconv.ParseUint(string(value), 10, 16)
+	if err != nil || slot >= Count {
+		return 0, fmt.Errorf(constants.InvalidSlotErr, value)
+	}
+	return uint16(slot), nil
+}
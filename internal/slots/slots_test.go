@@ -0,0 +1,54 @@
+package slots
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlot(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, uint16(0x31c3), crc16([]byte("123456789")))
+	assert.Equal(t, uint16(12182), Slot([]byte("foo")))
+	assert.Equal(t, uint16(0), Slot([]byte("")))
+
+	// only a non-empty hash tag is hashed
+	assert.Equal(t, Slot([]byte("user1000")), Slot([]byte("{user1000}.following")))
+	assert.Equal(t, Slot([]byte("{user1000}.followers")), Slot([]byte("{user1000}.following")))
+	assert.Equal(t, Slot([]byte("{}foo")), crc16([]byte("{}foo"))%Count)
+	assert.Equal(t, Slot([]byte("foo{")), crc16([]byte("foo{"))%Count)
+}
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+	ranges := []Range{
+		{Start: 0, End: 99, Addr: "a:1"},
+		{Start: 100, End: 199, Addr: "b:1"},
+		{Start: 300, End: Count - 1, Addr: "a:1"},
+	}
+	table, err := NewTable(ranges)
+	require.NoError(t, err)
+	assert.Equal(t, "b:1", table[150])
+	assert.Equal(t, "", table[250])
+	assert.Equal(t, ranges, table.Ranges())
+
+	decoded, err := ParseRanges(AppendRanges(nil, ranges))
+	require.NoError(t, err)
+	assert.Equal(t, ranges, decoded)
+
+	for _, invalid := range [][]Range{
+		{{Start: 10, End: 5, Addr: "a:1"}},
+		{{Start: 0, End: Count, Addr: "a:1"}},
+		{{Start: 0, End: 5}},
+		{{Start: 0, End: 5, Addr: "a:1"}, {Start: 5, End: 6, Addr: "b:1"}},
+	} {
+		_, err = NewTable(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	_, err = ParseRanges([][]byte{[]byte("0"), []byte("1")})
+	assert.Error(t, err)
+	_, err = ParseRanges([][]byte{[]byte("0"), []byte("16384"), []byte("a:1")})
+	assert.Error(t, err)
+}
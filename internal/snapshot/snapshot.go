@@ -0,0 +1,234 @@
+package snapshot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/storage"
+	"github.com/kevindweb/cache/internal/util"
+)
+
+//go:generate msgp
+
+const (
+	FormatVersion = 1
+
+	magic        = "CSNP"
+	checksumSize = 4
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Hash
+	List
+	ZSet
+)
+
+type Snapshot struct {
+	Version int     `msg:"version"`
+	Created int64   `msg:"created"`
+	Entries []Entry `msg:"entries"`
+}
+
+// Entry keeps hash fields and sorted set members in Fields, with their
+// values in Values and scores in Scores.
+type Entry struct {
+	Key      []byte    `msg:"key"`
+	Kind     Kind      `msg:"kind"`
+	Value    []byte    `msg:"value,omitempty"`
+	Fields   [][]byte  `msg:"fields,omitempty"`
+	Values   [][]byte  `msg:"values,omitempty"`
+	Scores   []float64 `msg:"scores,omitempty"`
+	ExpireAt int64     `msg:"expire_at,omitempty"`
+}
+
+var table = crc32.MakeTable(crc32.Castagnoli)
+
+// Encode shares values with kv until it returns, so kv must not change
+// meanwhile.
+func Encode(kv storage.KeyValue) ([]byte, error) {
+	snap, err := dump(kv, false)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Encode()
+}
+
+// Take copies the keys of kv, so the snapshot can be encoded later.
+func Take(kv storage.KeyValue) (*Snapshot, error) {
+	return dump(kv, true)
+}
+
+func (snap *Snapshot) Encode() ([]byte, error) {
+	data, err := snap.MarshalMsg([]byte(magic))
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint32(data, checksum(data)), nil
+}
+
+func dump(kv storage.KeyValue, copied bool) (*Snapshot, error) {
+	now := time.Now()
+	snap := &Snapshot{Version: FormatVersion, Created: now.UnixMilli()}
+	var err error
+	kv.Range(func(key []byte, value []byte, obj storage.Object, ttl time.Duration) bool {
+		entry := Entry{Key: key, Value: value}
+		if ttl != constants.NoExpiration {
+			entry.ExpireAt = now.Add(ttl).UnixMilli()
+		}
+
+		switch obj := obj.(type) {
+		case nil:
+			entry.Kind = String
+		case *storage.Hash:
+			entry.Kind = Hash
+			entry.Fields, entry.Values = obj.All()
+		case *storage.List:
+			entry.Kind = List
+			entry.Values = obj.Range(0, -1)
+		case *storage.ZSet:
+			entry.Kind = ZSet
+			entry.Fields, entry.Scores = obj.Range(0, -1)
+		default:
+			err = fmt.Errorf(constants.SnapshotObjectErr, obj.Type(), key)
+			return false
+		}
+
+		if copied {
+			entry.Key, entry.Value = clone(entry.Key), clone(entry.Value)
+			for i, value := range entry.Values {
+				entry.Values[i] = clone(value)
+			}
+		}
+		snap.Entries = append(snap.Entries, entry)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func checksum(data []byte) uint32 {
+	return crc32.Checksum(data, table)
+}
+
+func Decode(data []byte, kv storage.KeyValue) (int, error) {
+	if len(data) < len(magic)+checksumSize {
+		return 0, fmt.Errorf(constants.SnapshotTruncatedErr, len(data))
+	}
+
+	if string(data[:len(magic)]) != magic {
+		return 0, errors.New(constants.SnapshotMagicErr)
+	}
+
+	body, sum := data[:len(data)-checksumSize], binary.BigEndian.Uint32(data[len(data)-checksumSize:])
+	if actual := checksum(body); actual != sum {
+		return 0, fmt.Errorf(constants.SnapshotChecksumErr, sum, actual)
+	}
+
+	var snap Snapshot
+	if _, err := snap.UnmarshalMsg(body[len(magic):]); err != nil {
+		return 0, err
+	}
+
+	if snap.Version != FormatVersion {
+		return 0, fmt.Errorf(constants.SnapshotVersionErr, snap.Version, FormatVersion)
+	}
+
+	restored := 0
+	now := time.Now().UnixMilli()
+	for _, entry := range snap.Entries {
+		ttl := constants.NoExpiration
+		if entry.ExpireAt != 0 {
+			if entry.ExpireAt <= now {
+				continue
+			}
+			ttl = time.Duration(entry.ExpireAt-now) * time.Millisecond
+		}
+
+		if err := restore(kv, entry, ttl); err != nil {
+			return restored, fmt.Errorf(constants.RestoreKeyErr, entry.Key, err)
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+func restore(kv storage.KeyValue, entry Entry, ttl time.Duration) error {
+	var err error
+	switch entry.Kind {
+	case String:
+		if ttl != constants.NoExpiration {
+			return kv.SetWithTTL(entry.Key, entry.Value, ttl)
+		}
+		return kv.Set(entry.Key, entry.Value)
+	case Hash:
+		if len(entry.Fields) != len(entry.Values) {
+			return fmt.Errorf(constants.SnapshotFieldsErr, len(entry.Fields), len(entry.Values), entry.Key)
+		}
+		_, _, err = storage.HSet(kv, entry.Key, entry.Fields, entry.Values)
+	case List:
+		_, err = storage.RPush(kv, entry.Key, entry.Values)
+	case ZSet:
+		if len(entry.Fields) != len(entry.Scores) {
+			return fmt.Errorf(constants.SnapshotFieldsErr, len(entry.Fields), len(entry.Scores), entry.Key)
+		}
+		_, _, err = storage.ZAdd(kv, entry.Key, entry.Fields, entry.Scores)
+	default:
+		return fmt.Errorf(constants.SnapshotKindErr, entry.Kind, entry.Key)
+	}
+
+	if err != nil || ttl == constants.NoExpiration {
+		return err
+	}
+	return kv.Expire(entry.Key, ttl)
+}
+
+// Save renames a synced temporary file into place and syncs the directory,
+// so a crash never leaves a partial or an older snapshot at path.
+func Save(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return util.SyncDir(filepath.Dir(path))
+}
+
+func Load(path string, kv storage.KeyValue) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return Decode(data, kv)
+}
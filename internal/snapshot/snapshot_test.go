@@ -0,0 +1,163 @@
+package snapshot
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bytesOf(values ...string) [][]byte {
+	out := make([][]byte, len(values))
+	for i, value := range values {
+		out[i] = []byte(value)
+	}
+	return out
+}
+
+func populate(t *testing.T, kv storage.KeyValue) {
+	require.NoError(t, kv.Set([]byte("str"), []byte("val")))
+	require.NoError(t, kv.SetWithTTL([]byte("ttl"), []byte("val"), time.Minute))
+	require.NoError(t, kv.SetWithTTL([]byte("expired"), []byte("val"), time.Millisecond))
+	_, _, err := storage.HSet(kv, []byte("hash"), bytesOf("a", "b"), bytesOf("1", "2"))
+	require.NoError(t, err)
+	require.NoError(t, kv.Expire([]byte("hash"), time.Minute))
+	_, err = storage.RPush(kv, []byte("list"), bytesOf("x", "y", "z"))
+	require.NoError(t, err)
+	_, _, err = storage.ZAdd(kv, []byte("zset"), bytesOf("m", "n"), []float64{2.5, -1})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+	sources := []storage.KeyValue{
+		storage.NewCacheMap(),
+		storage.NewShardedMap(storage.DefaultShards),
+		storage.NewLockedMap(storage.NewBoundedCache(storage.NewCacheMap(), 1<<20, storage.LRU)),
+	}
+	for _, src := range sources {
+		populate(t, src)
+		data, err := Encode(src)
+		require.NoError(t, err)
+
+		dst := storage.NewCacheMap()
+		restored, err := Decode(data, dst)
+		require.NoError(t, err)
+		assert.Equal(t, 5, restored, "expired keys are left out")
+
+		val, err := dst.Get([]byte("str"))
+		assert.NoError(t, err)
+		assert.Equal(t, "val", string(val))
+		ttl, err := dst.TTL([]byte("str"))
+		assert.NoError(t, err)
+		assert.Equal(t, constants.NoExpiration, ttl)
+
+		_, err = dst.Get([]byte("expired"))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		for _, key := range []string{"ttl", "hash"} {
+			ttl, err = dst.TTL([]byte(key))
+			assert.NoError(t, err)
+			assert.Greater(t, ttl, 50*time.Second, key)
+		}
+
+		fields, values, err := storage.HGetAll(dst, []byte("hash"))
+		assert.NoError(t, err)
+		assert.Equal(t, bytesOf("a", "b"), fields)
+		assert.Equal(t, bytesOf("1", "2"), values)
+
+		list, err := storage.LRange(dst, []byte("list"), 0, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, bytesOf("x", "y", "z"), list)
+
+		members, scores, err := storage.ZRange(dst, []byte("zset"), 0, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, bytesOf("n", "m"), members)
+		assert.Equal(t, []float64{-1, 2.5}, scores)
+	}
+}
+
+func TestTake(t *testing.T) {
+	t.Parallel()
+	src := storage.NewCacheMap()
+	populate(t, src)
+	snap, err := Take(src)
+	require.NoError(t, err)
+
+	// the copy outlives every later write and the keyspace itself
+	require.NoError(t, src.Set([]byte("str"), []byte("new")))
+	_, err = storage.RPush(src, []byte("list"), bytesOf("w"))
+	require.NoError(t, err)
+	require.NoError(t, src.Free())
+
+	data, err := snap.Encode()
+	require.NoError(t, err)
+	dst := storage.NewCacheMap()
+	restored, err := Decode(data, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 5, restored)
+
+	val, err := dst.Get([]byte("str"))
+	assert.NoError(t, err)
+	assert.Equal(t, "val", string(val))
+	list, err := storage.LRange(dst, []byte("list"), 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, bytesOf("x", "y", "z"), list)
+}
+
+func TestDecodeRejectsCorruption(t *testing.T) {
+	t.Parallel()
+	kv := storage.NewCacheMap()
+	populate(t, kv)
+	data, err := Encode(kv)
+	require.NoError(t, err)
+
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	_, err = Decode(corrupt, storage.NewCacheMap())
+	assert.ErrorContains(t, err, "checksum")
+
+	_, err = Decode(data[:len(data)-1], storage.NewCacheMap())
+	assert.Error(t, err)
+	_, err = Decode([]byte("CS"), storage.NewCacheMap())
+	assert.ErrorContains(t, err, "truncated")
+	_, err = Decode(append([]byte("XXXX"), data[4:]...), storage.NewCacheMap())
+	assert.EqualError(t, err, constants.SnapshotMagicErr)
+
+	snap := Snapshot{Version: FormatVersion + 1}
+	future, err := snap.MarshalMsg([]byte(magic))
+	require.NoError(t, err)
+	future = append(future, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(future[len(future)-checksumSize:], checksum(future[:len(future)-checksumSize]))
+	_, err = Decode(future, storage.NewCacheMap())
+	assert.ErrorContains(t, err, "format version 2")
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "dump.snap")
+	_, err := Load(path, storage.NewCacheMap())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	kv := storage.NewCacheMap()
+	populate(t, kv)
+	data, err := Encode(kv)
+	require.NoError(t, err)
+	require.NoError(t, Save(path, data))
+
+	restored, err := Load(path, storage.NewCacheMap())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, restored)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+}
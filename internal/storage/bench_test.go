@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+const benchKeys = 1024
+
+func benchCaches() map[string]KeyValue {
+	return map[string]KeyValue{
+		// a single shard is a CacheMap behind one lock
+		"CacheMap":   NewShardedMap(1),
+		"ShardedMap": NewShardedMap(DefaultShards),
+	}
+}
+
+func benchKeySet() [][]byte {
+	keys := make([][]byte, benchKeys)
+	for i := range keys {
+		keys[i] = []byte("key-" + strconv.Itoa(i))
+	}
+	return keys
+}
+
+func BenchmarkParallelSet(b *testing.B) {
+	keys := benchKeySet()
+	value := []byte("value")
+	for name, cache := range benchCaches() {
+		cache := cache
+		b.Run(name, func(b *testing.B) {
+			var counter uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := atomic.AddUint64(&counter, 1)
+					if err := cache.Set(keys[i%benchKeys], value); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkParallelGet(b *testing.B) {
+	keys := benchKeySet()
+	for name, cache := range benchCaches() {
+		cache := cache
+		for _, key := range keys {
+			if err := cache.Set(key, key); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Run(name, func(b *testing.B) {
+			var counter uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := atomic.AddUint64(&counter, 1)
+					if _, err := cache.Get(keys[i%benchKeys]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkParallelMixed(b *testing.B) {
+	keys := benchKeySet()
+	value := []byte("value")
+	for name, cache := range benchCaches() {
+		cache := cache
+		b.Run(name, func(b *testing.B) {
+			var counter uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := atomic.AddUint64(&counter, 1)
+					key := keys[i%benchKeys]
+					if i%4 == 0 {
+						_ = cache.Set(key, value)
+					} else {
+						_, _ = cache.Get(key)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package storage
+
+import (
+	"container/heap"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+type EvictionPolicy int
+
+const (
+	NoEviction EvictionPolicy = iota
+	LRU
+	LFU
+	Random
+	VolatileTTL
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case NoEviction:
+		return "NOEVICTION"
+	case LRU:
+		return "LRU"
+	case LFU:
+		return "LFU"
+	case Random:
+		return "RANDOM"
+	case VolatileTTL:
+		return "VOLATILE_TTL"
+	default:
+		return strconv.Itoa(int(p))
+	}
+}
+
+func (p EvictionPolicy) Valid() bool {
+	return p >= NoEviction && p <= VolatileTTL
+}
+
+type EvictFunc func(key []byte)
+
+type BoundedCache struct {
+	kv       KeyValue
+	maxBytes int64
+	used     int64
+	policy   EvictionPolicy
+	onEvict  EvictFunc
+	clock    uint64
+	keys     map[string]*keyMeta
+	volatile map[string]*keyMeta
+	queue    evictionQueue
+}
+
+type keyMeta struct {
+	key      string
+	size     int64
+	access   uint64
+	hits     uint64
+	expireAt int64
+	index    int
+}
+
+func NewBoundedCache(kv KeyValue, maxBytes int64, policy EvictionPolicy) KeyValue {
+	return NewNotifyingCache(kv, maxBytes, policy, nil)
+}
+
+func NewNotifyingCache(kv KeyValue, maxBytes int64, policy EvictionPolicy, onEvict EvictFunc) KeyValue {
+	return &BoundedCache{
+		kv:       kv,
+		maxBytes: maxBytes,
+		policy:   policy,
+		onEvict:  onEvict,
+		keys:     map[string]*keyMeta{},
+		volatile: map[string]*keyMeta{},
+		queue:    evictionQueue{policy: policy},
+	}
+}
+
+func (b *BoundedCache) New() KeyValue {
+	return NewNotifyingCache(b.kv.New(), b.maxBytes, b.policy, b.onEvict)
+}
+
+func (b *BoundedCache) Free() error {
+	b.used = 0
+	b.keys = map[string]*keyMeta{}
+	b.volatile = map[string]*keyMeta{}
+	b.queue.items = nil
+	return b.kv.Free()
+}
+
+func (b *BoundedCache) Used() int64 {
+	return b.used
+}
+
+func (b *BoundedCache) Set(key []byte, value []byte) error {
+	size := entrySize(key, value)
+	if err := b.reserve(string(key), size); err != nil {
+		return err
+	}
+
+	if err := b.kv.Set(key, value); err != nil {
+		return err
+	}
+
+	b.record(string(key), size, 0)
+	return nil
+}
+
+func (b *BoundedCache) SetWithTTL(key []byte, value []byte, ttl time.Duration) error {
+	size := entrySize(key, value)
+	if err := b.reserve(string(key), size); err != nil {
+		return err
+	}
+
+	if err := b.kv.SetWithTTL(key, value, ttl); err != nil {
+		return err
+	}
+
+	b.record(string(key), size, expireAt(ttl))
+	return nil
+}
+
+// SetIf checks cond before reserving memory so a rejected write never
+// evicts other keys.
+func (b *BoundedCache) SetIf(key []byte, value []byte, ttl time.Duration, cond Condition) (uint64, error) {
+	_, current, err := b.kv.GetVersion(key)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrWrongType) {
+		return 0, err
+	}
+
+	if err = cond(key, current); err != nil {
+		return 0, err
+	}
+
+	size := entrySize(key, value)
+	if err = b.reserve(string(key), size); err != nil {
+		return 0, err
+	}
+
+	version, err := b.kv.SetIf(key, value, ttl, cond)
+	if err != nil {
+		return 0, err
+	}
+
+	expiry := int64(0)
+	if ttl > 0 {
+		expiry = expireAt(ttl)
+	}
+	b.record(string(key), size, expiry)
+	return version, nil
+}
+
+func (b *BoundedCache) Restore(key []byte, value []byte, obj Object, ttl time.Duration, version uint64) error {
+	size := entrySize(key, value)
+	if obj != nil {
+		size = int64(len(key)) + obj.Size()
+	}
+
+	if err := b.reserve(string(key), size); err != nil {
+		return err
+	}
+
+	if err := b.kv.Restore(key, value, obj, ttl, version); err != nil {
+		return err
+	}
+
+	expiry := int64(0)
+	if ttl > 0 {
+		expiry = expireAt(ttl)
+	}
+	b.record(string(key), size, expiry)
+	return nil
+}
+
+func (b *BoundedCache) GetVersion(key []byte) ([]byte, uint64, error) {
+	val, version, err := b.kv.GetVersion(key)
+	if err != nil {
+		b.miss(key, err)
+		return val, version, err
+	}
+
+	if meta, ok := b.keys[string(key)]; ok {
+		b.touch(meta)
+	}
+	return val, version, nil
+}
+
+func entrySize(key []byte, value []byte) int64 {
+	return int64(len(key) + len(value))
+}
+
+func (b *BoundedCache) Get(key []byte) ([]byte, error) {
+	val, err := b.kv.Get(key)
+	if err != nil {
+		b.miss(key, err)
+		return val, err
+	}
+
+	if meta, ok := b.keys[string(key)]; ok {
+		b.touch(meta)
+	}
+	return val, nil
+}
+
+func (b *BoundedCache) Update(key []byte, fn UpdateFunc) ([]byte, error) {
+	var (
+		size   int64
+		expiry int64
+	)
+	updated, err := b.kv.Update(key, func(value []byte, exists bool) ([]byte, error) {
+		if !exists {
+			b.forget(string(key))
+		}
+
+		updated, err := fn(value, exists)
+		if err != nil {
+			return nil, err
+		}
+
+		size = entrySize(key, updated)
+		return updated, b.reserve(string(key), size)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if meta, ok := b.keys[string(key)]; ok {
+		expiry = meta.expireAt
+	}
+	b.record(string(key), size, expiry)
+	return updated, nil
+}
+
+// UpdateObject reserves grow bytes up front since fn cannot be undone, a
+// grow of zero lets removals work while memory is full.
+func (b *BoundedCache) UpdateObject(key []byte, grow int64, fn ObjectFunc) (uint64, error) {
+	var size int64
+	version, err := b.kv.UpdateObject(key, grow, func(obj Object) (Object, error) {
+		size = int64(len(key)) + grow
+		if obj == nil {
+			b.forget(string(key))
+		} else {
+			size += obj.Size()
+		}
+
+		if grow > 0 {
+			if err := b.reserve(string(key), size); err != nil {
+				return nil, err
+			}
+		}
+
+		updated, err := fn(obj)
+		if err != nil || updated == nil {
+			return updated, err
+		}
+
+		size = int64(len(key)) + updated.Size()
+		return updated, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if version == 0 {
+		b.forget(string(key))
+		return 0, nil
+	}
+
+	expiry := int64(0)
+	if meta, ok := b.keys[string(key)]; ok {
+		expiry = meta.expireAt
+	}
+	b.record(string(key), size, expiry)
+	return version, nil
+}
+
+func (b *BoundedCache) ViewObject(key []byte, fn func(Object) error) error {
+	found := false
+	err := b.kv.ViewObject(key, func(obj Object) error {
+		found = true
+		return fn(obj)
+	})
+	if !found {
+		b.miss(key, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if meta, ok := b.keys[string(key)]; ok {
+		b.touch(meta)
+	}
+	return nil
+}
+
+func (b *BoundedCache) miss(key []byte, err error) {
+	if errors.Is(err, ErrNotFound) {
+		b.forget(string(key))
+	}
+}
+
+// Scan and Range leave the eviction order alone.
+func (b *BoundedCache) Scan(cursor uint64, pattern []byte, count int) ([][]byte, uint64, error) {
+	return b.kv.Scan(cursor, pattern, count)
+}
+
+func (b *BoundedCache) Range(fn RangeFunc) {
+	b.kv.Range(fn)
+}
+
+func (b *BoundedCache) Del(key []byte) error {
+	if err := b.kv.Del(key); err != nil {
+		return err
+	}
+
+	b.forget(string(key))
+	return nil
+}
+
+func (b *BoundedCache) Expire(key []byte, ttl time.Duration) error {
+	if err := b.kv.Expire(key, ttl); err != nil {
+		b.forget(string(key))
+		return err
+	}
+
+	if ttl <= 0 {
+		b.forget(string(key))
+	} else if meta, ok := b.keys[string(key)]; ok {
+		b.setExpiry(meta, expireAt(ttl))
+	}
+	return nil
+}
+
+func (b *BoundedCache) TTL(key []byte) (time.Duration, error) {
+	ttl, err := b.kv.TTL(key)
+	if err != nil {
+		b.forget(string(key))
+	}
+	return ttl, err
+}
+
+func (b *BoundedCache) Persist(key []byte) error {
+	if err := b.kv.Persist(key); err != nil {
+		b.forget(string(key))
+		return err
+	}
+
+	if meta, ok := b.keys[string(key)]; ok {
+		b.setExpiry(meta, 0)
+	}
+	return nil
+}
+
+func (b *BoundedCache) RemoveExpired(limit int) int {
+	now := time.Now().UnixNano()
+	removed := 0
+	for key, meta := range b.volatile {
+		if limit <= 0 {
+			break
+		}
+		limit--
+
+		if meta.expireAt <= now {
+			if err := b.kv.Del([]byte(key)); err == nil {
+				b.forget(key)
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+func (b *BoundedCache) reserve(key string, size int64) error {
+	need := b.used + size
+	if meta, ok := b.keys[key]; ok {
+		need -= meta.size
+	}
+
+	for need > b.maxBytes {
+		victim := b.victim(key)
+		if victim == nil || size > b.maxBytes {
+			return newError(ErrOutOfMemory, constants.OutOfMemoryErr, key, b.used, b.maxBytes)
+		}
+
+		if err := b.kv.Del([]byte(victim.key)); err != nil {
+			return err
+		}
+		need -= victim.size
+		b.forget(victim.key)
+		if b.onEvict != nil {
+			b.onEvict([]byte(victim.key))
+		}
+	}
+	return nil
+}
+
+func (b *BoundedCache) victim(exclude string) *keyMeta {
+	switch b.policy {
+	case NoEviction:
+		return nil
+	case Random:
+		for key, meta := range b.keys {
+			if key != exclude {
+				return meta
+			}
+		}
+		return nil
+	case LRU, LFU, VolatileTTL:
+		victim := b.queue.minExcluding(exclude)
+		if victim == nil || (b.policy == VolatileTTL && victim.expireAt == 0) {
+			return nil
+		}
+		return victim
+	default:
+		return nil
+	}
+}
+
+func (b *BoundedCache) record(key string, size int64, expiry int64) {
+	meta, ok := b.keys[key]
+	if !ok {
+		meta = &keyMeta{key: key}
+		b.keys[key] = meta
+		if b.queue.ordered() {
+			heap.Push(&b.queue, meta)
+		}
+	}
+
+	b.used += size - meta.size
+	meta.size = size
+	b.setExpiry(meta, expiry)
+	b.touch(meta)
+}
+
+func (b *BoundedCache) setExpiry(meta *keyMeta, expiry int64) {
+	meta.expireAt = expiry
+	if expiry == 0 {
+		delete(b.volatile, meta.key)
+	} else {
+		b.volatile[meta.key] = meta
+	}
+}
+
+func (b *BoundedCache) touch(meta *keyMeta) {
+	b.clock++
+	meta.access = b.clock
+	meta.hits++
+	if b.queue.ordered() {
+		heap.Fix(&b.queue, meta.index)
+	}
+}
+
+func (b *BoundedCache) forget(key string) {
+	meta, ok := b.keys[key]
+	if !ok {
+		return
+	}
+
+	b.used -= meta.size
+	delete(b.keys, key)
+	delete(b.volatile, key)
+	if b.queue.ordered() {
+		heap.Remove(&b.queue, meta.index)
+	}
+}
+
+type evictionQueue struct {
+	policy EvictionPolicy
+	items  []*keyMeta
+}
+
+func (q *evictionQueue) ordered() bool {
+	return q.policy == LRU || q.policy == LFU || q.policy == VolatileTTL
+}
+
+func (q *evictionQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *evictionQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	switch q.policy {
+	case LFU:
+		if a.hits != b.hits {
+			return a.hits < b.hits
+		}
+	case VolatileTTL:
+		if a.expireAt != b.expireAt {
+			return b.expireAt == 0 || (a.expireAt != 0 && a.expireAt < b.expireAt)
+		}
+	case NoEviction, LRU, Random:
+	}
+	return a.access < b.access
+}
+
+func (q *evictionQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *evictionQueue) Push(x any) {
+	meta, _ := x.(*keyMeta)
+	meta.index = len(q.items)
+	q.items = append(q.items, meta)
+}
+
+func (q *evictionQueue) Pop() any {
+	last := len(q.items) - 1
+	meta := q.items[last]
+	q.items[last] = nil
+	q.items = q.items[:last]
+	return meta
+}
+
+func (q *evictionQueue) minExcluding(exclude string) *keyMeta {
+	if len(q.items) == 0 {
+		return nil
+	}
+
+	if q.items[0].key != exclude {
+		return q.items[0]
+	}
+
+	switch {
+	case len(q.items) == 1:
+		return nil
+	case len(q.items) == 2 || q.Less(1, 2):
+		return q.items[1]
+	default:
+		return q.items[2]
+	}
+}
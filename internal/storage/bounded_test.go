@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setKeys(t *testing.T, cache KeyValue, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		require.NoError(t, cache.Set([]byte(key), []byte(key)))
+	}
+}
+
+func exists(cache KeyValue, key string) bool {
+	_, err := cache.Get([]byte(key))
+	return err == nil
+}
+
+func TestBoundedEviction(t *testing.T) {
+	t.Parallel()
+	// every key and value is two bytes, so three keys fit in 12 bytes
+	maxBytes := int64(12)
+	tests := []struct {
+		name    string
+		policy  EvictionPolicy
+		setup   func(*testing.T, KeyValue)
+		evicted string
+	}{
+		{
+			name:   "lru evicts least recently used",
+			policy: LRU,
+			setup: func(t *testing.T, cache KeyValue) {
+				setKeys(t, cache, "k1", "k2", "k3")
+				assert.True(t, exists(cache, "k1"))
+			},
+			evicted: "k2",
+		},
+		{
+			name:   "lfu evicts least frequently used",
+			policy: LFU,
+			setup: func(t *testing.T, cache KeyValue) {
+				setKeys(t, cache, "k1", "k2", "k3")
+				for i := 0; i < 3; i++ {
+					assert.True(t, exists(cache, "k1"))
+					assert.True(t, exists(cache, "k2"))
+				}
+			},
+			evicted: "k3",
+		},
+		{
+			name:   "volatile ttl evicts nearest expiration",
+			policy: VolatileTTL,
+			setup: func(t *testing.T, cache KeyValue) {
+				setKeys(t, cache, "k1")
+				require.NoError(t, cache.SetWithTTL([]byte("k2"), []byte("k2"), time.Hour))
+				require.NoError(t, cache.SetWithTTL([]byte("k3"), []byte("k3"), time.Minute))
+			},
+			evicted: "k3",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var evicted []string
+			cache := NewNotifyingCache(NewCacheMap(), maxBytes, tc.policy, func(key []byte) {
+				evicted = append(evicted, string(key))
+			})
+			tc.setup(t, cache)
+			setKeys(t, cache, "k4")
+			assert.Equal(t, []string{tc.evicted}, evicted)
+			assert.False(t, exists(cache, tc.evicted))
+			assert.True(t, exists(cache, "k4"))
+			assert.LessOrEqual(t, cache.(*BoundedCache).Used(), maxBytes)
+		})
+	}
+}
+
+func TestBoundedNoEviction(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, NoEviction)
+	setKeys(t, cache, "k1", "k2")
+	err := cache.Set([]byte("k3"), []byte("k3"))
+	assert.Equal(t, fmt.Sprintf(constants.OutOfMemoryErr, "k3", 8, 8), err.Error())
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+
+	// overwriting an existing key with the same size still fits
+	setKeys(t, cache, "k1")
+	assert.True(t, exists(cache, "k2"))
+
+	assert.NoError(t, cache.Del([]byte("k2")))
+	setKeys(t, cache, "k3")
+	assert.Equal(t, int64(8), cache.(*BoundedCache).Used())
+}
+
+func TestBoundedVolatileWithoutCandidates(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, VolatileTTL)
+	setKeys(t, cache, "k1", "k2")
+	assert.Error(t, cache.Set([]byte("k3"), []byte("k3")))
+}
+
+func TestBoundedRandomNeverEvictsWrittenKey(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, Random)
+	setKeys(t, cache, "k1", "k2")
+	require.NoError(t, cache.Set([]byte("k1"), []byte("large")))
+	assert.True(t, exists(cache, "k1"))
+	assert.False(t, exists(cache, "k2"))
+	assert.Error(t, cache.Set([]byte("k1"), []byte("too large")))
+}
+
+func TestBoundedTracksExpiredKeys(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, NoEviction)
+	require.NoError(t, cache.SetWithTTL([]byte("k1"), []byte("k1"), time.Millisecond))
+	require.NoError(t, cache.SetWithTTL([]byte("k2"), []byte("k2"), time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Equal(t, 2, cache.RemoveExpired(10))
+	assert.Equal(t, int64(0), cache.(*BoundedCache).Used())
+	setKeys(t, cache, "k3", "k4")
+}
@@ -1,7 +1,8 @@
 package storage
 
 import (
-	"fmt"
+	"errors"
+	"time"
 
 	"github.com/kevindweb/cache/internal/constants"
 )
@@ -10,28 +11,112 @@ const (
 	UnsetKeyErr = "key %s not set"
 )
 
+// CacheMap versions come from a counter that only grows, so a recreated key
+// never repeats an old version.
 type CacheMap struct {
-	kv map[string][]byte
+	kv      map[string]entry
+	expires map[string]int64
+	index   hashIndex
+	version uint64
+}
+
+type entry struct {
+	value   []byte
+	object  Object
+	version uint64
 }
 
 func NewCacheMap() KeyValue {
-	cm := CacheMap{}
-	return cm.New()
+	return newCacheMap()
 }
 
-func (cm CacheMap) New() KeyValue {
+func newCacheMap() *CacheMap {
 	return &CacheMap{
-		kv: make(map[string][]byte, constants.MaxRequestBatch),
+		kv:      make(map[string]entry, constants.MaxRequestBatch),
+		expires: map[string]int64{},
+		index:   newHashIndex(),
 	}
 }
 
+func (cm CacheMap) New() KeyValue {
+	return newCacheMap()
+}
+
 func (cm *CacheMap) Free() error {
-	cm.kv = map[string][]byte{}
+	cm.kv = map[string]entry{}
+	cm.expires = map[string]int64{}
+	cm.index = newHashIndex()
 	return nil
 }
 
 func (cm *CacheMap) Set(key []byte, value []byte) error {
-	cm.kv[string(key)] = cp(value)
+	cm.store(string(key), cp(value))
+	delete(cm.expires, string(key))
+	return nil
+}
+
+func (cm *CacheMap) store(key string, value []byte) uint64 {
+	cm.version++
+	cm.put(key, entry{value: value, version: cm.version})
+	return cm.version
+}
+
+func (cm *CacheMap) storeObject(key string, obj Object) uint64 {
+	cm.version++
+	cm.put(key, entry{object: obj, version: cm.version})
+	return cm.version
+}
+
+func (cm *CacheMap) put(key string, e entry) {
+	if _, ok := cm.kv[key]; !ok {
+		cm.index.add(hashKey([]byte(key)), key)
+	}
+	cm.kv[key] = e
+}
+
+func (cm *CacheMap) SetWithTTL(key []byte, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return newError(ErrInvalidTTL, constants.KeyTTLErr, ttl, key)
+	}
+
+	cm.store(string(key), cp(value))
+	cm.expires[string(key)] = expireAt(ttl)
+	return nil
+}
+
+func (cm *CacheMap) SetIf(key []byte, value []byte, ttl time.Duration, cond Condition) (uint64, error) {
+	if ttl < 0 {
+		return 0, newError(ErrInvalidTTL, constants.KeyTTLErr, ttl, key)
+	}
+
+	cm.expired(string(key))
+	if err := cond(key, cm.kv[string(key)].version); err != nil {
+		return 0, err
+	}
+
+	version := cm.store(string(key), cp(value))
+	if ttl == 0 {
+		delete(cm.expires, string(key))
+	} else {
+		cm.expires[string(key)] = expireAt(ttl)
+	}
+	return version, nil
+}
+
+// Restore keeps the version of the key's last write, so undoing a write does
+// not look like a new one.
+func (cm *CacheMap) Restore(key []byte, value []byte, obj Object, ttl time.Duration, version uint64) error {
+	e := entry{object: obj, version: version}
+	if obj == nil {
+		e.value = cp(value)
+	}
+
+	cm.put(string(key), e)
+	if ttl > 0 {
+		cm.expires[string(key)] = expireAt(ttl)
+	} else {
+		delete(cm.expires, string(key))
+	}
 	return nil
 }
 
@@ -41,15 +126,217 @@ func cp(src []byte) []byte {
 	return dst
 }
 
+func expireAt(ttl time.Duration) int64 {
+	return time.Now().Add(ttl).UnixNano()
+}
+
 func (cm *CacheMap) Get(key []byte) ([]byte, error) {
-	val, ok := cm.kv[string(key)]
+	e, ok := cm.lookup(string(key))
 	if !ok {
-		return []byte{}, fmt.Errorf(UnsetKeyErr, key)
+		return []byte{}, newError(ErrNotFound, UnsetKeyErr, key)
+	}
+
+	if e.object != nil {
+		return []byte{}, wrongType(key, e.object.Type())
+	}
+	return e.value, nil
+}
+
+func (cm *CacheMap) lookup(key string) (entry, bool) {
+	if cm.expired(key) {
+		return entry{}, false
+	}
+
+	e, ok := cm.kv[key]
+	return e, ok
+}
+
+// GetVersion reports the version of an object alongside ErrWrongType so it
+// can be watched.
+func (cm *CacheMap) GetVersion(key []byte) ([]byte, uint64, error) {
+	val, err := cm.Get(key)
+	if err != nil && !errors.Is(err, ErrWrongType) {
+		return val, 0, err
+	}
+	return val, cm.kv[string(key)].version, err
+}
+
+func (cm *CacheMap) Update(key []byte, fn UpdateFunc) ([]byte, error) {
+	e, ok := cm.lookup(string(key))
+	if e.object != nil {
+		return nil, wrongType(key, e.object.Type())
+	}
+
+	updated, err := fn(e.value, ok)
+	if err != nil {
+		return nil, err
+	}
+
+	cm.store(string(key), updated)
+	return updated, nil
+}
+
+func (cm *CacheMap) UpdateObject(key []byte, _ int64, fn ObjectFunc) (uint64, error) {
+	e, ok := cm.lookup(string(key))
+	if ok && e.object == nil {
+		return 0, wrongType(key, stringType)
+	}
+
+	updated, err := fn(e.object)
+	if err != nil {
+		return 0, err
+	}
+
+	if updated == nil {
+		cm.delete(string(key))
+		return 0, nil
+	}
+	return cm.storeObject(string(key), updated), nil
+}
+
+func (cm *CacheMap) ViewObject(key []byte, fn func(Object) error) error {
+	e, ok := cm.lookup(string(key))
+	if !ok {
+		return newError(ErrNotFound, UnsetKeyErr, key)
+	}
+
+	if e.object == nil {
+		return wrongType(key, stringType)
+	}
+	return fn(e.object)
+}
+
+func (cm *CacheMap) Scan(cursor uint64, pattern []byte, count int) ([][]byte, uint64, error) {
+	if err := validatePattern(pattern); err != nil {
+		return nil, 0, err
+	}
+
+	keys, _, next := cm.scan(cursor, count)
+	return matching(keys, pattern), next, nil
+}
+
+// scan counts expired keys towards count so a page never costs more, and
+// keeps keys sharing a hash on the same page.
+func (cm *CacheMap) scan(cursor uint64, count int) ([]string, int, uint64) {
+	if count < 1 {
+		count = 1
+	}
+
+	var (
+		keys     []string
+		examined int
+		last     uint64
+		next     uint64
+	)
+	now := time.Now().UnixNano()
+	cm.index.from(cursor, func(hash uint64, key string) bool {
+		if examined >= count && hash != last {
+			next = last + 1
+			return false
+		}
+		examined, last = examined+1, hash
+
+		if deadline, ok := cm.expires[key]; !ok || deadline > now {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys, examined, next
+}
+
+func (cm *CacheMap) Range(fn RangeFunc) {
+	now := time.Now().UnixNano()
+	for key, e := range cm.kv {
+		ttl := constants.NoExpiration
+		if deadline, ok := cm.expires[key]; ok {
+			if deadline <= now {
+				continue
+			}
+			ttl = time.Duration(deadline - now)
+		}
+
+		if !fn([]byte(key), e.value, e.object, ttl) {
+			return
+		}
 	}
-	return val, nil
 }
 
 func (cm *CacheMap) Del(key []byte) error {
-	delete(cm.kv, string(key))
+	cm.delete(string(key))
+	return nil
+}
+
+func (cm *CacheMap) delete(key string) {
+	if _, ok := cm.kv[key]; ok {
+		cm.index.remove(hashKey([]byte(key)), key)
+	}
+	delete(cm.kv, key)
+	delete(cm.expires, key)
+}
+
+func (cm *CacheMap) expired(key string) bool {
+	deadline, ok := cm.expires[key]
+	if !ok || deadline > time.Now().UnixNano() {
+		return false
+	}
+
+	cm.delete(key)
+	return true
+}
+
+func (cm *CacheMap) Expire(key []byte, ttl time.Duration) error {
+	if _, ok := cm.lookup(string(key)); !ok {
+		return newError(ErrNotFound, UnsetKeyErr, key)
+	}
+
+	if ttl <= 0 {
+		cm.delete(string(key))
+		return nil
+	}
+
+	cm.expires[string(key)] = expireAt(ttl)
+	return nil
+}
+
+func (cm *CacheMap) TTL(key []byte) (time.Duration, error) {
+	if _, ok := cm.lookup(string(key)); !ok {
+		return 0, newError(ErrNotFound, UnsetKeyErr, key)
+	}
+
+	deadline, ok := cm.expires[string(key)]
+	if !ok {
+		return constants.NoExpiration, nil
+	}
+	return time.Until(time.Unix(0, deadline)), nil
+}
+
+func (cm *CacheMap) Persist(key []byte) error {
+	if _, ok := cm.lookup(string(key)); !ok {
+		return newError(ErrNotFound, UnsetKeyErr, key)
+	}
+
+	delete(cm.expires, string(key))
 	return nil
 }
+
+func (cm *CacheMap) RemoveExpired(limit int) int {
+	_, removed := cm.sampleExpired(limit)
+	return removed
+}
+
+func (cm *CacheMap) sampleExpired(limit int) (int, int) {
+	now := time.Now().UnixNano()
+	sampled, removed := 0, 0
+	for key, deadline := range cm.expires {
+		if sampled >= limit {
+			break
+		}
+		sampled++
+
+		if deadline <= now {
+			cm.delete(key)
+			removed++
+		}
+	}
+	return sampled, removed
+}
@@ -0,0 +1,32 @@
+package storage
+
+import "github.com/kevindweb/cache/internal/constants"
+
+type Condition func(key []byte, version uint64) error
+
+func IfAbsent(key []byte, version uint64) error {
+	if version != 0 {
+		return newError(ErrConflict, constants.KeyExistsErr, key)
+	}
+	return nil
+}
+
+func IfExists(key []byte, version uint64) error {
+	if version == 0 {
+		return newError(ErrNotFound, UnsetKeyErr, key)
+	}
+	return nil
+}
+
+func IfVersion(expected uint64) Condition {
+	return func(key []byte, version uint64) error {
+		if version == 0 {
+			return newError(ErrNotFound, UnsetKeyErr, key)
+		}
+
+		if version != expected {
+			return newError(ErrConflict, constants.VersionMismatchErr, key, version, expected)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIf(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("conditional writes", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("key")
+			_, err := cache.SetIf(key, []byte("v0"), 0, IfExists)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			v1, err := cache.SetIf(key, []byte("v1"), 0, IfAbsent)
+			assert.NoError(t, err)
+			assert.NotZero(t, v1)
+
+			_, err = cache.SetIf(key, []byte("v2"), 0, IfAbsent)
+			assert.ErrorIs(t, err, ErrConflict)
+
+			v2, err := cache.SetIf(key, []byte("v2"), 0, IfVersion(v1))
+			assert.NoError(t, err)
+			assert.Greater(t, v2, v1)
+
+			_, err = cache.SetIf(key, []byte("v3"), 0, IfVersion(v1))
+			assert.ErrorIs(t, err, ErrConflict)
+
+			val, err := cache.Get(key)
+			assert.NoError(t, err)
+			assert.Equal(t, "v2", string(val))
+
+			val, version, err := cache.GetVersion(key)
+			assert.NoError(t, err)
+			assert.Equal(t, v2, version)
+			assert.Equal(t, "v2", string(val))
+
+			// every write moves the version, including counters
+			assert.NoError(t, cache.Set(key, []byte("1")))
+			_, v3, err := cache.GetVersion(key)
+			assert.NoError(t, err)
+			assert.Greater(t, v3, v2)
+			_, err = IncrBy(cache, key, 1)
+			assert.NoError(t, err)
+			_, v4, err := cache.GetVersion(key)
+			assert.NoError(t, err)
+			assert.Greater(t, v4, v3)
+
+			v5, err := cache.SetIf(key, []byte("v5"), 0, IfExists)
+			assert.NoError(t, err)
+			assert.Greater(t, v5, v4)
+		})
+	}
+}
+
+func TestSetIfRecreatedKey(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("recreated", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("key")
+			v1, err := cache.SetIf(key, []byte("v1"), 0, IfAbsent)
+			assert.NoError(t, err)
+			assert.NoError(t, cache.Del(key))
+
+			_, _, err = cache.GetVersion(key)
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = cache.SetIf(key, []byte("v1"), 0, IfVersion(v1))
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			v2, err := cache.SetIf(key, []byte("v2"), 0, IfAbsent)
+			assert.NoError(t, err)
+			assert.NotEqual(t, v1, v2, "a recreated key never repeats a version")
+		})
+	}
+}
+
+func TestSetIfTTL(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("ttl", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("lock")
+			_, err := cache.SetIf(key, []byte("owner"), -time.Second, IfAbsent)
+			assert.ErrorIs(t, err, ErrInvalidTTL)
+
+			_, err = cache.SetIf(key, []byte("owner"), time.Millisecond, IfAbsent)
+			assert.NoError(t, err)
+			time.Sleep(2 * time.Millisecond)
+
+			// an expired key counts as absent
+			_, err = cache.SetIf(key, []byte("owner"), 0, IfAbsent)
+			assert.NoError(t, err)
+			ttl, err := cache.TTL(key)
+			assert.NoError(t, err)
+			assert.Less(t, ttl, time.Duration(0))
+		})
+	}
+}
+
+func TestBoundedSetIfRejectedDoesNotEvict(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, LRU)
+	setKeys(t, cache, "k1", "k2")
+	_, err := cache.SetIf([]byte("k1"), []byte("longer value"), 0, IfAbsent)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.True(t, exists(cache, "k1"))
+	assert.True(t, exists(cache, "k2"))
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+// IncrBy stores canonical base-10 text, so values like "007" are not integers.
+func IncrBy(kv KeyValue, key []byte, delta int64) (int64, error) {
+	var n int64
+	_, err := kv.Update(key, func(value []byte, exists bool) ([]byte, error) {
+		current := int64(0)
+		if exists {
+			var ok bool
+			if current, ok = parseInt(value); !ok {
+				return nil, newError(ErrNotNumber, constants.NotIntegerErr, key)
+			}
+		}
+
+		if (delta > 0 && current > math.MaxInt64-delta) ||
+			(delta < 0 && current < math.MinInt64-delta) {
+			return nil, newError(ErrOverflow, constants.OverflowErr, key, delta)
+		}
+
+		n = current + delta
+		return strconv.AppendInt(nil, n, 10), nil
+	})
+	return n, err
+}
+
+func parseInt(value []byte) (int64, bool) {
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	return n, err == nil && strconv.FormatInt(n, 10) == string(value)
+}
+
+func IncrByFloat(kv KeyValue, key []byte, delta float64) (float64, error) {
+	var f float64
+	_, err := kv.Update(key, func(value []byte, exists bool) ([]byte, error) {
+		current := float64(0)
+		if exists {
+			var ok bool
+			if current, ok = parseFloat(value); !ok {
+				return nil, newError(ErrNotNumber, constants.NotFloatErr, key)
+			}
+		}
+
+		f = current + delta
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, newError(ErrOverflow, constants.OverflowErr, key, delta)
+		}
+		return strconv.AppendFloat(nil, f, 'f', -1, 64), nil
+	})
+	return f, err
+}
+
+func parseFloat(value []byte) (float64, bool) {
+	f, err := strconv.ParseFloat(string(value), 64)
+	return f, err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrBy(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("incr", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("counter")
+			n, err := IncrBy(cache, key, 5)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), n)
+
+			n, err = IncrBy(cache, key, -7)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(-2), n)
+
+			val, err := cache.Get(key)
+			assert.NoError(t, err)
+			assert.Equal(t, "-2", string(val))
+
+			assert.NoError(t, cache.Expire(key, time.Minute))
+			_, err = IncrBy(cache, key, 1)
+			assert.NoError(t, err)
+			ttl, err := cache.TTL(key)
+			assert.NoError(t, err)
+			assert.Greater(t, ttl, time.Duration(0), "incr keeps the ttl")
+
+			assert.NoError(t, cache.Set(key, []byte(strconv.FormatInt(math.MaxInt64, 10))))
+			_, err = IncrBy(cache, key, 1)
+			assert.ErrorIs(t, err, ErrOverflow)
+
+			assert.NoError(t, cache.Set(key, []byte(strconv.FormatInt(math.MinInt64, 10))))
+			_, err = IncrBy(cache, key, -1)
+			assert.ErrorIs(t, err, ErrOverflow)
+
+			for _, invalid := range []string{"abc", "007", "+7", " 7", "1.5", ""} {
+				assert.NoError(t, cache.Set(key, []byte(invalid)))
+				_, err = IncrBy(cache, key, 1)
+				assert.ErrorIs(t, err, ErrNotNumber, invalid)
+				val, err = cache.Get(key)
+				assert.NoError(t, err)
+				assert.Equal(t, invalid, string(val), "failed incr leaves the value")
+			}
+		})
+	}
+}
+
+func TestIncrByExpiredKey(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("expired", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("counter")
+			assert.NoError(t, cache.SetWithTTL(key, []byte("10"), time.Millisecond))
+			time.Sleep(2 * time.Millisecond)
+
+			n, err := IncrBy(cache, key, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			ttl, err := cache.TTL(key)
+			assert.NoError(t, err)
+			assert.Less(t, ttl, time.Duration(0), "a new counter has no ttl")
+		})
+	}
+}
+
+func TestIncrByFloat(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("incr float", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("float")
+			assert.NoError(t, cache.Set(key, []byte("10")))
+			f, err := IncrByFloat(cache, key, 0.5)
+			assert.NoError(t, err)
+			assert.Equal(t, 10.5, f)
+
+			val, err := cache.Get(key)
+			assert.NoError(t, err)
+			assert.Equal(t, "10.5", string(val))
+
+			_, err = IncrByFloat(cache, key, math.MaxFloat64)
+			assert.NoError(t, err)
+			_, err = IncrByFloat(cache, key, math.MaxFloat64)
+			assert.ErrorIs(t, err, ErrOverflow)
+
+			assert.NoError(t, cache.Set(key, []byte("NaN")))
+			_, err = IncrByFloat(cache, key, 1)
+			assert.ErrorIs(t, err, ErrNotNumber)
+		})
+	}
+}
+
+func TestIncrByConcurrent(t *testing.T) {
+	t.Parallel()
+	cache := NewShardedMap(DefaultShards)
+	key := []byte("counter")
+	workers, incrs := 8, 500
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < incrs; j++ {
+				_, err := IncrBy(cache, key, 1)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	val, err := cache.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(workers*incrs), string(val))
+}
+
+func TestBoundedUpdateAccounting(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, NoEviction)
+	key := []byte("n")
+	_, err := IncrBy(cache, key, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), cache.(*BoundedCache).Used())
+
+	_, err = IncrBy(cache, key, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), cache.(*BoundedCache).Used())
+
+	_, err = IncrBy(cache, key, 1_000_000_000)
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+	val, err := cache.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", string(val))
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound       = errors.New("key not found")
+	ErrOutOfMemory    = errors.New("out of memory")
+	ErrInvalidTTL     = errors.New("invalid ttl")
+	ErrNotNumber      = errors.New("value is not a number")
+	ErrOverflow       = errors.New("numeric overflow")
+	ErrConflict       = errors.New("write conflict")
+	ErrInvalidPattern = errors.New("invalid pattern")
+	ErrWrongType      = errors.New("wrong value type")
+)
+
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func newError(kind error, format string, args ...any) error {
+	return kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e kindError) Error() string {
+	return e.msg
+}
+
+func (e kindError) Unwrap() error {
+	return e.kind
+}
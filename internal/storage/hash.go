@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+const (
+	hashType = "hash"
+)
+
+type Hash struct {
+	fields map[string][]byte
+	size   int64
+}
+
+func NewHash() *Hash {
+	return &Hash{fields: map[string][]byte{}}
+}
+
+func (h *Hash) Type() string {
+	return hashType
+}
+
+func (h *Hash) Size() int64 {
+	return h.size
+}
+
+func (h *Hash) Clone() Object {
+	clone := &Hash{fields: make(map[string][]byte, len(h.fields)), size: h.size}
+	for field, value := range h.fields {
+		clone.fields[field] = value
+	}
+	return clone
+}
+
+func (h *Hash) Len() int {
+	return len(h.fields)
+}
+
+func (h *Hash) Set(field, value []byte) bool {
+	old, ok := h.fields[string(field)]
+	if ok {
+		h.size -= int64(len(old))
+	} else {
+		h.size += int64(len(field))
+	}
+
+	h.fields[string(field)] = cp(value)
+	h.size += int64(len(value))
+	return !ok
+}
+
+func (h *Hash) Get(field []byte) ([]byte, bool) {
+	value, ok := h.fields[string(field)]
+	return value, ok
+}
+
+func (h *Hash) Del(field []byte) bool {
+	value, ok := h.fields[string(field)]
+	if ok {
+		delete(h.fields, string(field))
+		h.size -= int64(len(field) + len(value))
+	}
+	return ok
+}
+
+func (h *Hash) All() ([][]byte, [][]byte) {
+	fields := make([]string, 0, len(h.fields))
+	for field := range h.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	keys := make([][]byte, len(fields))
+	values := make([][]byte, len(fields))
+	for i, field := range fields {
+		keys[i], values[i] = []byte(field), h.fields[field]
+	}
+	return keys, values
+}
+
+func hashOf(key []byte, obj Object) (*Hash, error) {
+	hash, ok := obj.(*Hash)
+	if !ok {
+		return nil, wrongType(key, obj.Type())
+	}
+	return hash, nil
+}
+
+func HSet(kv KeyValue, key []byte, fields, values [][]byte) (int, uint64, error) {
+	grow := int64(0)
+	for i := range fields {
+		grow += int64(len(fields[i]) + len(values[i]))
+	}
+
+	added := 0
+	version, err := kv.UpdateObject(key, grow, func(obj Object) (Object, error) {
+		hash := NewHash()
+		if obj != nil {
+			var err error
+			if hash, err = hashOf(key, obj); err != nil {
+				return nil, err
+			}
+		}
+
+		for i := range fields {
+			if hash.Set(fields[i], values[i]) {
+				added++
+			}
+		}
+		return hash, nil
+	})
+	return added, version, err
+}
+
+func HGet(kv KeyValue, key, field []byte) ([]byte, error) {
+	var value []byte
+	err := kv.ViewObject(key, func(obj Object) error {
+		hash, err := hashOf(key, obj)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		if value, ok = hash.Get(field); !ok {
+			return newError(ErrNotFound, constants.UnsetFieldErr, field, key)
+		}
+		return nil
+	})
+	return value, err
+}
+
+func HDel(kv KeyValue, key []byte, fields [][]byte) (int, error) {
+	removed := 0
+	_, err := kv.UpdateObject(key, 0, func(obj Object) (Object, error) {
+		if obj == nil {
+			return nil, nil
+		}
+
+		hash, err := hashOf(key, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			if hash.Del(field) {
+				removed++
+			}
+		}
+
+		if hash.Len() == 0 {
+			return nil, nil
+		}
+		return hash, nil
+	})
+	return removed, err
+}
+
+func HGetAll(kv KeyValue, key []byte) ([][]byte, [][]byte, error) {
+	var fields, values [][]byte
+	err := kv.ViewObject(key, func(obj Object) error {
+		hash, err := hashOf(key, obj)
+		if err != nil {
+			return err
+		}
+
+		fields, values = hash.All()
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return [][]byte{}, [][]byte{}, nil
+	}
+	return fields, values, err
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bytesOf(values ...string) [][]byte {
+	out := make([][]byte, len(values))
+	for i, value := range values {
+		out[i] = []byte(value)
+	}
+	return out
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("hash", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("user:1")
+			added, v1, err := HSet(cache, key, bytesOf("name", "age"), bytesOf("ada", "36"))
+			assert.NoError(t, err)
+			assert.Equal(t, 2, added)
+			assert.NotZero(t, v1)
+
+			added, v2, err := HSet(cache, key, bytesOf("age", "city"), bytesOf("37", "london"))
+			assert.NoError(t, err)
+			assert.Equal(t, 1, added)
+			assert.Greater(t, v2, v1)
+
+			val, err := HGet(cache, key, []byte("age"))
+			assert.NoError(t, err)
+			assert.Equal(t, "37", string(val))
+
+			_, err = HGet(cache, key, []byte("missing"))
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = HGet(cache, []byte("missing"), []byte("age"))
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			fields, values, err := HGetAll(cache, key)
+			assert.NoError(t, err)
+			assert.Equal(t, bytesOf("age", "city", "name"), fields)
+			assert.Equal(t, bytesOf("37", "london", "ada"), values)
+
+			removed, err := HDel(cache, key, bytesOf("name", "missing"))
+			assert.NoError(t, err)
+			assert.Equal(t, 1, removed)
+
+			removed, err = HDel(cache, key, bytesOf("age", "city"))
+			assert.NoError(t, err)
+			assert.Equal(t, 2, removed)
+			_, err = cache.TTL(key)
+			assert.ErrorIs(t, err, ErrNotFound, "deleting the last field deletes the key")
+
+			fields, values, err = HGetAll(cache, key)
+			assert.NoError(t, err)
+			assert.Empty(t, fields)
+			assert.Empty(t, values)
+		})
+	}
+}
+
+func TestHashWrongType(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("wrong type", func(t *testing.T) {
+			t.Parallel()
+			str, hash := []byte("str"), []byte("hash")
+			assert.NoError(t, cache.Set(str, []byte("v")))
+			_, _, err := HSet(cache, hash, bytesOf("f"), bytesOf("v"))
+			assert.NoError(t, err)
+
+			_, _, err = HSet(cache, str, bytesOf("f"), bytesOf("v"))
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, err = HGet(cache, str, []byte("f"))
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, err = HDel(cache, str, bytesOf("f"))
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, _, err = HGetAll(cache, str)
+			assert.ErrorIs(t, err, ErrWrongType)
+
+			_, err = cache.Get(hash)
+			assert.ErrorIs(t, err, ErrWrongType)
+			assert.EqualError(t, err, "key hash holds a hash value")
+			_, err = IncrBy(cache, hash, 1)
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, version, err := cache.GetVersion(hash)
+			assert.ErrorIs(t, err, ErrWrongType)
+			assert.NotZero(t, version)
+
+			// key level commands work on any type
+			assert.NoError(t, cache.Expire(hash, time.Minute))
+			ttl, err := cache.TTL(hash)
+			assert.NoError(t, err)
+			assert.Greater(t, ttl, time.Duration(0))
+			_, _, err = HSet(cache, hash, bytesOf("g"), bytesOf("v"))
+			assert.NoError(t, err)
+			ttl, err = cache.TTL(hash)
+			assert.NoError(t, err)
+			assert.Greater(t, ttl, time.Duration(0), "hset keeps the ttl")
+
+			assert.NoError(t, cache.Set(hash, []byte("plain")))
+			val, err := cache.Get(hash)
+			assert.NoError(t, err)
+			assert.Equal(t, "plain", string(val))
+		})
+	}
+}
+
+func TestBoundedHashAccounting(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 16, NoEviction)
+	key := []byte("h")
+	_, _, err := HSet(cache, key, bytesOf("f1"), bytesOf("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), cache.(*BoundedCache).Used())
+
+	_, _, err = HSet(cache, key, bytesOf("f1"), bytesOf("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), cache.(*BoundedCache).Used())
+
+	_, _, err = HSet(cache, key, bytesOf("f2"), bytesOf("0123456789ab"))
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+	_, err = HGet(cache, key, []byte("f2"))
+	assert.ErrorIs(t, err, ErrNotFound, "a rejected hset leaves the hash")
+
+	_, err = cache.Get(key)
+	assert.ErrorIs(t, err, ErrWrongType)
+	assert.Equal(t, int64(4), cache.(*BoundedCache).Used(), "a wrong type read keeps the key")
+
+	_, err = HDel(cache, key, bytesOf("f1"))
+	assert.NoError(t, err)
+	assert.Zero(t, cache.(*BoundedCache).Used())
+}
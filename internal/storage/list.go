@@ -0,0 +1,232 @@
+package storage
+
+import "errors"
+
+const (
+	listType = "list"
+
+	minListCapacity = 8
+)
+
+type List struct {
+	items [][]byte
+	head  int
+	n     int
+	size  int64
+}
+
+func NewList() *List {
+	return &List{}
+}
+
+func (l *List) Type() string {
+	return listType
+}
+
+func (l *List) Size() int64 {
+	return l.size
+}
+
+func (l *List) Clone() Object {
+	clone := &List{items: make([][]byte, l.n), n: l.n, size: l.size}
+	for i := range clone.items {
+		clone.items[i] = l.at(i)
+	}
+	return clone
+}
+
+func (l *List) Len() int {
+	return l.n
+}
+
+func (l *List) at(i int) []byte {
+	return l.items[(l.head+i)%len(l.items)]
+}
+
+func (l *List) grow() {
+	if l.n < len(l.items) {
+		return
+	}
+
+	capacity := 2 * len(l.items)
+	if capacity < minListCapacity {
+		capacity = minListCapacity
+	}
+
+	items := make([][]byte, capacity)
+	for i := 0; i < l.n; i++ {
+		items[i] = l.at(i)
+	}
+	l.items, l.head = items, 0
+}
+
+func (l *List) PushFront(value []byte) {
+	l.grow()
+	l.head = (l.head - 1 + len(l.items)) % len(l.items)
+	l.items[l.head] = cp(value)
+	l.n++
+	l.size += int64(len(value))
+}
+
+func (l *List) PushBack(value []byte) {
+	l.grow()
+	l.items[(l.head+l.n)%len(l.items)] = cp(value)
+	l.n++
+	l.size += int64(len(value))
+}
+
+func (l *List) PopFront() ([]byte, bool) {
+	if l.n == 0 {
+		return nil, false
+	}
+
+	value := l.items[l.head]
+	l.items[l.head] = nil
+	l.head = (l.head + 1) % len(l.items)
+	l.n--
+	l.size -= int64(len(value))
+	return value, true
+}
+
+func (l *List) PopBack() ([]byte, bool) {
+	if l.n == 0 {
+		return nil, false
+	}
+
+	tail := (l.head + l.n - 1) % len(l.items)
+	value := l.items[tail]
+	l.items[tail] = nil
+	l.n--
+	l.size -= int64(len(value))
+	return value, true
+}
+
+func (l *List) Range(start, stop int64) [][]byte {
+	n := int64(l.n)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+
+	if start > stop {
+		return [][]byte{}
+	}
+
+	values := make([][]byte, 0, stop-start+1)
+	for i := start; i <= stop; i++ {
+		values = append(values, l.at(int(i)))
+	}
+	return values
+}
+
+func listOf(key []byte, obj Object) (*List, error) {
+	list, ok := obj.(*List)
+	if !ok {
+		return nil, wrongType(key, obj.Type())
+	}
+	return list, nil
+}
+
+func LPush(kv KeyValue, key []byte, values [][]byte) (int, error) {
+	return push(kv, key, values, (*List).PushFront)
+}
+
+func RPush(kv KeyValue, key []byte, values [][]byte) (int, error) {
+	return push(kv, key, values, (*List).PushBack)
+}
+
+func push(kv KeyValue, key []byte, values [][]byte, pushFn func(*List, []byte)) (int, error) {
+	grow := int64(0)
+	for _, value := range values {
+		grow += int64(len(value))
+	}
+
+	n := 0
+	_, err := kv.UpdateObject(key, grow, func(obj Object) (Object, error) {
+		list := NewList()
+		if obj != nil {
+			var err error
+			if list, err = listOf(key, obj); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, value := range values {
+			pushFn(list, value)
+		}
+		n = list.Len()
+		return list, nil
+	})
+	return n, err
+}
+
+func LPop(kv KeyValue, key []byte) ([]byte, error) {
+	return pop(kv, key, (*List).PopFront)
+}
+
+func RPop(kv KeyValue, key []byte) ([]byte, error) {
+	return pop(kv, key, (*List).PopBack)
+}
+
+func pop(kv KeyValue, key []byte, popFn func(*List) ([]byte, bool)) ([]byte, error) {
+	var value []byte
+	_, err := kv.UpdateObject(key, 0, func(obj Object) (Object, error) {
+		if obj == nil {
+			return nil, newError(ErrNotFound, UnsetKeyErr, key)
+		}
+
+		list, err := listOf(key, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		value, _ = popFn(list)
+		if list.Len() == 0 {
+			return nil, nil
+		}
+		return list, nil
+	})
+	return value, err
+}
+
+func LRange(kv KeyValue, key []byte, start, stop int64) ([][]byte, error) {
+	var values [][]byte
+	err := kv.ViewObject(key, func(obj Object) error {
+		list, err := listOf(key, obj)
+		if err != nil {
+			return err
+		}
+
+		values = list.Range(start, stop)
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return [][]byte{}, nil
+	}
+	return values, err
+}
+
+func LLen(kv KeyValue, key []byte) (int, error) {
+	n := 0
+	err := kv.ViewObject(key, func(obj Object) error {
+		list, err := listOf(key, obj)
+		if err != nil {
+			return err
+		}
+
+		n = list.Len()
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	return n, err
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("list", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("queue")
+			n, err := RPush(cache, key, bytesOf("b", "c"))
+			assert.NoError(t, err)
+			assert.Equal(t, 2, n)
+
+			n, err = LPush(cache, key, bytesOf("a", "z"))
+			assert.NoError(t, err)
+			assert.Equal(t, 4, n)
+
+			values, err := LRange(cache, key, 0, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, bytesOf("z", "a", "b", "c"), values)
+
+			values, err = LRange(cache, key, -2, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, bytesOf("b", "c"), values)
+			values, err = LRange(cache, key, 3, 1)
+			assert.NoError(t, err)
+			assert.Empty(t, values)
+
+			val, err := LPop(cache, key)
+			assert.NoError(t, err)
+			assert.Equal(t, "z", string(val))
+			val, err = RPop(cache, key)
+			assert.NoError(t, err)
+			assert.Equal(t, "c", string(val))
+
+			n, err = LLen(cache, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, n)
+
+			for _, want := range []string{"a", "b"} {
+				val, err = LPop(cache, key)
+				assert.NoError(t, err)
+				assert.Equal(t, want, string(val))
+			}
+
+			_, err = LPop(cache, key)
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = cache.TTL(key)
+			assert.ErrorIs(t, err, ErrNotFound, "popping the last value deletes the key")
+
+			n, err = LLen(cache, key)
+			assert.NoError(t, err)
+			assert.Zero(t, n)
+			values, err = LRange(cache, key, 0, -1)
+			assert.NoError(t, err)
+			assert.Empty(t, values)
+
+			assert.NoError(t, cache.Set([]byte("str"), []byte("v")))
+			_, err = RPush(cache, []byte("str"), bytesOf("v"))
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, _, err = HSet(cache, []byte("hash"), bytesOf("f"), bytesOf("v"))
+			assert.NoError(t, err)
+			_, err = LPop(cache, []byte("hash"))
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, err = LLen(cache, []byte("hash"))
+			assert.ErrorIs(t, err, ErrWrongType)
+		})
+	}
+}
+
+func TestListRingBuffer(t *testing.T) {
+	t.Parallel()
+	list := NewList()
+	want := []string{}
+	// wrap the head around the buffer in both directions while it grows
+	for i := 0; i < 50; i++ {
+		value := strconv.Itoa(i)
+		if i%3 == 0 {
+			list.PushFront([]byte(value))
+			want = append([]string{value}, want...)
+		} else {
+			list.PushBack([]byte(value))
+			want = append(want, value)
+		}
+
+		if i%5 == 0 {
+			val, ok := list.PopFront()
+			assert.True(t, ok)
+			assert.Equal(t, want[0], string(val))
+			want = want[1:]
+		}
+	}
+
+	assert.Equal(t, bytesOf(want...), list.Range(0, -1))
+	assert.Equal(t, bytesOf(want...), list.Clone().(*List).Range(0, -1))
+
+	size := int64(0)
+	for _, value := range want {
+		size += int64(len(value))
+	}
+	assert.Equal(t, size, list.Size())
+
+	for i := len(want) - 1; i >= 0; i-- {
+		val, ok := list.PopBack()
+		assert.True(t, ok)
+		assert.Equal(t, want[i], string(val))
+	}
+	_, ok := list.PopBack()
+	assert.False(t, ok)
+	assert.Zero(t, list.Size())
+}
+
+func TestBoundedPopWhenFull(t *testing.T) {
+	t.Parallel()
+	cache := NewBoundedCache(NewCacheMap(), 8, NoEviction)
+	_, err := RPush(cache, []byte("q"), bytesOf("1234567"))
+	assert.NoError(t, err)
+	_, err = RPush(cache, []byte("q"), bytesOf("x"))
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+
+	_, err = LPop(cache, []byte("missing"))
+	assert.ErrorIs(t, err, ErrNotFound)
+	val, err := LPop(cache, []byte("q"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1234567", string(val))
+	assert.Zero(t, cache.(*BoundedCache).Used())
+}
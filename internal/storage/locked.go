@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+type LockedMap struct {
+	mu sync.Mutex
+	kv KeyValue
+}
+
+func NewLockedMap(kv KeyValue) KeyValue {
+	return &LockedMap{kv: kv}
+}
+
+func (lm *LockedMap) New() KeyValue {
+	return NewLockedMap(lm.kv.New())
+}
+
+func (lm *LockedMap) Free() error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Free()
+}
+
+func (lm *LockedMap) Set(key []byte, value []byte) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Set(key, value)
+}
+
+func (lm *LockedMap) SetWithTTL(key []byte, value []byte, ttl time.Duration) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.SetWithTTL(key, value, ttl)
+}
+
+func (lm *LockedMap) Get(key []byte) ([]byte, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Get(key)
+}
+
+func (lm *LockedMap) Del(key []byte) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Del(key)
+}
+
+func (lm *LockedMap) Expire(key []byte, ttl time.Duration) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Expire(key, ttl)
+}
+
+func (lm *LockedMap) TTL(key []byte) (time.Duration, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.TTL(key)
+}
+
+func (lm *LockedMap) Persist(key []byte) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Persist(key)
+}
+
+func (lm *LockedMap) Update(key []byte, fn UpdateFunc) ([]byte, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Update(key, fn)
+}
+
+func (lm *LockedMap) GetVersion(key []byte) ([]byte, uint64, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.GetVersion(key)
+}
+
+func (lm *LockedMap) SetIf(key []byte, value []byte, ttl time.Duration, cond Condition) (uint64, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.SetIf(key, value, ttl, cond)
+}
+
+func (lm *LockedMap) Restore(key []byte, value []byte, obj Object, ttl time.Duration, version uint64) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Restore(key, value, obj, ttl, version)
+}
+
+func (lm *LockedMap) Scan(cursor uint64, pattern []byte, count int) ([][]byte, uint64, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.Scan(cursor, pattern, count)
+}
+
+func (lm *LockedMap) Range(fn RangeFunc) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.kv.Range(fn)
+}
+
+func (lm *LockedMap) RemoveExpired(limit int) int {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.RemoveExpired(limit)
+}
+
+func (lm *LockedMap) UpdateObject(key []byte, grow int64, fn ObjectFunc) (uint64, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.UpdateObject(key, grow, fn)
+}
+
+func (lm *LockedMap) ViewObject(key []byte, fn func(Object) error) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.kv.ViewObject(key, fn)
+}
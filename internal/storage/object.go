@@ -0,0 +1,21 @@
+package storage
+
+import "github.com/kevindweb/cache/internal/constants"
+
+const (
+	stringType = "string"
+)
+
+type Object interface {
+	Type() string
+	Size() int64
+	Clone() Object
+}
+
+// ObjectFunc runs under the storage lock, it may change obj in place but must
+// not keep it.
+type ObjectFunc func(obj Object) (Object, error)
+
+func wrongType(key []byte, typ string) error {
+	return newError(ErrWrongType, constants.WrongTypeErr, key, typ)
+}
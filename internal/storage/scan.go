@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+const (
+	minIndexBits = 4
+	maxIndexLoad = 8
+)
+
+// hashIndex orders keys by hash so a cursor survives writes between pages,
+// a key existing for the whole scan is returned exactly once.
+type hashIndex struct {
+	bits    uint
+	buckets [][]indexed
+	size    int
+}
+
+type indexed struct {
+	hash uint64
+	key  string
+}
+
+func (i indexed) less(hash uint64, key string) bool {
+	return i.hash < hash || (i.hash == hash && i.key < key)
+}
+
+func newHashIndex() hashIndex {
+	return hashIndex{bits: minIndexBits, buckets: make([][]indexed, 1<<minIndexBits)}
+}
+
+func (ix *hashIndex) bucket(hash uint64) uint64 {
+	return hash >> (64 - ix.bits)
+}
+
+func (ix *hashIndex) search(bucket []indexed, hash uint64, key string) int {
+	return sort.Search(len(bucket), func(i int) bool {
+		return !bucket[i].less(hash, key)
+	})
+}
+
+func (ix *hashIndex) add(hash uint64, key string) {
+	b := ix.bucket(hash)
+	bucket := ix.buckets[b]
+	i := ix.search(bucket, hash, key)
+	bucket = append(bucket, indexed{})
+	copy(bucket[i+1:], bucket[i:])
+	bucket[i] = indexed{hash: hash, key: key}
+	ix.buckets[b] = bucket
+
+	ix.size++
+	if ix.size > maxIndexLoad*len(ix.buckets) {
+		ix.resize(ix.bits + 1)
+	}
+}
+
+func (ix *hashIndex) remove(hash uint64, key string) {
+	b := ix.bucket(hash)
+	bucket := ix.buckets[b]
+	i := ix.search(bucket, hash, key)
+	if i == len(bucket) || bucket[i].hash != hash || bucket[i].key != key {
+		return
+	}
+	ix.buckets[b] = append(bucket[:i], bucket[i+1:]...)
+
+	ix.size--
+	if ix.bits > minIndexBits && ix.size < len(ix.buckets)/2 {
+		ix.resize(ix.bits - 1)
+	}
+}
+
+func (ix *hashIndex) resize(bits uint) {
+	resized := hashIndex{bits: bits, buckets: make([][]indexed, 1<<bits), size: ix.size}
+	for _, bucket := range ix.buckets {
+		for _, item := range bucket {
+			b := resized.bucket(item.hash)
+			resized.buckets[b] = append(resized.buckets[b], item)
+		}
+	}
+	*ix = resized
+}
+
+func (ix *hashIndex) from(cursor uint64, fn func(hash uint64, key string) bool) {
+	first := ix.bucket(cursor)
+	for b := first; b < uint64(len(ix.buckets)); b++ {
+		bucket := ix.buckets[b]
+		i := 0
+		if b == first {
+			i = ix.search(bucket, cursor, "")
+		}
+
+		for ; i < len(bucket); i++ {
+			if !fn(bucket[i].hash, bucket[i].key) {
+				return
+			}
+		}
+	}
+}
+
+func matching(keys []string, pattern []byte) [][]byte {
+	matched := [][]byte{}
+	for _, key := range keys {
+		if len(pattern) == 0 || matchGlob(pattern, []byte(key)) {
+			matched = append(matched, []byte(key))
+		}
+	}
+	return matched
+}
+
+func validatePattern(pattern []byte) error {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i++; i == len(pattern) {
+				return newError(ErrInvalidPattern, constants.InvalidPatternErr, pattern)
+			}
+		case '[':
+			width := classWidth(pattern[i:])
+			if width == 0 {
+				return newError(ErrInvalidPattern, constants.InvalidPatternErr, pattern)
+			}
+			i += width - 1
+		}
+	}
+	return nil
+}
+
+// matchGlob differs from path.Match in treating '/' as an ordinary byte.
+func matchGlob(pattern []byte, key []byte) bool {
+	px, kx := 0, 0
+	starPx, starKx := -1, -1
+	for px < len(pattern) || kx < len(key) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starKx = px, kx+1
+				px++
+				continue
+			case '?':
+				if kx < len(key) {
+					px++
+					kx++
+					continue
+				}
+			case '[':
+				if kx < len(key) && matchClass(pattern[px:], key[kx]) {
+					px += classWidth(pattern[px:])
+					kx++
+					continue
+				}
+			case '\\':
+				if kx < len(key) && pattern[px+1] == key[kx] {
+					px += 2
+					kx++
+					continue
+				}
+			default:
+				if kx < len(key) && key[kx] == c {
+					px++
+					kx++
+					continue
+				}
+			}
+		}
+
+		// let the last '*' swallow one more byte and try again
+		if starPx >= 0 && starKx <= len(key) {
+			px, kx = starPx+1, starKx
+			starKx++
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func classWidth(pattern []byte) int {
+	i := 1
+	if i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!') {
+		i++
+	}
+	for start := i; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '\\':
+			i++
+		case pattern[i] == ']' && i > start:
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func matchClass(pattern []byte, c byte) bool {
+	end := classWidth(pattern) - 1
+	i, negate := 1, false
+	if pattern[i] == '^' || pattern[i] == '!' {
+		i, negate = i+1, true
+	}
+
+	matched := false
+	for i < end {
+		lo := pattern[i]
+		if lo == '\\' {
+			i++
+			lo = pattern[i]
+		}
+		i++
+
+		hi := lo
+		if i+1 < end && pattern[i] == '-' {
+			hi = pattern[i+1]
+			if hi == '\\' {
+				i++
+				hi = pattern[i+1]
+			}
+			i += 2
+		}
+
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	return matched != negate
+}
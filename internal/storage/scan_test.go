@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanAll(t *testing.T, cache KeyValue, pattern string, count int) []string {
+	t.Helper()
+	keys := []string{}
+	cursor := uint64(0)
+	for {
+		page, next, err := cache.Scan(cursor, []byte(pattern), count)
+		assert.NoError(t, err)
+		for _, key := range page {
+			keys = append(keys, string(key))
+		}
+		if next == 0 {
+			return keys
+		}
+		assert.Greater(t, next, cursor)
+		cursor = next
+	}
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("scan", func(t *testing.T) {
+			t.Parallel()
+			want := []string{}
+			for i := 0; i < 100; i++ {
+				key := "user:" + strconv.Itoa(i)
+				want = append(want, key)
+				assert.NoError(t, cache.Set([]byte(key), []byte("v")))
+			}
+			assert.NoError(t, cache.Set([]byte("session:1"), []byte("v")))
+			assert.NoError(t, cache.SetWithTTL([]byte("user:gone"), []byte("v"), time.Millisecond))
+			time.Sleep(2 * time.Millisecond)
+
+			assert.ElementsMatch(t, want, scanAll(t, cache, "user:*", 7))
+			assert.Len(t, scanAll(t, cache, "", 1000), 101)
+			assert.ElementsMatch(t, []string{"user:0", "user:10", "user:20", "user:30", "user:40", "user:50", "user:60", "user:70", "user:80", "user:90"}, scanAll(t, cache, "user:*0", 3))
+			assert.Len(t, scanAll(t, cache, "user:1?", 3), 10)
+			assert.Empty(t, scanAll(t, cache, "nothing*", 10))
+
+			_, _, err := cache.Scan(0, []byte("user:[0-9"), 10)
+			assert.ErrorIs(t, err, ErrInvalidPattern)
+		})
+	}
+}
+
+func TestScanConcurrentWrites(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("stable", func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < 200; i++ {
+				assert.NoError(t, cache.Set([]byte("stable:"+strconv.Itoa(i)), []byte("v")))
+			}
+
+			seen := map[string]int{}
+			cursor, i := uint64(0), 0
+			for {
+				page, next, err := cache.Scan(cursor, []byte("stable:*"), 10)
+				assert.NoError(t, err)
+				for _, key := range page {
+					seen[string(key)]++
+				}
+
+				// churn the keyspace between pages
+				for j := 0; j < 20; j++ {
+					key := []byte("churn:" + strconv.Itoa(i))
+					assert.NoError(t, cache.Set(key, []byte("v")))
+					if i%2 == 0 {
+						assert.NoError(t, cache.Del(key))
+					}
+					i++
+				}
+
+				if next == 0 {
+					break
+				}
+				cursor = next
+			}
+
+			assert.Len(t, seen, 200)
+			for key, n := range seen {
+				assert.Equal(t, 1, n, key)
+			}
+		})
+	}
+}
+
+func TestScanCollidingHashes(t *testing.T) {
+	t.Parallel()
+	cache := newCacheMap()
+	// index the keys directly so ties can be forced without a collision
+	for _, key := range []string{"b", "a", "c"} {
+		cache.kv[key] = entry{value: []byte("v")}
+	}
+	cache.index.add(5, "b")
+	cache.index.add(5, "a")
+	cache.index.add(9, "c")
+
+	keys, examined, next := cache.scan(0, 1)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, 2, examined)
+	assert.Equal(t, uint64(6), next)
+
+	keys, _, next = cache.scan(next, 1)
+	assert.Equal(t, []string{"c"}, keys)
+	assert.Zero(t, next)
+}
+
+func TestScanPageCost(t *testing.T) {
+	t.Parallel()
+	cache := newCacheMap()
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, cache.Set([]byte(strconv.Itoa(i)), []byte("v")))
+	}
+	assert.Greater(t, cache.index.bits, uint(minIndexBits))
+
+	hashes := []uint64{}
+	cache.index.from(0, func(hash uint64, _ string) bool {
+		hashes = append(hashes, hash)
+		return true
+	})
+	assert.Len(t, hashes, 1000)
+	assert.IsIncreasing(t, hashes)
+
+	_, examined, next := cache.scan(hashes[500], 10)
+	assert.Equal(t, 10, examined, "a page only examines count keys")
+	assert.Equal(t, hashes[509]+1, next)
+
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, cache.Del([]byte(strconv.Itoa(i))))
+	}
+	assert.Equal(t, uint(minIndexBits), cache.index.bits)
+	assert.Zero(t, cache.index.size)
+}
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "anything/at:all", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:1", "a/b:1", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[!e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"[]]", "]", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYbZ", false},
+		{"**", "x", true},
+	}
+
+	for _, test := range tests {
+		assert.NoError(t, validatePattern([]byte(test.pattern)), test.pattern)
+		assert.Equal(t, test.match, matchGlob([]byte(test.pattern), []byte(test.key)), "%s %s", test.pattern, test.key)
+	}
+
+	for _, invalid := range []string{"[abc", `abc\`, "[]"} {
+		assert.ErrorIs(t, validatePattern([]byte(invalid)), ErrInvalidPattern, invalid)
+	}
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultShards = 64
+
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// ShardedMap picks shards by the top bits of the key hash, so each one holds
+// a contiguous range of scan cursors.
+type ShardedMap struct {
+	shards     []shard
+	mask       uint64
+	shift      uint
+	expireNext atomic.Uint64
+}
+
+type shard struct {
+	mu sync.Mutex
+	kv *CacheMap
+}
+
+func NewShardedMap(shards int) KeyValue {
+	count, bits := 1, uint(0)
+	for count < shards {
+		count <<= 1
+		bits++
+	}
+
+	sm := &ShardedMap{
+		shards: make([]shard, count),
+		mask:   uint64(count - 1),
+		shift:  64 - bits,
+	}
+	for i := range sm.shards {
+		sm.shards[i].kv = newCacheMap()
+	}
+	return sm
+}
+
+func (sm *ShardedMap) New() KeyValue {
+	return NewShardedMap(len(sm.shards))
+}
+
+func hashKey(key []byte) uint64 {
+	hash := uint64(fnvOffset)
+	for _, b := range key {
+		hash ^= uint64(b)
+		hash *= fnvPrime
+	}
+	return hash
+}
+
+func (sm *ShardedMap) shard(key []byte) *shard {
+	return &sm.shards[sm.index(hashKey(key))]
+}
+
+func (sm *ShardedMap) index(hash uint64) uint64 {
+	return hash >> sm.shift
+}
+
+func (sm *ShardedMap) Free() error {
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		s.mu.Lock()
+		err := s.kv.Free()
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sm *ShardedMap) Set(key []byte, value []byte) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Set(key, value)
+}
+
+func (sm *ShardedMap) SetWithTTL(key []byte, value []byte, ttl time.Duration) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.SetWithTTL(key, value, ttl)
+}
+
+func (sm *ShardedMap) Get(key []byte) ([]byte, error) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Get(key)
+}
+
+func (sm *ShardedMap) Del(key []byte) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Del(key)
+}
+
+func (sm *ShardedMap) Expire(key []byte, ttl time.Duration) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Expire(key, ttl)
+}
+
+func (sm *ShardedMap) TTL(key []byte) (time.Duration, error) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.TTL(key)
+}
+
+func (sm *ShardedMap) Persist(key []byte) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Persist(key)
+}
+
+func (sm *ShardedMap) Update(key []byte, fn UpdateFunc) ([]byte, error) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Update(key, fn)
+}
+
+func (sm *ShardedMap) GetVersion(key []byte) ([]byte, uint64, error) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.GetVersion(key)
+}
+
+func (sm *ShardedMap) SetIf(key []byte, value []byte, ttl time.Duration, cond Condition) (uint64, error) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.SetIf(key, value, ttl, cond)
+}
+
+func (sm *ShardedMap) Restore(key []byte, value []byte, obj Object, ttl time.Duration, version uint64) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.Restore(key, value, obj, ttl, version)
+}
+
+func (sm *ShardedMap) UpdateObject(key []byte, grow int64, fn ObjectFunc) (uint64, error) {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.UpdateObject(key, grow, fn)
+}
+
+func (sm *ShardedMap) ViewObject(key []byte, fn func(Object) error) error {
+	s := sm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kv.ViewObject(key, fn)
+}
+
+func (sm *ShardedMap) Scan(cursor uint64, pattern []byte, count int) ([][]byte, uint64, error) {
+	if err := validatePattern(pattern); err != nil {
+		return nil, 0, err
+	}
+
+	if count < 1 {
+		count = 1
+	}
+
+	var (
+		keys     []string
+		examined int
+	)
+	for i := sm.index(cursor); i < uint64(len(sm.shards)); i++ {
+		if examined >= count {
+			return matching(keys, pattern), i << sm.shift, nil
+		}
+
+		from := cursor
+		if i != sm.index(cursor) {
+			from = i << sm.shift
+		}
+
+		s := &sm.shards[i]
+		s.mu.Lock()
+		page, n, next := s.kv.scan(from, count-examined)
+		s.mu.Unlock()
+
+		keys, examined = append(keys, page...), examined+n
+		if next != 0 {
+			return matching(keys, pattern), next, nil
+		}
+	}
+	return matching(keys, pattern), 0, nil
+}
+
+func (sm *ShardedMap) Range(fn RangeFunc) {
+	more := true
+	for i := 0; i < len(sm.shards) && more; i++ {
+		s := &sm.shards[i]
+		s.mu.Lock()
+		s.kv.Range(func(key []byte, value []byte, obj Object, ttl time.Duration) bool {
+			more = fn(key, value, obj, ttl)
+			return more
+		})
+		s.mu.Unlock()
+	}
+}
+
+// RemoveExpired resumes after the shard the last call ran out of budget in,
+// so all shards are swept as often.
+func (sm *ShardedMap) RemoveExpired(limit int) int {
+	start := sm.expireNext.Load()
+	removed := 0
+	for i := uint64(0); i < uint64(len(sm.shards)) && limit > 0; i++ {
+		index := (start + i) & sm.mask
+		s := &sm.shards[index]
+		s.mu.Lock()
+		sampled, n := s.kv.sampleExpired(limit)
+		s.mu.Unlock()
+
+		limit -= sampled
+		removed += n
+		if limit <= 0 {
+			sm.expireNext.Store(index + 1)
+		}
+	}
+	return removed
+}
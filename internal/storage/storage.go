@@ -1,15 +1,38 @@
 package storage
 
+import "time"
+
 type KeyValue interface {
 	New() KeyValue
 	Free() error
 	Set([]byte, []byte) error
+	SetWithTTL([]byte, []byte, time.Duration) error
 	Get([]byte) ([]byte, error)
 	Del([]byte) error
+	Expire([]byte, time.Duration) error
+	TTL([]byte) (time.Duration, error)
+	Persist([]byte) error
+	RemoveExpired(int) int
+	Update([]byte, UpdateFunc) ([]byte, error)
+	GetVersion([]byte) ([]byte, uint64, error)
+	SetIf([]byte, []byte, time.Duration, Condition) (uint64, error)
+	Restore([]byte, []byte, Object, time.Duration, uint64) error
+	Scan(uint64, []byte, int) ([][]byte, uint64, error)
+	UpdateObject([]byte, int64, ObjectFunc) (uint64, error)
+	ViewObject([]byte, func(Object) error) error
+	Range(RangeFunc)
 }
 
+// UpdateFunc must not return a slice aliasing value, storage keeps it.
+type UpdateFunc func(value []byte, exists bool) ([]byte, error)
+
+type RangeFunc func(key []byte, value []byte, obj Object, ttl time.Duration) bool
+
 func caches() []KeyValue {
 	return []KeyValue{
 		NewCacheMap(),
+		NewBoundedCache(NewCacheMap(), 1<<20, LRU),
+		NewShardedMap(DefaultShards),
+		NewLockedMap(NewBoundedCache(NewCacheMap(), 1<<20, LFU)),
 	}
 }
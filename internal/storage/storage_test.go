@@ -1,8 +1,13 @@
 package storage
 
 import (
+	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -63,3 +68,148 @@ func TestStorageSetGetDel(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageTTL(t *testing.T) {
+	t.Parallel()
+	key := []byte("key")
+	val := []byte("val")
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("expire, persist and lazy removal", func(t *testing.T) {
+			t.Parallel()
+			assert.Error(t, cache.SetWithTTL(key, val, 0))
+			_, err := cache.TTL(key)
+			assert.Error(t, err)
+
+			assert.NoError(t, cache.Set(key, val))
+			ttl, err := cache.TTL(key)
+			assert.NoError(t, err)
+			assert.Equal(t, constants.NoExpiration, ttl)
+
+			assert.NoError(t, cache.Expire(key, time.Minute))
+			ttl, err = cache.TTL(key)
+			assert.NoError(t, err)
+			assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+			assert.NoError(t, cache.Persist(key))
+			ttl, err = cache.TTL(key)
+			assert.NoError(t, err)
+			assert.Equal(t, constants.NoExpiration, ttl)
+
+			assert.NoError(t, cache.SetWithTTL(key, val, time.Millisecond))
+			time.Sleep(2 * time.Millisecond)
+			_, err = cache.Get(key)
+			assert.Error(t, err)
+			assert.Error(t, cache.Persist(key))
+		})
+	}
+}
+
+func TestStorageRemoveExpired(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("active removal", func(t *testing.T) {
+			t.Parallel()
+			numKeys := 10
+			for i := 0; i < numKeys; i++ {
+				key := []byte(getRandomString(10))
+				assert.NoError(t, cache.SetWithTTL(key, key, time.Millisecond))
+			}
+			assert.NoError(t, cache.Set([]byte("persistent"), []byte("val")))
+			time.Sleep(2 * time.Millisecond)
+
+			assert.Equal(t, numKeys, cache.RemoveExpired(numKeys*2))
+			assert.Equal(t, 0, cache.RemoveExpired(numKeys*2))
+			_, err := cache.Get([]byte("persistent"))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestStorageRestore(t *testing.T) {
+	t.Parallel()
+	key := []byte("key")
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("restore keeps version and ttl", func(t *testing.T) {
+			t.Parallel()
+			version, err := cache.SetIf(key, []byte("old"), time.Minute, IfAbsent)
+			assert.NoError(t, err)
+			assert.NoError(t, cache.Set(key, []byte("new")))
+
+			assert.NoError(t, cache.Restore(key, []byte("old"), nil, time.Minute, version))
+			val, got, err := cache.GetVersion(key)
+			assert.NoError(t, err)
+			assert.Equal(t, "old", string(val))
+			assert.Equal(t, version, got)
+			ttl, err := cache.TTL(key)
+			assert.NoError(t, err)
+			assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+			assert.NoError(t, cache.Restore(key, []byte("old"), nil, constants.NoExpiration, version))
+			ttl, err = cache.TTL(key)
+			assert.NoError(t, err)
+			assert.Equal(t, constants.NoExpiration, ttl)
+		})
+	}
+}
+
+func TestShardedMapRemoveExpiredBudget(t *testing.T) {
+	t.Parallel()
+	cache := NewShardedMap(4).(*ShardedMap)
+	live, expired := make([]int, 4), make([]int, 4)
+	for i := 0; ; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		index := cache.index(hashKey(key))
+		switch {
+		case live[index] < 5:
+			live[index]++
+			assert.NoError(t, cache.SetWithTTL(key, key, time.Minute))
+		case expired[index] < 1:
+			expired[index]++
+			assert.NoError(t, cache.SetWithTTL(key, key, time.Millisecond))
+		}
+
+		if fmt.Sprint(live, expired) == fmt.Sprint([]int{5, 5, 5, 5}, []int{1, 1, 1, 1}) {
+			break
+		}
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// each sample only covers the six volatile keys of one shard, and the
+	// next call moves on to the following shard
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, 1, cache.RemoveExpired(6))
+	}
+	assert.Equal(t, 0, cache.RemoveExpired(100))
+}
+
+func TestShardedMapConcurrentAccess(t *testing.T) {
+	t.Parallel()
+	cache := NewShardedMap(DefaultShards)
+	numWriters := 8
+	numKeys := 100
+	var wg sync.WaitGroup
+	wg.Add(numWriters)
+	for w := 0; w < numWriters; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numKeys; i++ {
+				key := []byte(fmt.Sprintf("%d-%d", w, i))
+				assert.NoError(t, cache.Set(key, key))
+				got, err := cache.Get(key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, got)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < numWriters; w++ {
+		for i := 0; i < numKeys; i++ {
+			_, err := cache.Get([]byte(fmt.Sprintf("%d-%d", w, i)))
+			assert.NoError(t, err)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/kevindweb/cache/internal/constants"
+)
+
+const (
+	zsetType = "zset"
+
+	scoreSize = 8
+
+	zsetMaxLevel    = 32
+	zsetProbability = 0.25
+)
+
+// ZSet is a skip list whose links count the members they skip, so ranks are
+// found in logarithmic time.
+type ZSet struct {
+	scores map[string]float64
+	head   *zNode
+	level  int
+	size   int64
+}
+
+type zNode struct {
+	member string
+	score  float64
+	levels []zLevel
+}
+
+type zLevel struct {
+	next *zNode
+	span int
+}
+
+func NewZSet() *ZSet {
+	return &ZSet{
+		scores: map[string]float64{},
+		head:   &zNode{levels: make([]zLevel, zsetMaxLevel)},
+		level:  1,
+	}
+}
+
+func (z *ZSet) Type() string {
+	return zsetType
+}
+
+func (z *ZSet) Size() int64 {
+	return z.size
+}
+
+func (z *ZSet) Clone() Object {
+	clone := NewZSet()
+	for x := z.head.levels[0].next; x != nil; x = x.levels[0].next {
+		clone.Add([]byte(x.member), x.score)
+	}
+	return clone
+}
+
+func (z *ZSet) Len() int {
+	return len(z.scores)
+}
+
+func (z *ZSet) Score(member []byte) (float64, bool) {
+	score, ok := z.scores[string(member)]
+	return score, ok
+}
+
+func (z *ZSet) Add(member []byte, score float64) bool {
+	old, ok := z.scores[string(member)]
+	if ok {
+		if old == score {
+			return false
+		}
+		z.remove(string(member), old)
+	} else {
+		z.size += int64(len(member) + scoreSize)
+	}
+
+	z.scores[string(member)] = score
+	z.insert(string(member), score)
+	return !ok
+}
+
+func (z *ZSet) Rem(member []byte) bool {
+	score, ok := z.scores[string(member)]
+	if ok {
+		delete(z.scores, string(member))
+		z.remove(string(member), score)
+		z.size -= int64(len(member) + scoreSize)
+	}
+	return ok
+}
+
+func (z *ZSet) Range(start, stop int64) ([][]byte, []float64) {
+	n := int64(z.Len())
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+
+	if start > stop {
+		return [][]byte{}, []float64{}
+	}
+
+	members := make([][]byte, 0, stop-start+1)
+	scores := make([]float64, 0, stop-start+1)
+	x := z.byRank(int(start))
+	for i := start; i <= stop; i++ {
+		members = append(members, []byte(x.member))
+		scores = append(scores, x.score)
+		x = x.levels[0].next
+	}
+	return members, scores
+}
+
+func (z *ZSet) RangeByScore(min, max float64) ([][]byte, []float64) {
+	members, scores := [][]byte{}, []float64{}
+	x := z.head
+	for i := z.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && x.levels[i].next.score < min {
+			x = x.levels[i].next
+		}
+	}
+
+	for x = x.levels[0].next; x != nil && x.score <= max; x = x.levels[0].next {
+		members = append(members, []byte(x.member))
+		scores = append(scores, x.score)
+	}
+	return members, scores
+}
+
+func (n *zNode) before(member string, score float64) bool {
+	return n.score < score || (n.score == score && n.member < member)
+}
+
+func randomLevel() int {
+	level := 1
+	for level < zsetMaxLevel && rand.Float64() < zsetProbability {
+		level++
+	}
+	return level
+}
+
+func (z *ZSet) insert(member string, score float64) {
+	var update [zsetMaxLevel]*zNode
+	var rank [zsetMaxLevel]int
+	x := z.head
+	for i := z.level - 1; i >= 0; i-- {
+		if i < z.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].next != nil && x.levels[i].next.before(member, score) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].next
+		}
+		update[i] = x
+	}
+
+	// the new member is already counted, so the fresh levels of the head span
+	// every member but itself
+	level := randomLevel()
+	if level > z.level {
+		for i := z.level; i < level; i++ {
+			update[i] = z.head
+			z.head.levels[i].span = z.Len() - 1
+		}
+		z.level = level
+	}
+
+	node := &zNode{member: member, score: score, levels: make([]zLevel, level)}
+	for i := 0; i < level; i++ {
+		node.levels[i].next = update[i].levels[i].next
+		update[i].levels[i].next = node
+		node.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+
+	for i := level; i < z.level; i++ {
+		update[i].levels[i].span++
+	}
+}
+
+func (z *ZSet) remove(member string, score float64) {
+	var update [zsetMaxLevel]*zNode
+	x := z.head
+	for i := z.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && x.levels[i].next.before(member, score) {
+			x = x.levels[i].next
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].next
+	for i := 0; i < z.level; i++ {
+		if update[i].levels[i].next == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].next = x.levels[i].next
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+
+	for z.level > 1 && z.head.levels[z.level-1].next == nil {
+		z.level--
+	}
+}
+
+func (z *ZSet) byRank(rank int) *zNode {
+	traversed, x := 0, z.head
+	for i := z.level - 1; i >= 0; i-- {
+		for x.levels[i].next != nil && traversed+x.levels[i].span <= rank+1 {
+			traversed += x.levels[i].span
+			x = x.levels[i].next
+		}
+		if traversed == rank+1 {
+			return x
+		}
+	}
+	return nil
+}
+
+func zsetOf(key []byte, obj Object) (*ZSet, error) {
+	zset, ok := obj.(*ZSet)
+	if !ok {
+		return nil, wrongType(key, obj.Type())
+	}
+	return zset, nil
+}
+
+func ZAdd(kv KeyValue, key []byte, members [][]byte, scores []float64) (int, uint64, error) {
+	grow := int64(0)
+	for _, member := range members {
+		grow += int64(len(member) + scoreSize)
+	}
+
+	added := 0
+	version, err := kv.UpdateObject(key, grow, func(obj Object) (Object, error) {
+		zset := NewZSet()
+		if obj != nil {
+			var err error
+			if zset, err = zsetOf(key, obj); err != nil {
+				return nil, err
+			}
+		}
+
+		for i := range members {
+			if zset.Add(members[i], scores[i]) {
+				added++
+			}
+		}
+		return zset, nil
+	})
+	return added, version, err
+}
+
+func ZIncrBy(kv KeyValue, key, member []byte, delta float64) (float64, error) {
+	var score float64
+	_, err := kv.UpdateObject(key, int64(len(member)+scoreSize), func(obj Object) (Object, error) {
+		zset := NewZSet()
+		if obj != nil {
+			var err error
+			if zset, err = zsetOf(key, obj); err != nil {
+				return nil, err
+			}
+		}
+
+		current, _ := zset.Score(member)
+		score = current + delta
+		if math.IsInf(score, 0) || math.IsNaN(score) {
+			return nil, newError(ErrOverflow, constants.OverflowErr, key, delta)
+		}
+
+		zset.Add(member, score)
+		return zset, nil
+	})
+	return score, err
+}
+
+func ZRem(kv KeyValue, key []byte, members [][]byte) (int, error) {
+	removed := 0
+	_, err := kv.UpdateObject(key, 0, func(obj Object) (Object, error) {
+		if obj == nil {
+			return nil, nil
+		}
+
+		zset, err := zsetOf(key, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range members {
+			if zset.Rem(member) {
+				removed++
+			}
+		}
+
+		if zset.Len() == 0 {
+			return nil, nil
+		}
+		return zset, nil
+	})
+	return removed, err
+}
+
+func ZScore(kv KeyValue, key, member []byte) (float64, error) {
+	var score float64
+	err := kv.ViewObject(key, func(obj Object) error {
+		zset, err := zsetOf(key, obj)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		if score, ok = zset.Score(member); !ok {
+			return newError(ErrNotFound, constants.UnsetMemberErr, member, key)
+		}
+		return nil
+	})
+	return score, err
+}
+
+func ZRange(kv KeyValue, key []byte, start, stop int64) ([][]byte, []float64, error) {
+	return viewZSet(kv, key, func(zset *ZSet) ([][]byte, []float64) {
+		return zset.Range(start, stop)
+	})
+}
+
+func ZRangeByScore(kv KeyValue, key []byte, min, max float64) ([][]byte, []float64, error) {
+	return viewZSet(kv, key, func(zset *ZSet) ([][]byte, []float64) {
+		return zset.RangeByScore(min, max)
+	})
+}
+
+func viewZSet(
+	kv KeyValue, key []byte, fn func(*ZSet) ([][]byte, []float64),
+) ([][]byte, []float64, error) {
+	var members [][]byte
+	var scores []float64
+	err := kv.ViewObject(key, func(obj Object) error {
+		zset, err := zsetOf(key, obj)
+		if err != nil {
+			return err
+		}
+
+		members, scores = fn(zset)
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return [][]byte{}, []float64{}, nil
+	}
+	return members, scores, err
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZSet(t *testing.T) {
+	t.Parallel()
+	for _, cache := range caches() {
+		cache := cache
+		t.Run("zset", func(t *testing.T) {
+			t.Parallel()
+			key := []byte("board")
+			added, version, err := ZAdd(cache, key, bytesOf("ada", "bob", "cy"), []float64{30, 10, 20})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, added)
+			assert.NotZero(t, version)
+
+			added, _, err = ZAdd(cache, key, bytesOf("bob", "dee"), []float64{40, 20})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, added)
+
+			members, scores, err := ZRange(cache, key, 0, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, bytesOf("cy", "dee", "ada", "bob"), members, "ties order by member")
+			assert.Equal(t, []float64{20, 20, 30, 40}, scores)
+
+			members, _, err = ZRange(cache, key, -2, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, bytesOf("ada", "bob"), members)
+
+			members, scores, err = ZRangeByScore(cache, key, 20, 30)
+			assert.NoError(t, err)
+			assert.Equal(t, bytesOf("cy", "dee", "ada"), members)
+			assert.Equal(t, []float64{20, 20, 30}, scores)
+
+			score, err := ZIncrBy(cache, key, []byte("cy"), 25.5)
+			assert.NoError(t, err)
+			assert.Equal(t, 45.5, score)
+			score, err = ZIncrBy(cache, key, []byte("eve"), -1)
+			assert.NoError(t, err)
+			assert.Equal(t, float64(-1), score)
+
+			score, err = ZScore(cache, key, []byte("cy"))
+			assert.NoError(t, err)
+			assert.Equal(t, 45.5, score)
+			_, err = ZScore(cache, key, []byte("missing"))
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			big := []byte("big")
+			_, err = ZIncrBy(cache, big, []byte("m"), math.MaxFloat64)
+			assert.NoError(t, err)
+			_, err = ZIncrBy(cache, big, []byte("m"), math.MaxFloat64)
+			assert.ErrorIs(t, err, ErrOverflow)
+			score, err = ZScore(cache, big, []byte("m"))
+			assert.NoError(t, err)
+			assert.Equal(t, math.MaxFloat64, score, "an overflow keeps the score")
+
+			removed, err := ZRem(cache, key, bytesOf("eve", "missing"))
+			assert.NoError(t, err)
+			assert.Equal(t, 1, removed)
+			removed, err = ZRem(cache, key, bytesOf("ada", "bob", "cy", "dee"))
+			assert.NoError(t, err)
+			assert.Equal(t, 4, removed)
+			_, err = cache.TTL(key)
+			assert.ErrorIs(t, err, ErrNotFound, "removing the last member deletes the key")
+
+			members, scores, err = ZRange(cache, key, 0, -1)
+			assert.NoError(t, err)
+			assert.Empty(t, members)
+			assert.Empty(t, scores)
+
+			assert.NoError(t, cache.Set([]byte("str"), []byte("v")))
+			_, _, err = ZAdd(cache, []byte("str"), bytesOf("m"), []float64{1})
+			assert.ErrorIs(t, err, ErrWrongType)
+			_, _, err = ZRangeByScore(cache, []byte("str"), 0, 1)
+			assert.ErrorIs(t, err, ErrWrongType)
+		})
+	}
+}
+
+// TestZSetSkipList checks ranks and score ranges against a sorted copy while
+// members are added, rescored and removed at random.
+func TestZSetSkipList(t *testing.T) {
+	t.Parallel()
+	zset := NewZSet()
+	want := map[string]float64{}
+	for i := 0; i < 2000; i++ {
+		member := strconv.Itoa(rand.Intn(300))
+		if rand.Intn(4) == 0 {
+			_, ok := want[member]
+			assert.Equal(t, ok, zset.Rem([]byte(member)))
+			delete(want, member)
+			continue
+		}
+
+		score := float64(rand.Intn(50))
+		_, ok := want[member]
+		assert.Equal(t, !ok, zset.Add([]byte(member), score))
+		want[member] = score
+	}
+
+	sorted := make([]string, 0, len(want))
+	size := int64(0)
+	for member := range want {
+		sorted = append(sorted, member)
+		size += int64(len(member) + scoreSize)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		return want[a] < want[b] || (want[a] == want[b] && a < b)
+	})
+	assert.Equal(t, size, zset.Size())
+
+	members, _ := zset.Range(0, -1)
+	assert.Equal(t, bytesOf(sorted...), members)
+	clone, _ := zset.Clone().(*ZSet).Range(0, -1)
+	assert.Equal(t, members, clone)
+
+	for rank := range sorted {
+		members, scores := zset.Range(int64(rank), int64(rank))
+		assert.Equal(t, bytesOf(sorted[rank]), members)
+		assert.Equal(t, []float64{want[sorted[rank]]}, scores)
+	}
+
+	members, _ = zset.RangeByScore(10, 19)
+	inRange := []string{}
+	for _, member := range sorted {
+		if want[member] >= 10 && want[member] <= 19 {
+			inRange = append(inRange, member)
+		}
+	}
+	assert.Equal(t, bytesOf(inRange...), members)
+
+	members, _ = zset.RangeByScore(math.Inf(-1), math.Inf(1))
+	assert.Len(t, members, len(sorted))
+	members, _ = zset.RangeByScore(30, 20)
+	assert.Empty(t, members)
+}
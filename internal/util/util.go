@@ -2,8 +2,8 @@ package util
 
 import (
 	"errors"
-	"fmt"
 	"net"
+	"os"
 	"sync/atomic"
 
 	"github.com/kevindweb/cache/internal/constants"
@@ -13,17 +13,12 @@ var (
 	uniquePort uint32 = constants.DefaultPort //nolint:gochecknoglobals // need globally unique port
 )
 
-func ErrResponse(err string) []string {
-	return []string{fmt.Sprintf("%c%s", constants.ERR, err)}
-}
-
 func IsTimeout(err error) bool {
 	if err == nil {
 		return false
 	}
 	var netErr net.Error
-	ok := !errors.As(err, &netErr)
-	return ok && netErr.Timeout()
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 func GetUniquePort() int {
@@ -33,3 +28,13 @@ func GetUniquePort() int {
 func ReadRequestBytes(data []byte) string {
 	return string(data[constants.HeaderSize:])
 }
+
+// SyncDir makes a rename into dir survive a crash, syncing the file itself
+// does not persist its directory entry.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
@@ -1,11 +1,11 @@
 package client
 
 import (
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
 	"net"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/kevindweb/cache/internal/constants"
@@ -13,15 +13,30 @@ import (
 	"github.com/kevindweb/cache/internal/util"
 )
 
+const NoExpiration = constants.NoExpiration
+
 type Client struct {
-	workers  []Worker
-	requests chan clientReq
+	workers        []*Worker
+	requests       chan clientReq
+	pipelines      chan []clientReq
+	requestTimeout time.Duration
+	router         *slotRouter
+	network        string
+	addr           string
+	dialTimeout    time.Duration
+	readTimeout    time.Duration
 }
 
 type Options struct {
-	Host    string
-	Port    int
-	Network string
+	Host           string
+	Port           int
+	Network        string
+	PoolSize       int
+	BatchWindow    time.Duration
+	MaxBatch       int
+	DialTimeout    time.Duration
+	ReadTimeout    time.Duration
+	RequestTimeout time.Duration
 }
 
 func fillDefaultOptions(opts *Options) Options {
@@ -41,45 +56,128 @@ func fillDefaultOptions(opts *Options) Options {
 		opts.Network = constants.DefaultNetwork
 	}
 
+	if opts.PoolSize == 0 {
+		opts.PoolSize = constants.MaxConnectionPool
+	}
+
+	if opts.BatchWindow == 0 {
+		opts.BatchWindow = constants.BaseWaitTime
+	}
+
+	if opts.MaxBatch == 0 {
+		opts.MaxBatch = constants.MaxRequestBatch
+	}
+
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = constants.DialTimeout
+	}
+
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = constants.ReadTimeout
+	}
+
+	if opts.RequestTimeout == 0 {
+		opts.RequestTimeout = constants.ClientRequestTimeout
+	}
+
 	return *opts
 }
 
+func validateOptions(opts Options) error {
+	if opts.Port <= 0 {
+		return fmt.Errorf(constants.InvalidPortErr, opts.Port)
+	}
+
+	if opts.PoolSize < 1 {
+		return fmt.Errorf(constants.InvalidPoolSizeErr, opts.PoolSize)
+	}
+
+	if opts.MaxBatch < 1 || opts.MaxBatch > constants.MaxRequestBatch {
+		return fmt.Errorf(constants.InvalidMaxBatchErr, opts.MaxBatch, constants.MaxRequestBatch)
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"batch window", opts.BatchWindow},
+		{"dial timeout", opts.DialTimeout},
+		{"read timeout", opts.ReadTimeout},
+		{"request timeout", opts.RequestTimeout},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return fmt.Errorf(constants.InvalidDurationErr, d.name, d.value)
+		}
+	}
+
+	return nil
+}
+
 func New(opts Options) (*Client, error) {
 	opts = fillDefaultOptions(&opts)
-	maxClientRequests := constants.MaxRequestBatch * constants.MaxConnectionPool
-	requests := make(chan clientReq, maxClientRequests)
-	pool, err := createWorkers(requests, opts)
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+
+	requests := make(chan clientReq, opts.MaxBatch*opts.PoolSize)
+	pipelines := make(chan []clientReq, opts.PoolSize)
+	pool, err := createWorkers(requests, pipelines, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Client{
-		workers:  pool,
-		requests: requests,
+		workers:        pool,
+		requests:       requests,
+		pipelines:      pipelines,
+		requestTimeout: opts.RequestTimeout,
+		network:        opts.Network,
+		addr:           address(opts),
+		dialTimeout:    opts.DialTimeout,
+		readTimeout:    opts.ReadTimeout,
 	}, nil
 }
 
 func createWorkers(
-	requests chan clientReq, opts Options,
-) ([]Worker, error) {
-	addr := fmt.Sprintf("%s:%d", opts.Host, opts.Port)
-	pool := make([]Worker, 0, constants.MaxConnectionPool)
-	for i := 0; i < constants.MaxConnectionPool; i++ {
-		conn, err := connectWithTimeout(addr, constants.DialTimeout, opts)
+	requests chan clientReq, pipelines chan []clientReq, opts Options,
+) ([]*Worker, error) {
+	addr := address(opts)
+	pool := make([]*Worker, 0, opts.PoolSize)
+	for i := 0; i < opts.PoolSize; i++ {
+		conn, err := connectWithTimeout(addr, opts.DialTimeout, opts)
 		if err != nil {
+			closeWorkers(pool)
 			return nil, err
 		}
 
-		worker := Worker{
-			conn:     conn,
-			shutdown: make(chan bool, 1),
-			requests: requests,
+		worker := &Worker{
+			conn:        conn,
+			shutdown:    make(chan bool, 1),
+			requests:    requests,
+			pipelines:   pipelines,
+			network:     opts.Network,
+			addr:        addr,
+			dialTimeout: opts.DialTimeout,
+			batchWindow: opts.BatchWindow,
+			maxBatch:    opts.MaxBatch,
+			readTimeout: opts.ReadTimeout,
 		}
 		pool = append(pool, worker)
 	}
 	return pool, nil
 }
 
+func address(opts Options) string {
+	return fmt.Sprintf("%s:%d", opts.Host, opts.Port)
+}
+
+func closeWorkers(pool []*Worker) {
+	for _, worker := range pool {
+		_ = worker.close()
+	}
+}
+
 func connectWithTimeout(
 	addr string, timeout time.Duration, opts Options,
 ) (net.Conn, error) {
@@ -103,20 +201,30 @@ func connectWithTimeout(
 }
 
 func (c *Client) Ping() error {
+	return c.PingCtx(context.Background())
+}
+
+func (c *Client) PingCtx(ctx context.Context) error {
 	if err := c.validateClient(); err != nil {
 		return err
 	}
 
-	return c.pingRequest()
+	return c.pingRequest(ctx)
 }
 
 type clientReq struct {
+	ctx context.Context
 	req protocol.Operation
-	res chan []string
+	res chan clientRes
+}
+
+type clientRes struct {
+	protocol.Result
+	err error
 }
 
-func (c *Client) pingRequest() error {
-	response, err := c.sendRequest(protocol.Operation{
+func (c *Client) pingRequest(ctx context.Context) error {
+	response, err := c.sendRequest(ctx, protocol.Operation{
 		Type: protocol.PING,
 	})
 	if err != nil {
@@ -126,78 +234,114 @@ func (c *Client) pingRequest() error {
 	return expectResponse(constants.PING, constants.PONG, response)
 }
 
-func (c *Client) sendRequest(op protocol.Operation) ([]string, error) {
-	resChan := make(chan []string)
-	c.requests <- clientReq{
-		req: op,
-		res: resChan,
+func (c *Client) sendRequest(
+	ctx context.Context, op protocol.Operation,
+) (protocol.Result, error) {
+	if c.router != nil {
+		return c.router.send(ctx, op)
 	}
-	select {
-	case res := <-resChan:
-		return res, nil
-	case <-time.After(constants.ClientRequestTimeout):
-		return []string{}, fmt.Errorf(
-			constants.ClientRequestTimeoutErr, op.Type, constants.ClientRequestTimeout,
-		)
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
 	}
-}
 
-func errorResponse(command string, res []string) error {
-	if len(res) == 0 {
-		return fmt.Errorf(constants.EmptyResErr, command)
+	req := clientReq{
+		ctx: ctx,
+		req: op,
+		res: make(chan clientRes, 1),
+	}
+	select {
+	case c.requests <- req:
+	case <-ctx.Done():
+		return protocol.Result{}, fmt.Errorf(constants.ClientRequestCancelledErr, op.Type, ctx.Err())
 	}
 
-	first := res[0]
-	if len(first) == 0 {
-		return fmt.Errorf(constants.EmptyResArgErr, command)
+	select {
+	case res := <-req.res:
+		if res.err != nil {
+			return res.Result, res.err
+		}
+		return res.Result, resultErr(res.Result)
+	case <-ctx.Done():
+		return protocol.Result{}, fmt.Errorf(constants.ClientRequestCancelledErr, op.Type, ctx.Err())
 	}
+}
 
-	if first[0] != constants.ERR {
+func resultErr(res protocol.Result) error {
+	if res.Status == protocol.SUCCESS {
 		return nil
 	}
 
-	return fmt.Errorf(first[1:])
+	return &statusError{status: res.Status, message: string(res.Message)}
 }
 
 func (c *Client) Get(key string) (string, error) {
+	return c.GetCtx(context.Background(), key)
+}
+
+func (c *Client) GetCtx(ctx context.Context, key string) (string, error) {
 	if err := c.validateParams(key); err != nil {
 		return "", err
 	}
 
-	response, err := c.sendRequest(protocol.Operation{
-		Type: protocol.GET,
-		Key:  []byte(key),
-	})
+	val, err := c.GetBytesCtx(ctx, []byte(key))
 	if err != nil {
 		return "", err
 	}
-	return getResponse(key, response)
-}
 
-func getResponse(key string, res []string) (string, error) {
-	if err := errorResponse(constants.GET, res); err != nil {
-		return "", err
+	if len(val) == 0 {
+		return "", fmt.Errorf(constants.EmptyValueErr, key)
 	}
+	return string(val), nil
+}
 
-	if len(res) != 1 || res[0] == "" {
-		return "", fmt.Errorf(
-			"expected value for key %s, received %d results: %v",
-			key, len(res), res,
-		)
+func (c *Client) GetBytes(key []byte) ([]byte, error) {
+	return c.GetBytesCtx(context.Background(), key)
+}
+
+func (c *Client) GetBytesCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if err := c.validateKeys(key); err != nil {
+		return nil, err
 	}
 
-	return res[0], nil
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.GET,
+		Key:  key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Message, nil
 }
 
 func (c *Client) Set(key, val string) error {
+	return c.SetCtx(context.Background(), key, val)
+}
+
+func (c *Client) SetCtx(ctx context.Context, key, val string) error {
 	if err := c.validateParams(key, val); err != nil {
 		return err
 	}
 
-	response, sendErr := c.sendRequest(protocol.Operation{
+	return c.SetBytesCtx(ctx, []byte(key), []byte(val))
+}
+
+// SetBytes stores val under key, unlike Set the value may be empty.
+func (c *Client) SetBytes(key, val []byte) error {
+	return c.SetBytesCtx(context.Background(), key, val)
+}
+
+func (c *Client) SetBytesCtx(ctx context.Context, key, val []byte) error {
+	if err := c.validateKeys(key); err != nil {
+		return err
+	}
+
+	response, sendErr := c.sendRequest(ctx, protocol.Operation{
 		Type:  protocol.SET,
-		Key:   []byte(key),
-		Value: []byte(val),
+		Key:   key,
+		Value: val,
 	})
 	if sendErr != nil {
 		return sendErr
@@ -205,264 +349,240 @@ func (c *Client) Set(key, val string) error {
 	return expectResponse(constants.SET, constants.OK, response)
 }
 
-func (c *Client) validateParams(params ...string) error {
-	if err := c.validateClient(); err != nil {
+func (c *Client) SetEx(key, val string, ttl time.Duration) error {
+	return c.SetExCtx(context.Background(), key, val, ttl)
+}
+
+func (c *Client) SetExCtx(ctx context.Context, key, val string, ttl time.Duration) error {
+	if err := c.validateParams(key, val); err != nil {
 		return err
 	}
 
-	for _, param := range params {
-		if param == "" {
-			return errors.New(constants.EmptyParamErr)
-		}
+	if err := validateTTL(ttl); err != nil {
+		return err
 	}
 
-	return nil
+	response, sendErr := c.sendRequest(ctx, protocol.Operation{
+		Type:  protocol.SETEX,
+		Key:   []byte(key),
+		Value: []byte(val),
+		TTL:   ttl.Milliseconds(),
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	return expectResponse(constants.SETEX, constants.OK, response)
 }
 
-func (c *Client) Del(key string) error {
+func (c *Client) Expire(key string, ttl time.Duration) error {
+	return c.ExpireCtx(context.Background(), key, ttl)
+}
+
+func (c *Client) ExpireCtx(ctx context.Context, key string, ttl time.Duration) error {
 	if err := c.validateParams(key); err != nil {
 		return err
 	}
 
-	response, sendErr := c.sendRequest(protocol.Operation{
-		Type: protocol.DELETE,
+	if err := validateTTL(ttl); err != nil {
+		return err
+	}
+
+	response, sendErr := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.EXPIRE,
 		Key:  []byte(key),
+		TTL:  ttl.Milliseconds(),
 	})
 	if sendErr != nil {
 		return sendErr
 	}
+	return expectResponse(constants.EXPIRE, constants.OK, response)
+}
 
-	return expectResponse(constants.DEL, constants.OK, response)
+func validateTTL(ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return fmt.Errorf(constants.InvalidTTLErr, ttl)
+	}
+
+	return nil
 }
 
-func expectResponse(command, expected string, res []string) error {
-	if err := errorResponse(command, res); err != nil {
-		return err
+func (c *Client) TTL(key string) (time.Duration, error) {
+	return c.TTLCtx(context.Background(), key)
+}
+
+func (c *Client) TTLCtx(ctx context.Context, key string) (time.Duration, error) {
+	if err := c.validateParams(key); err != nil {
+		return 0, err
 	}
 
-	if len(res) != 1 || res[0] != expected {
-		return fmt.Errorf(
-			"expected %s for %s, received %v", expected, command, res,
-		)
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.TTL,
+		Key:  []byte(key),
+	})
+	if err != nil {
+		return 0, err
 	}
+	return ttlResponse(response)
+}
 
-	return nil
+func ttlResponse(res protocol.Result) (time.Duration, error) {
+	if string(res.Message) == constants.PERSISTENT {
+		return NoExpiration, nil
+	}
+
+	ms, err := strconv.ParseInt(string(res.Message), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(constants.InvalidIntErr, constants.TTL, res.Message)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
 }
 
-func (c *Client) validateClient() error {
-	if len(c.workers) == 0 {
-		return errors.New(constants.ClientUninitializedErr)
+func (c *Client) Persist(key string) error {
+	return c.PersistCtx(context.Background(), key)
+}
+
+func (c *Client) PersistCtx(ctx context.Context, key string) error {
+	if err := c.validateParams(key); err != nil {
+		return err
 	}
 
-	return nil
+	response, sendErr := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.PERSIST,
+		Key:  []byte(key),
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	return expectResponse(constants.PERSIST, constants.OK, response)
 }
 
-func StartDefault() (*Client, error) {
-	return StartOptions(Options{})
+func (c *Client) Save() error {
+	return c.SaveCtx(context.Background())
 }
 
-func StartOptions(opts Options) (*Client, error) {
-	c, err := New(opts)
-	if err != nil {
-		return nil, err
+func (c *Client) SaveCtx(ctx context.Context) error {
+	if err := c.validateClient(); err != nil {
+		return err
 	}
 
-	c.Start()
-	return c, c.Ping()
+	response, err := c.sendRequest(ctx, protocol.Operation{Type: protocol.SAVE})
+	if err != nil {
+		return err
+	}
+	return expectResponse(constants.SAVE, constants.OK, response)
 }
 
-func (c *Client) Start() {
-	for _, worker := range c.workers {
-		worker := worker
-		go worker.scheduler()
+func (c *Client) validateParams(params ...string) error {
+	if err := c.validateClient(); err != nil {
+		return err
 	}
+
+	return validateParams(params...)
 }
 
-func (c *Client) Stop() error {
-	for _, worker := range c.workers {
-		worker.shutdown <- true
-		if err := worker.conn.Close(); err != nil {
-			return err
+func (c *Client) validateKeys(keys ...[]byte) error {
+	if err := c.validateClient(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if len(key) == 0 {
+			return errors.New(constants.EmptyParamErr)
 		}
 	}
 
 	return nil
 }
 
-type Worker struct {
-	conn     net.Conn
-	shutdown chan bool
-	requests chan clientReq
+func (c *Client) Del(key string) error {
+	return c.DelCtx(context.Background(), key)
 }
 
-func (w *Worker) scheduler() {
-	var (
-		timer *time.Timer
-		mu    sync.Mutex
+func (c *Client) DelCtx(ctx context.Context, key string) error {
+	if err := c.validateParams(key); err != nil {
+		return err
+	}
 
-		batch = &protocol.BatchedRequest{
-			Operations: []protocol.Operation{},
-		}
-		requests = []clientReq{}
-	)
+	return c.DelBytesCtx(ctx, []byte(key))
+}
 
-	for {
-		select {
-		case <-w.shutdown:
-			return
-		case req := <-w.requests:
-			mu.Lock()
-			if len(batch.Operations) == 0 {
-				timer = time.AfterFunc(constants.BaseWaitTime, func() {
-					mu.Lock()
-					defer mu.Unlock()
-					w.processBatch(batch, requests)
-					batch.Operations = []protocol.Operation{}
-					requests = []clientReq{}
-					timer.Reset(constants.BaseWaitTime)
-				})
-			}
-			w.processNewRequest(req, batch, &requests, timer)
-			mu.Unlock()
-		}
-	}
+func (c *Client) DelBytes(key []byte) error {
+	return c.DelBytesCtx(context.Background(), key)
 }
 
-func (w *Worker) processNewRequest(
-	req clientReq,
-	batch *protocol.BatchedRequest,
-	requests *[]clientReq,
-	timer *time.Timer,
-) {
-	batch.Operations = append(batch.Operations, req.req)
-	*requests = append(*requests, req)
-	if len(batch.Operations) < constants.MaxRequestBatch {
-		return
+func (c *Client) DelBytesCtx(ctx context.Context, key []byte) error {
+	if err := c.validateKeys(key); err != nil {
+		return err
 	}
 
-	timer.Stop()
-	w.processBatch(batch, *requests)
-	batch.Operations = batch.Operations[:0]
-	(*requests) = []clientReq{}
-	timer.Reset(constants.BaseWaitTime)
-}
-
-func (w *Worker) processBatch(
-	batch *protocol.BatchedRequest, requests []clientReq,
-) {
-	if len(requests) == 0 {
-		return
+	response, sendErr := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.DELETE,
+		Key:  key,
+	})
+	if sendErr != nil {
+		return sendErr
 	}
 
-	ops, requestIndex := requestDeduplication(batch.Operations)
-	batch.Operations = ops
+	return expectResponse(constants.DEL, constants.OK, response)
+}
 
-	encoded, err := batch.MarshalMsg(nil)
-	if err != nil {
-		batchError(err, requests)
-		return
+func expectResponse(command, expected string, res protocol.Result) error {
+	if string(res.Message) != expected {
+		return fmt.Errorf(
+			"expected %s for %s, received %q", expected, command, res.Message,
+		)
 	}
 
-	_, err = w.conn.Write(encoded)
-	if err != nil {
-		batchError(err, requests)
-		return
-	}
+	return nil
+}
 
-	responseBytes, err := readFromConnection(w.conn, constants.ReadTimeout)
-	if err != nil {
-		batchError(err, requests)
-		return
-	}
-
-	batchResponse := &protocol.BatchedResponse{}
-	_, unmarshalErr := batchResponse.UnmarshalMsg(responseBytes)
-	if unmarshalErr != nil {
-		batchError(unmarshalErr, requests)
-		return
-	}
-
-	responses := batchResponse.Results
-	if len(responses) != len(batch.Operations) {
-		if len(responses) == 1 {
-			err = fmt.Errorf(
-				"received 1 response: (%s) %s, requests: %v",
-				responses[0].Status,
-				responses[0].Message,
-				batch.Operations[0],
-			)
-		} else {
-			err = fmt.Errorf(
-				"expected %d responses, received %d",
-				len(requests), len(responses),
-			)
-		}
-		batchError(err, requests)
-		return
+func (c *Client) validateClient() error {
+	if len(c.workers) == 0 && c.router == nil {
+		return errors.New(constants.ClientUninitializedErr)
 	}
 
-	propagateBatch(responses, requests, requestIndex)
+	return nil
 }
 
-func requestDeduplication(operations []protocol.Operation) ([]protocol.Operation, map[int][]int) {
-	deduplicated := []protocol.Operation{}
-	index := make(map[int][]int)
-	seen := make(map[string]int)
+func StartDefault() (*Client, error) {
+	return StartOptions(Options{})
+}
 
-	for i, op := range operations {
-		hash := op.Index()
-		if updatedInx, opSeen := seen[hash]; opSeen {
-			index[updatedInx] = append(index[updatedInx], i)
-		} else {
-			newInx := len(deduplicated)
-			seen[hash] = newInx
-			deduplicated = append(deduplicated, op)
-			index[newInx] = []int{i}
-		}
+func StartOptions(opts Options) (*Client, error) {
+	c, err := New(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	return deduplicated, index
+	c.Start()
+	return c, c.Ping()
 }
 
-func batchError(err error, requests []clientReq) {
-	bulkErr := util.ErrResponse(err.Error())
-	for _, req := range requests {
-		req.res <- bulkErr
+func (c *Client) Start() {
+	for _, worker := range c.workers {
+		go worker.scheduler()
 	}
 }
 
-func propagateBatch(responses []protocol.Result, requests []clientReq, index map[int][]int) {
-	for i, res := range responses {
-		for _, dup := range index[i] {
-			req := requests[dup]
-			msg := string(res.Message)
-			if res.Status == protocol.FAILURE {
-				req.res <- util.ErrResponse(msg)
-			} else {
-				req.res <- []string{msg}
-			}
-		}
+func (c *Client) Stop() error {
+	if c.router != nil {
+		return c.router.stop()
 	}
-}
 
-func readFromConnection(conn net.Conn, timeout time.Duration) ([]byte, error) {
-	err := conn.SetReadDeadline(time.Now().Add(timeout))
-	if err != nil {
-		return []byte{}, err
+	for _, worker := range c.workers {
+		worker.stop()
+		if err := worker.close(); err != nil {
+			return err
+		}
 	}
 
-	responseLengthBytes := make([]byte, constants.HeaderSize)
-	_, err = conn.Read(responseLengthBytes)
-	if err != nil {
-		return []byte{}, err
-	}
+	return nil
+}
 
-	responseLength := int(binary.LittleEndian.Uint32(responseLengthBytes))
-	responseBytes := make([]byte, responseLength)
-	_, err = conn.Read(responseBytes)
-	if err != nil {
-		return []byte{}, err
+func (c *Client) Health() []WorkerHealth {
+	health := make([]WorkerHealth, len(c.workers))
+	for i, worker := range c.workers {
+		health[i] = worker.Health()
 	}
-
-	return responseBytes, nil
+	return health
 }
@@ -1,17 +1,43 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/kevindweb/cache/internal/constants"
 	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/slots"
 	"github.com/stretchr/testify/require"
 )
 
+func success(msg string) protocol.Result {
+	return protocol.Result{
+		Status:  protocol.SUCCESS,
+		Message: []byte(msg),
+	}
+}
+
+func failure(msg string) protocol.Result {
+	return protocol.Result{
+		Status:  protocol.FAILURE,
+		Message: []byte(msg),
+	}
+}
+
 func setupClient() *Client {
 	return &Client{
-		requests: make(chan clientReq),
-		workers:  []Worker{{}},
+		requests:       make(chan clientReq),
+		pipelines:      make(chan []clientReq),
+		workers:        []*Worker{{}},
+		requestTimeout: constants.ClientRequestTimeout,
 	}
 }
 
@@ -21,14 +47,14 @@ func TestGet(t *testing.T) {
 	testCases := []struct {
 		name              string
 		key               string
-		response          string
+		response          protocol.Result
 		failBeforeRequest bool
 		check             func(*testing.T, string, error)
 	}{
 		{
 			name:     "valid get",
 			key:      "key",
-			response: "data",
+			response: success("data"),
 			check: func(t *testing.T, v string, err error) {
 				require.NoError(t, err)
 				require.Equal(t, "data", v)
@@ -37,7 +63,7 @@ func TestGet(t *testing.T) {
 		{
 			name:     "expect error on invalid get response",
 			key:      "key",
-			response: "",
+			response: success(""),
 			check: func(t *testing.T, _ string, err error) {
 				require.Error(t, err)
 			},
@@ -53,7 +79,7 @@ func TestGet(t *testing.T) {
 		{
 			name:     "error response",
 			key:      "key",
-			response: "-Invalid get",
+			response: failure("Invalid get"),
 			check: func(t *testing.T, _ string, err error) {
 				require.Equal(t, "Invalid get", err.Error())
 			},
@@ -73,7 +99,7 @@ func TestGet(t *testing.T) {
 						Key:  []byte(tc.key),
 					}
 					require.Equal(t, getOperation, req.req)
-					req.res <- []string{tc.response}
+					req.res <- clientRes{Result: tc.response}
 				}()
 			}
 
@@ -83,6 +109,118 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetBytes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		res   clientRes
+		check func(*testing.T, []byte, error)
+	}{
+		{
+			name: "value with leading dash",
+			res:  clientRes{Result: success("-not an error")},
+			check: func(t *testing.T, v []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []byte("-not an error"), v)
+			},
+		},
+		{
+			name: "empty value",
+			res:  clientRes{Result: success("")},
+			check: func(t *testing.T, v []byte, err error) {
+				require.NoError(t, err)
+				require.Empty(t, v)
+			},
+		},
+		{
+			name: "failure status",
+			res:  clientRes{Result: failure("key not set")},
+			check: func(t *testing.T, _ []byte, err error) {
+				require.EqualError(t, err, "key not set")
+			},
+		},
+		{
+			name: "batch error keeps its type",
+			res:  clientRes{err: fmt.Errorf("%w: read", ErrTimeout)},
+			check: func(t *testing.T, _ []byte, err error) {
+				require.ErrorIs(t, err, ErrTimeout)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := setupClient()
+			key := []byte{0, '-', 0xff}
+			go func() {
+				req := <-c.requests
+				require.Equal(t, key, req.req.Key)
+				req.res <- tc.res
+			}()
+
+			val, err := c.GetBytes(key)
+			tc.check(t, val, err)
+		})
+	}
+}
+
+func TestSetBytesEmptyValue(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		require.Equal(t, protocol.SET, req.req.Type)
+		require.Empty(t, req.req.Value)
+		req.res <- clientRes{Result: success(constants.OK)}
+	}()
+	require.NoError(t, c.SetBytes([]byte("key"), nil))
+	require.EqualError(t, c.SetBytes(nil, []byte("val")), constants.EmptyParamErr)
+}
+
+func TestPropagateBatchCopiesDuplicates(t *testing.T) {
+	t.Parallel()
+	requests := []clientReq{
+		{res: make(chan clientRes, 1)},
+		{res: make(chan clientRes, 1)},
+	}
+	propagateBatch(
+		[]protocol.Result{success("value")},
+		requests,
+		map[int][]int{0: {0, 1}},
+	)
+	first := <-requests[0].res
+	second := <-requests[1].res
+	first.Message[0] = 'V'
+	require.Equal(t, []byte("value"), second.Message)
+}
+
+func TestPropagateBatchCopiesEveryField(t *testing.T) {
+	t.Parallel()
+	requests := []clientReq{
+		{res: make(chan clientRes, 1)},
+		{res: make(chan clientRes, 1)},
+	}
+	res := protocol.Result{
+		Status:  protocol.SUCCESS,
+		Message: []byte("value"),
+		Version: 7,
+		Values:  [][]byte{[]byte("field"), []byte("value")},
+		Results: []protocol.Result{{Message: []byte("ok"), Version: 3, Values: [][]byte{[]byte("nested")}}},
+	}
+	propagateBatch([]protocol.Result{res}, requests, map[int][]int{0: {0, 1}})
+	first := <-requests[0].res
+	second := <-requests[1].res
+	require.Equal(t, first.Result, second.Result)
+
+	first.Values[0][0] = 'F'
+	first.Results[0].Values[0][0] = 'N'
+	require.Equal(t, []byte("field"), second.Values[0])
+	require.Equal(t, []byte("nested"), second.Results[0].Values[0])
+}
+
 func TestSet(t *testing.T) {
 	t.Parallel()
 
@@ -90,7 +228,7 @@ func TestSet(t *testing.T) {
 		name              string
 		key               string
 		val               string
-		response          string
+		response          protocol.Result
 		failBeforeRequest bool
 		check             func(*testing.T, error)
 	}{
@@ -98,7 +236,7 @@ func TestSet(t *testing.T) {
 			name:     "valid set",
 			key:      "key",
 			val:      "val",
-			response: constants.OK,
+			response: success(constants.OK),
 			check: func(t *testing.T, err error) {
 				require.NoError(t, err)
 			},
@@ -107,7 +245,7 @@ func TestSet(t *testing.T) {
 			name:     "expect error on invalid set response",
 			key:      "key",
 			val:      "val",
-			response: "",
+			response: success(""),
 			check: func(t *testing.T, err error) {
 				require.Error(t, err)
 			},
@@ -125,7 +263,7 @@ func TestSet(t *testing.T) {
 			name:     "error response",
 			key:      "key",
 			val:      "val",
-			response: "-Invalid set",
+			response: failure("Invalid set"),
 			check: func(t *testing.T, err error) {
 				require.Equal(t, "Invalid set", err.Error())
 			},
@@ -146,7 +284,7 @@ func TestSet(t *testing.T) {
 						Value: []byte(tc.val),
 					}
 					require.Equal(t, setOperation, req.req)
-					req.res <- []string{tc.response}
+					req.res <- clientRes{Result: tc.response}
 				}()
 			}
 			tc.check(t, c.Set(tc.key, tc.val))
@@ -160,14 +298,14 @@ func TestDelete(t *testing.T) {
 	testCases := []struct {
 		name              string
 		key               string
-		response          string
+		response          protocol.Result
 		failBeforeRequest bool
 		check             func(*testing.T, error)
 	}{
 		{
 			name:     "valid delete",
 			key:      "delete",
-			response: constants.OK,
+			response: success(constants.OK),
 			check: func(t *testing.T, err error) {
 				require.NoError(t, err)
 			},
@@ -175,7 +313,7 @@ func TestDelete(t *testing.T) {
 		{
 			name:     "expect error on invalid del response",
 			key:      "delete",
-			response: constants.PING,
+			response: success(constants.PING),
 			check: func(t *testing.T, err error) {
 				require.Error(t, err)
 			},
@@ -191,7 +329,7 @@ func TestDelete(t *testing.T) {
 		{
 			name:     "error response",
 			key:      "delete",
-			response: "-Invalid delete",
+			response: failure("Invalid delete"),
 			check: func(t *testing.T, err error) {
 				require.Equal(t, "Invalid delete", err.Error())
 			},
@@ -211,7 +349,7 @@ func TestDelete(t *testing.T) {
 						Key:  []byte(tc.key),
 					}
 					require.Equal(t, delOperation, req.req)
-					req.res <- []string{tc.response}
+					req.res <- clientRes{Result: tc.response}
 				}()
 			}
 			tc.check(t, c.Del(tc.key))
@@ -219,32 +357,101 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestTTL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		response protocol.Result
+		check    func(*testing.T, time.Duration, error)
+	}{
+		{
+			name:     "remaining ttl",
+			response: success("1500"),
+			check: func(t *testing.T, ttl time.Duration, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1500*time.Millisecond, ttl)
+			},
+		},
+		{
+			name:     "no expiration",
+			response: success(constants.PERSISTENT),
+			check: func(t *testing.T, ttl time.Duration, err error) {
+				require.NoError(t, err)
+				require.Equal(t, NoExpiration, ttl)
+			},
+		},
+		{
+			name:     "non integer response",
+			response: success("OK"),
+			check: func(t *testing.T, _ time.Duration, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:     "error response",
+			response: failure("key not set"),
+			check: func(t *testing.T, _ time.Duration, err error) {
+				require.Equal(t, "key not set", err.Error())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := setupClient()
+			go func() {
+				req := <-c.requests
+				ttlOperation := protocol.Operation{
+					Type: protocol.TTL,
+					Key:  []byte("key"),
+				}
+				require.Equal(t, ttlOperation, req.req)
+				req.res <- clientRes{Result: tc.response}
+			}()
+
+			ttl, err := c.TTL("key")
+			tc.check(t, ttl, err)
+		})
+	}
+}
+
+func TestSetExInvalidTTL(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	err := c.SetEx("key", "val", time.Microsecond)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(constants.InvalidTTLErr, time.Microsecond), err.Error())
+}
+
 func TestPing(t *testing.T) {
 	t.Parallel()
 
 	testCases := []struct {
 		name              string
-		response          string
+		response          protocol.Result
 		failBeforeRequest bool
 		check             func(*testing.T, error)
 	}{
 		{
 			name:     "valid ping pong",
-			response: constants.PONG,
+			response: success(constants.PONG),
 			check: func(t *testing.T, err error) {
 				require.NoError(t, err)
 			},
 		},
 		{
 			name:     "server responds PING expect error",
-			response: constants.PING,
+			response: success(constants.PING),
 			check: func(t *testing.T, err error) {
 				require.Error(t, err)
 			},
 		},
 		{
 			name:     "error response",
-			response: "-Invalid request",
+			response: failure("Invalid request"),
 			check: func(t *testing.T, err error) {
 				require.Equal(t, "Invalid request", err.Error())
 			},
@@ -271,7 +478,7 @@ func TestPing(t *testing.T) {
 						Type: protocol.PING,
 					}
 					require.Equal(t, pingOperation, req.req)
-					req.res <- []string{tc.response}
+					req.res <- clientRes{Result: tc.response}
 				}()
 			} else {
 				c = &Client{}
@@ -373,6 +580,25 @@ func TestDeduplication(t *testing.T) {
 				1: {1},
 			},
 		},
+		{
+			name: "counters are never deduplicated",
+			batch: []protocol.Operation{
+				{Type: protocol.INCR, Key: []byte("counter")},
+				{Type: protocol.GET, Key: []byte("counter")},
+				{Type: protocol.INCR, Key: []byte("counter")},
+				{Type: protocol.GET, Key: []byte("counter")},
+			},
+			wantOperations: []protocol.Operation{
+				{Type: protocol.INCR, Key: []byte("counter")},
+				{Type: protocol.GET, Key: []byte("counter")},
+				{Type: protocol.INCR, Key: []byte("counter")},
+			},
+			wantIndex: map[int][]int{
+				0: {0},
+				1: {1, 3},
+				2: {2},
+			},
+		},
 	}
 	for _, tc := range cases {
 		tc := tc
@@ -384,3 +610,1361 @@ func TestDeduplication(t *testing.T) {
 		})
 	}
 }
+
+func TestReadFrame(t *testing.T) {
+	t.Parallel()
+	payload := bytes.Repeat([]byte("value"), 10000)
+	frame := protocol.AppendFrame(nil, payload)
+	corrupt := make([]byte, constants.HeaderSize)
+	binary.LittleEndian.PutUint32(corrupt, constants.MaxFrameSize+1)
+
+	testCases := []struct {
+		name   string
+		writes [][]byte
+		check  func(*testing.T, []byte, error)
+	}{
+		{
+			name:   "frame split across writes",
+			writes: [][]byte{frame[:2], frame[2:100], frame[100:]},
+			check: func(t *testing.T, got []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, payload, got)
+			},
+		},
+		{
+			name:   "corrupt length",
+			writes: [][]byte{corrupt},
+			check: func(t *testing.T, _ []byte, err error) {
+				require.ErrorIs(t, err, ErrCorruptFrame)
+			},
+		},
+		{
+			name:   "partial frame times out",
+			writes: [][]byte{frame[:100]},
+			check: func(t *testing.T, _ []byte, err error) {
+				require.ErrorIs(t, err, ErrTimeout)
+				require.NotErrorIs(t, err, ErrCorruptFrame)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clientConn, serverConn := net.Pipe()
+			defer clientConn.Close()
+			defer serverConn.Close()
+			go func() {
+				for _, write := range tc.writes {
+					if _, err := serverConn.Write(write); err != nil {
+						return
+					}
+				}
+			}()
+
+			w := &Worker{}
+			got, err := w.readFrame(clientConn, time.Now().Add(50*time.Millisecond))
+			tc.check(t, got, err)
+		})
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("cancelled before send", func(t *testing.T) {
+		t.Parallel()
+		c := setupClient()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := c.GetCtx(ctx, "key")
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("deadline while waiting for result", func(t *testing.T) {
+		t.Parallel()
+		c := setupClient()
+		go func() {
+			<-c.requests
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := c.SetCtx(ctx, "key", "val")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestDropCancelled(t *testing.T) {
+	t.Parallel()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	requests := []clientReq{
+		{ctx: context.Background(), req: protocol.Operation{Key: []byte("live")}},
+		{ctx: cancelled, req: protocol.Operation{Key: []byte("cancelled")}},
+		{req: protocol.Operation{Key: []byte("no context")}},
+	}
+	live := dropCancelled(requests)
+	require.Equal(t, []protocol.Operation{
+		{Key: []byte("live")},
+		{Key: []byte("no context")},
+	}, operations(live))
+}
+
+func TestBatchDeadline(t *testing.T) {
+	t.Parallel()
+	soon, cancelSoon := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelSoon()
+	later, cancelLater := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelLater()
+
+	want, _ := soon.Deadline()
+	require.Equal(t, want, batchDeadline([]clientReq{{ctx: soon}}, constants.ReadTimeout))
+
+	// the read timeout caps later deadlines and applies without one
+	got := batchDeadline([]clientReq{{ctx: soon}, {ctx: later}, {}}, constants.ReadTimeout)
+	require.WithinDuration(t, time.Now().Add(constants.ReadTimeout), got, 100*time.Millisecond)
+
+	blocking := clientReq{ctx: later, req: protocol.Operation{Type: protocol.BLPOP, TTL: 5000}}
+	got = batchDeadline([]clientReq{{ctx: soon}, blocking}, constants.ReadTimeout)
+	require.WithinDuration(t, time.Now().Add(constants.ReadTimeout+5*time.Second), got, 100*time.Millisecond)
+}
+
+// silentServer accepts connections but never answers them, returning its
+// port.
+func silentServer(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestReadTimeout(t *testing.T) {
+	t.Parallel()
+	c, err := New(Options{
+		Host:           "127.0.0.1",
+		Port:           silentServer(t),
+		PoolSize:       1,
+		ReadTimeout:    50 * time.Millisecond,
+		RequestTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	c.Start()
+	defer c.Stop()
+
+	start := time.Now()
+	_, err = c.Get("key")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestStopTwice(t *testing.T) {
+	t.Parallel()
+	c, err := New(Options{Host: "127.0.0.1", Port: silentServer(t), PoolSize: 2})
+	require.NoError(t, err)
+	c.Start()
+
+	stopped := make(chan error, 2)
+	go func() {
+		stopped <- c.Stop()
+		stopped <- c.Stop()
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-stopped:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Stop blocked")
+		}
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "latency sensitive",
+			opts: Options{PoolSize: 4, BatchWindow: 50 * time.Microsecond},
+		},
+		{
+			name:    "negative pool size",
+			opts:    Options{PoolSize: -1},
+			wantErr: fmt.Sprintf(constants.InvalidPoolSizeErr, -1),
+		},
+		{
+			name:    "batch larger than server accepts",
+			opts:    Options{MaxBatch: constants.MaxRequestBatch + 1},
+			wantErr: fmt.Sprintf(constants.InvalidMaxBatchErr, constants.MaxRequestBatch+1, constants.MaxRequestBatch),
+		},
+		{
+			name:    "negative read timeout",
+			opts:    Options{ReadTimeout: -time.Second},
+			wantErr: fmt.Sprintf(constants.InvalidDurationErr, "read timeout", -time.Second),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateOptions(fillDefaultOptions(&tc.opts))
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedulerFlushesFullBatch(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	maxBatch := 3
+	w := &Worker{
+		conn:        clientConn,
+		shutdown:    make(chan bool, 1),
+		requests:    make(chan clientReq, maxBatch),
+		batchWindow: time.Hour,
+		maxBatch:    maxBatch,
+		readTimeout: time.Second,
+	}
+	go w.scheduler()
+	defer func() {
+		w.shutdown <- true
+	}()
+
+	results := make([]chan clientRes, maxBatch)
+	for i := range results {
+		results[i] = make(chan clientRes, 1)
+		w.requests <- clientReq{
+			req: protocol.Operation{Type: protocol.GET, Key: []byte{byte('a' + i)}},
+			res: results[i],
+		}
+	}
+
+	// the batch window is an hour, so only a full batch triggers a write
+	header := make([]byte, constants.HeaderSize)
+	_, err := io.ReadFull(serverConn, header)
+	require.NoError(t, err)
+	payload := make([]byte, binary.LittleEndian.Uint32(header))
+	_, err = io.ReadFull(serverConn, payload)
+	require.NoError(t, err)
+
+	batch := protocol.BatchedRequest{}
+	_, err = batch.UnmarshalMsg(payload)
+	require.NoError(t, err)
+	require.Len(t, batch.Operations, maxBatch)
+
+	response := protocol.BatchedResponse{
+		Results: []protocol.Result{success("a"), success("b"), success("c")},
+	}
+	encoded, err := response.MarshalMsg(nil)
+	require.NoError(t, err)
+	_, err = serverConn.Write(protocol.AppendFrame(nil, encoded))
+	require.NoError(t, err)
+
+	for i, res := range results {
+		got := <-res
+		require.NoError(t, got.err)
+		require.Equal(t, []byte{byte('a' + i)}, got.Message)
+	}
+}
+
+func TestWorkerDisconnectsOnCorruptFrame(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	w := &Worker{conn: clientConn, readTimeout: time.Second}
+	res := make(chan clientRes, 1)
+	go func() {
+		header := make([]byte, constants.HeaderSize)
+		if _, err := io.ReadFull(serverConn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.LittleEndian.Uint32(header))
+		if _, err := io.ReadFull(serverConn, payload); err != nil {
+			return
+		}
+		_, _ = serverConn.Write(protocol.AppendFrame(nil, []byte{0xc1}))
+	}()
+
+	w.processBatch(&protocol.BatchedRequest{}, []clientReq{{
+		ctx: context.Background(),
+		req: protocol.Operation{Type: protocol.GET, Key: []byte("key")},
+		res: res,
+	}})
+	require.ErrorIs(t, (<-res).err, ErrCorruptFrame)
+	require.False(t, w.Health().Connected)
+
+	disconnected := make(chan clientRes, 1)
+	w.processBatch(&protocol.BatchedRequest{}, []clientReq{{
+		ctx: context.Background(),
+		req: protocol.Operation{Type: protocol.GET, Key: []byte("key")},
+		res: disconnected,
+	}})
+	require.ErrorIs(t, (<-disconnected).err, ErrDisconnected)
+}
+
+func TestWorkerReconnect(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	w := &Worker{
+		shutdown:    make(chan bool, 1),
+		network:     "tcp",
+		addr:        listener.Addr().String(),
+		dialTimeout: time.Second,
+	}
+	require.True(t, w.reconnect())
+	require.Equal(t, WorkerHealth{Connected: true, Reconnects: 1}, w.Health())
+	require.NoError(t, w.close())
+	require.NoError(t, w.close())
+}
+
+func TestWorkerReconnectShutdown(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	w := &Worker{
+		shutdown:    make(chan bool, 1),
+		network:     "tcp",
+		addr:        addr,
+		dialTimeout: time.Second,
+	}
+	w.shutdown <- true
+	require.False(t, w.reconnect())
+	require.False(t, w.Health().Connected)
+}
+
+func TestStatusErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status protocol.ResultStatus
+		want   error
+	}{
+		{status: protocol.FAILURE, want: ErrFailure},
+		{status: protocol.NOT_FOUND, want: ErrNotFound},
+		{status: protocol.WRONG_TYPE, want: ErrWrongType},
+		{status: protocol.OUT_OF_MEMORY, want: ErrOutOfMemory},
+		{status: protocol.BATCH_TOO_LARGE, want: ErrBatchTooLarge},
+		{status: protocol.UNAUTHORIZED, want: ErrUnauthorized},
+		{status: protocol.INVALID_ARGUMENT, want: ErrInvalidArgument},
+		{status: protocol.UNKNOWN_OPERATION, want: ErrUnknownOperation},
+		{status: protocol.ResultStatus(100), want: ErrFailure},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.status.String(), func(t *testing.T) {
+			t.Parallel()
+			err := resultErr(protocol.Result{Status: tc.status, Message: []byte("server message")})
+			require.ErrorIs(t, err, tc.want)
+			require.Equal(t, "server message", err.Error())
+		})
+	}
+	require.NoError(t, resultErr(success("")))
+}
+
+func TestBatchRejected(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	w := &Worker{conn: clientConn, readTimeout: time.Second}
+	go func() {
+		header := make([]byte, constants.HeaderSize)
+		if _, err := io.ReadFull(serverConn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.LittleEndian.Uint32(header))
+		if _, err := io.ReadFull(serverConn, payload); err != nil {
+			return
+		}
+		response := protocol.BatchedResponse{Results: []protocol.Result{{
+			Status:  protocol.BATCH_TOO_LARGE,
+			Message: []byte("batch size 2 too large"),
+		}}}
+		encoded, _ := response.MarshalMsg(nil)
+		_, _ = serverConn.Write(protocol.AppendFrame(nil, encoded))
+	}()
+
+	results := []chan clientRes{make(chan clientRes, 1), make(chan clientRes, 1)}
+	w.processBatch(&protocol.BatchedRequest{}, []clientReq{
+		{req: protocol.Operation{Type: protocol.GET, Key: []byte("a")}, res: results[0]},
+		{req: protocol.Operation{Type: protocol.GET, Key: []byte("b")}, res: results[1]},
+	})
+	for _, res := range results {
+		require.ErrorIs(t, (<-res).err, ErrBatchTooLarge)
+	}
+	require.True(t, w.Health().Connected)
+}
+
+func TestMGetSplitsLargeRequests(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	keys := make([]string, constants.MaxRequestBatch*2+1)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			req := <-c.requests
+			res := success("")
+			for _, key := range req.req.Keys {
+				if string(key) == "0" {
+					res.Results = append(res.Results, failure("missing"))
+					continue
+				}
+				res.Results = append(res.Results, success(string(key)))
+			}
+			req.res <- clientRes{Result: res}
+		}
+	}()
+
+	results, err := c.MGet(keys...)
+	require.NoError(t, err)
+	require.Len(t, results, len(keys))
+	require.Error(t, results[0].Err)
+	for i, res := range results[1:] {
+		require.NoError(t, res.Err)
+		require.Equal(t, keys[i+1], res.Value)
+	}
+}
+
+func TestMSetMismatched(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	_, err := c.MSet([]string{"a", "b"}, []string{"1"})
+	require.Equal(t, fmt.Sprintf(constants.MismatchedErr, 2, 1), err.Error())
+	_, err = c.MDel("a", "")
+	require.Equal(t, constants.EmptyParamErr, err.Error())
+}
+
+func TestMultiResultCount(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		req.res <- clientRes{Result: success(constants.OK)}
+	}()
+
+	_, err := c.MDel("a", "b")
+	require.Equal(t, fmt.Sprintf(constants.MultiResultsErr, 2, protocol.MDEL, 0), err.Error())
+}
+
+func TestIncr(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		call     func(*Client) (int64, error)
+		wantOp   protocol.Operation
+		response protocol.Result
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:     "incr",
+			call:     func(c *Client) (int64, error) { return c.Incr("key") },
+			wantOp:   protocol.Operation{Type: protocol.INCR, Key: []byte("key")},
+			response: success("1"),
+			want:     1,
+		},
+		{
+			name:     "decr",
+			call:     func(c *Client) (int64, error) { return c.Decr("key") },
+			wantOp:   protocol.Operation{Type: protocol.DECR, Key: []byte("key")},
+			response: success("-1"),
+			want:     -1,
+		},
+		{
+			name:     "incr by",
+			call:     func(c *Client) (int64, error) { return c.IncrBy("key", 10) },
+			wantOp:   protocol.Operation{Type: protocol.INCRBY, Key: []byte("key"), Delta: 10},
+			response: success("10"),
+			want:     10,
+		},
+		{
+			name:   "overflow",
+			call:   func(c *Client) (int64, error) { return c.IncrBy("key", 10) },
+			wantOp: protocol.Operation{Type: protocol.INCRBY, Key: []byte("key"), Delta: 10},
+			response: protocol.Result{
+				Status:  protocol.OVERFLOW,
+				Message: []byte("overflow"),
+			},
+			wantErr: ErrOverflow,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := setupClient()
+			go func() {
+				req := <-c.requests
+				require.Equal(t, tc.wantOp, req.req)
+				req.res <- clientRes{Result: tc.response}
+			}()
+
+			got, err := tc.call(c)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestIncrInvalidResponse(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		req.res <- clientRes{Result: success(constants.OK)}
+	}()
+
+	_, err := c.Incr("key")
+	require.Equal(t, fmt.Sprintf(constants.InvalidIntErr, constants.INCR, constants.OK), err.Error())
+	_, err = c.IncrByFloat("key", math.Inf(1))
+	require.Error(t, err)
+}
+
+func TestConditionalWrites(t *testing.T) {
+	t.Parallel()
+	conflict := protocol.Result{Status: protocol.CONFLICT, Message: []byte("conflict")}
+	versioned := success(constants.OK)
+	versioned.Version = 7
+	tests := []struct {
+		name     string
+		call     func(*Client) (uint64, error)
+		wantOp   protocol.Operation
+		response protocol.Result
+		wantErr  error
+	}{
+		{
+			name:     "set if absent",
+			call:     func(c *Client) (uint64, error) { return c.SetNX("key", "val") },
+			wantOp:   protocol.Operation{Type: protocol.SETNX, Key: []byte("key"), Value: []byte("val")},
+			response: versioned,
+		},
+		{
+			name:     "set if present",
+			call:     func(c *Client) (uint64, error) { return c.SetXX("key", "val") },
+			wantOp:   protocol.Operation{Type: protocol.SETXX, Key: []byte("key"), Value: []byte("val")},
+			response: versioned,
+		},
+		{
+			name: "compare and swap",
+			call: func(c *Client) (uint64, error) { return c.CompareAndSwap("key", 6, "val") },
+			wantOp: protocol.Operation{
+				Type: protocol.CAS, Key: []byte("key"), Value: []byte("val"), Version: 6,
+			},
+			response: versioned,
+		},
+		{
+			name: "stale version",
+			call: func(c *Client) (uint64, error) { return c.CompareAndSwap("key", 5, "val") },
+			wantOp: protocol.Operation{
+				Type: protocol.CAS, Key: []byte("key"), Value: []byte("val"), Version: 5,
+			},
+			response: conflict,
+			wantErr:  ErrConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := setupClient()
+			go func() {
+				req := <-c.requests
+				require.Equal(t, tc.wantOp, req.req)
+				req.res <- clientRes{Result: tc.response}
+			}()
+
+			version, err := tc.call(c)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, uint64(7), version)
+		})
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		res := success("val")
+		res.Version = 3
+		req.res <- clientRes{Result: res}
+
+		req = <-c.requests
+		req.res <- clientRes{Result: protocol.Result{Status: protocol.WRONG_TYPE, Version: 5}}
+	}()
+
+	val, version, err := c.GetVersion("key")
+	require.NoError(t, err)
+	require.Equal(t, "val", val)
+	require.Equal(t, uint64(3), version)
+
+	// objects keep their version so they can be watched
+	_, version, err = c.GetVersion("hash")
+	require.ErrorIs(t, err, ErrWrongType)
+	require.Equal(t, uint64(5), version)
+}
+
+func TestTxExec(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	tx := c.Tx()
+	tx.Watch("watched", 4)
+	tx.Set("key", "val")
+	tx.Incr("counter")
+	tx.Get("missing")
+
+	go func() {
+		req := <-c.requests
+		require.Equal(t, protocol.Operation{
+			Type: protocol.TX,
+			Ops: []protocol.Operation{
+				{Type: protocol.SET, Key: []byte("key"), Value: []byte("val")},
+				{Type: protocol.INCR, Key: []byte("counter")},
+				{Type: protocol.GET, Key: []byte("missing")},
+			},
+			Keys:     [][]byte{[]byte("watched")},
+			Versions: []uint64{4},
+		}, req.req)
+		res := success(constants.OK)
+		res.Results = []protocol.Result{
+			success(constants.OK),
+			success("1"),
+			{Status: protocol.NOT_FOUND, Message: []byte("key missing not set")},
+		}
+		req.res <- clientRes{Result: res}
+	}()
+
+	results, err := tx.Exec()
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, "1", results[1].Value)
+	require.ErrorIs(t, results[2].Err, ErrNotFound)
+}
+
+func TestTxAborted(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	tx := c.Tx()
+	tx.Set("key", "val")
+	go func() {
+		req := <-c.requests
+		req.res <- clientRes{Result: protocol.Result{Status: protocol.CONFLICT, Message: []byte("conflict")}}
+	}()
+
+	_, err := tx.Exec()
+	require.ErrorIs(t, err, ErrConflict)
+
+	invalid := c.Tx()
+	invalid.Set("key", "")
+	_, err = invalid.Exec()
+	require.Equal(t, constants.EmptyParamErr, err.Error())
+}
+
+func TestPipelineBypassesBatchWindow(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	c := setupClient()
+	w := &Worker{
+		conn:        clientConn,
+		shutdown:    make(chan bool, 1),
+		requests:    c.requests,
+		pipelines:   c.pipelines,
+		batchWindow: time.Hour,
+		maxBatch:    constants.MaxRequestBatch,
+		readTimeout: time.Second,
+	}
+	go w.scheduler()
+	defer func() {
+		w.shutdown <- true
+	}()
+
+	go func() {
+		header := make([]byte, constants.HeaderSize)
+		if _, err := io.ReadFull(serverConn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.LittleEndian.Uint32(header))
+		if _, err := io.ReadFull(serverConn, payload); err != nil {
+			return
+		}
+
+		batch := protocol.BatchedRequest{}
+		if _, err := batch.UnmarshalMsg(payload); err != nil || len(batch.Operations) != 3 {
+			return
+		}
+
+		response := protocol.BatchedResponse{Results: []protocol.Result{
+			success(constants.OK),
+			success("val"),
+			{Status: protocol.NOT_FOUND, Message: []byte("missing")},
+		}}
+		encoded, _ := response.MarshalMsg(nil)
+		_, _ = serverConn.Write(protocol.AppendFrame(nil, encoded))
+	}()
+
+	pipe := c.Pipeline()
+	pipe.Set("key", "val")
+	pipe.Get("key")
+	pipe.Get("missing")
+
+	// the batch window is an hour, so only the pipeline flushes the batch
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	results, err := pipe.ExecCtx(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, constants.OK, results[0].Value)
+	require.Equal(t, "val", results[1].Value)
+	require.ErrorIs(t, results[2].Err, ErrNotFound)
+}
+
+func TestPipelineValidation(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	results, err := c.Pipeline().Exec()
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	pipe := c.Pipeline()
+	pipe.Get("")
+	_, err = pipe.Exec()
+	require.Equal(t, constants.EmptyParamErr, err.Error())
+
+	pipe = c.Pipeline()
+	for i := 0; i <= constants.MaxRequestBatch; i++ {
+		pipe.Del("key")
+	}
+	_, err = pipe.Exec()
+	require.Equal(t, fmt.Sprintf(constants.PipelineTooLargeErr, constants.MaxRequestBatch+1, constants.MaxRequestBatch), err.Error())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pipe = c.Pipeline()
+	pipe.Get("key")
+	_, err = pipe.ExecCtx(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	pages := []struct {
+		cursor uint64
+		keys   []string
+		next   uint64
+	}{
+		{cursor: 0, keys: []string{"a", "b"}, next: 7},
+		{cursor: 7, keys: nil, next: 9},
+		{cursor: 9, keys: []string{"c"}, next: 0},
+	}
+	go func() {
+		for _, page := range pages {
+			req := <-c.requests
+			require.Equal(t, protocol.SCAN, req.req.Type)
+			require.Equal(t, "k*", string(req.req.Pattern))
+			require.Equal(t, page.cursor, req.req.Cursor)
+			require.Equal(t, int64(2), req.req.Count)
+			res := success(constants.OK)
+			res.Values = toBytes(page.keys)
+			res.Version = page.next
+			req.res <- clientRes{Result: res}
+		}
+	}()
+
+	keys := []string{}
+	it := c.Scan("k*", 2)
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	require.False(t, it.Next())
+}
+
+func TestScanError(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		req.res <- clientRes{Result: protocol.Result{
+			Status:  protocol.INVALID_ARGUMENT,
+			Message: []byte("invalid match pattern"),
+		}}
+	}()
+
+	it := c.Scan("[", 10)
+	require.False(t, it.Next())
+	require.ErrorIs(t, it.Err(), ErrInvalidArgument)
+
+	_, _, err := (&Client{}).ScanPage(0, "", 10)
+	require.EqualError(t, err, constants.ClientUninitializedErr)
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		require.Equal(t, protocol.Operation{
+			Type:   protocol.HSET,
+			Key:    []byte("user"),
+			Keys:   [][]byte{[]byte("age"), []byte("name")},
+			Values: [][]byte{[]byte("36"), []byte("ada")},
+		}, req.req)
+		req.res <- clientRes{Result: success("2")}
+
+		req = <-c.requests
+		require.Equal(t, protocol.HGETALL, req.req.Type)
+		res := success(constants.OK)
+		res.Values = toBytes([]string{"age", "36", "name", "ada"})
+		req.res <- clientRes{Result: res}
+
+		req = <-c.requests
+		require.Equal(t, protocol.HGETALL, req.req.Type)
+		res.Values = res.Values[:3]
+		req.res <- clientRes{Result: res}
+	}()
+
+	added, err := c.HSet("user", map[string]string{"name": "ada", "age": "36"})
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+
+	fields, err := c.HGetAll("user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"name": "ada", "age": "36"}, fields)
+
+	_, err = c.HGetAll("user")
+	require.Error(t, err)
+
+	_, err = c.HSet("user", nil)
+	require.Error(t, err)
+	_, err = c.HSet("user", map[string]string{"": "v"})
+	require.Error(t, err)
+}
+
+func TestBlockingPop(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	c := setupClient()
+	c.network, c.addr, c.dialTimeout, c.readTimeout = "tcp", listener.Addr().String(), time.Second, time.Second
+
+	// every pop dials its own connection, the pool channels have no reader
+	responses := []protocol.Result{success("job"), {Status: protocol.NOT_FOUND}}
+	ops := make(chan protocol.Operation, len(responses))
+	go func() {
+		for _, response := range responses {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			header := make([]byte, constants.HeaderSize)
+			if _, err = io.ReadFull(conn, header); err != nil {
+				return
+			}
+			payload := make([]byte, binary.LittleEndian.Uint32(header))
+			if _, err = io.ReadFull(conn, payload); err != nil {
+				return
+			}
+
+			batch := protocol.BatchedRequest{}
+			if _, err = batch.UnmarshalMsg(payload); err != nil || len(batch.Operations) != 1 {
+				return
+			}
+			ops <- batch.Operations[0]
+
+			encoded, _ := (&protocol.BatchedResponse{Results: []protocol.Result{response}}).MarshalMsg(nil)
+			_, _ = conn.Write(protocol.AppendFrame(nil, encoded))
+			conn.Close()
+		}
+	}()
+
+	val, err := c.BLPop("queue", 1500*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, "job", val)
+	require.Equal(t, protocol.Operation{
+		Type: protocol.BLPOP,
+		Key:  []byte("queue"),
+		TTL:  1500,
+	}, <-ops)
+
+	_, err = c.BRPop("queue", time.Second)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, protocol.BRPOP, (<-ops).Type)
+
+	_, err = c.BLPop("queue", 0)
+	require.Error(t, err)
+	_, err = c.RPush("queue")
+	require.Error(t, err)
+}
+
+func TestBlockingPopCancelled(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	c.network, c.addr, c.dialTimeout, c.readTimeout = "tcp", fmt.Sprintf("127.0.0.1:%d", silentServer(t)), time.Second, time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.BLPopCtx(ctx, "queue", time.Minute)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestZSet(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		require.Equal(t, protocol.Operation{
+			Type:   protocol.ZADD,
+			Key:    []byte("board"),
+			Keys:   [][]byte{[]byte("ada"), []byte("bob")},
+			Scores: []float64{1.5, -2},
+		}, req.req)
+		req.res <- clientRes{Result: success("2")}
+
+		req = <-c.requests
+		require.Equal(t, protocol.Operation{
+			Type:   protocol.ZRANGEBYSCORE,
+			Key:    []byte("board"),
+			Scores: []float64{math.Inf(-1), 0},
+		}, req.req)
+		res := success(constants.OK)
+		res.Values = toBytes([]string{"bob", "-2"})
+		req.res <- clientRes{Result: res}
+
+		req = <-c.requests
+		require.Equal(t, protocol.ZRANGE, req.req.Type)
+		res.Values = toBytes([]string{"bob", "two"})
+		req.res <- clientRes{Result: res}
+	}()
+
+	added, err := c.ZAdd("board", map[string]float64{"bob": -2, "ada": 1.5})
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+
+	members, err := c.ZRangeByScore("board", math.Inf(-1), 0)
+	require.NoError(t, err)
+	require.Equal(t, []ZMember{{Member: "bob", Score: -2}}, members)
+
+	_, err = c.ZRange("board", 0, -1)
+	require.Error(t, err)
+
+	_, err = c.ZAdd("board", map[string]float64{"ada": math.Inf(1)})
+	require.Error(t, err)
+	_, err = c.ZIncrBy("board", "ada", math.NaN())
+	require.Error(t, err)
+}
+
+func TestSave(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		require.Equal(t, protocol.Operation{Type: protocol.SAVE}, req.req)
+		req.res <- clientRes{Result: success(constants.OK)}
+
+		req = <-c.requests
+		req.res <- clientRes{Result: protocol.Result{Status: protocol.FAILURE, Message: []byte("no snapshot path")}}
+	}()
+
+	require.NoError(t, c.Save())
+	require.Error(t, c.Save())
+}
+
+func TestReplication(t *testing.T) {
+	t.Parallel()
+	c := setupClient()
+	go func() {
+		req := <-c.requests
+		require.Equal(t, protocol.Operation{Type: protocol.REPLICATION}, req.req)
+		req.res <- clientRes{Result: protocol.Result{
+			Message: []byte("replica"),
+			Version: 7,
+			Values: [][]byte{
+				[]byte("leader"), []byte("localhost:6380"),
+				[]byte("connected"), []byte("true"),
+				[]byte("lag"), []byte("25"),
+			},
+		}}
+
+		req = <-c.requests
+		req.res <- clientRes{Result: protocol.Result{Message: []byte("leader"), Values: [][]byte{[]byte("replicas")}}}
+
+		req = <-c.requests
+		req.res <- clientRes{Result: protocol.Result{Status: protocol.READ_ONLY, Message: []byte("read only")}}
+	}()
+
+	info, err := c.Replication()
+	require.NoError(t, err)
+	require.Equal(t, ReplicationInfo{
+		Role:      "replica",
+		Offset:    7,
+		Leader:    "localhost:6380",
+		Connected: true,
+		Lag:       25 * time.Millisecond,
+	}, info)
+
+	_, err = c.Replication()
+	require.Error(t, err)
+	_, err = c.Replication()
+	require.ErrorIs(t, err, ErrReadOnly)
+}
+
+func ringKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+func owners(r *ring, keys []string) map[string]string {
+	owned := make(map[string]string, len(keys))
+	for _, key := range keys {
+		owned[key], _ = r.get(key)
+	}
+	return owned
+}
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+	r := newRing(constants.DefaultVirtualNodes)
+	_, ok := r.get("key")
+	require.False(t, ok)
+
+	nodes := []string{"a:1", "b:1", "c:1", "d:1"}
+	for _, node := range nodes {
+		r.add(node)
+	}
+
+	keys := ringKeys(20000)
+	before := owners(r, keys)
+	counts := map[string]int{}
+	for _, node := range before {
+		counts[node]++
+	}
+	for _, node := range nodes {
+		share := float64(counts[node]) / float64(len(keys))
+		require.InDelta(t, 0.25, share, 0.07, node)
+	}
+
+	// only keys taken by the new node move
+	r.add("e:1")
+	added := owners(r, keys)
+	moved := 0
+	for key, node := range added {
+		if node != before[key] {
+			require.Equal(t, "e:1", node)
+			moved++
+		}
+	}
+	require.InDelta(t, 0.2, float64(moved)/float64(len(keys)), 0.07)
+
+	// only keys of the removed node move, and removing the added node
+	// restores the original owners
+	r.remove("b:1")
+	for key, node := range owners(r, keys) {
+		if added[key] != "b:1" {
+			require.Equal(t, added[key], node)
+		}
+	}
+	r.add("b:1")
+	r.remove("e:1")
+	require.Equal(t, before, owners(r, keys))
+}
+
+func TestClusterOptions(t *testing.T) {
+	t.Parallel()
+	_, err := StartCluster(ClusterOptions{})
+	require.Error(t, err)
+	_, err = StartCluster(ClusterOptions{Addrs: []string{"localhost:1", "localhost:1"}})
+	require.Error(t, err)
+	_, err = StartCluster(ClusterOptions{Addrs: []string{"localhost:1"}, VirtualNodes: -1})
+	require.Error(t, err)
+	_, err = nodeOptions("localhost", Options{})
+	require.Error(t, err)
+
+	opts, err := nodeOptions("127.0.0.1:7000", Options{PoolSize: 3})
+	require.NoError(t, err)
+	require.Equal(t, Options{Host: "127.0.0.1", Port: 7000, PoolSize: 3}, opts)
+}
+
+func TestClusterSplitsMultiKey(t *testing.T) {
+	t.Parallel()
+	cluster := &Cluster{
+		ring:  newRing(constants.DefaultVirtualNodes),
+		nodes: map[string]*Client{"a:1": setupClient(), "b:1": setupClient()},
+	}
+	cluster.ring.add("a:1")
+	cluster.ring.add("b:1")
+
+	// every node answers each key with its own address
+	for addr, node := range cluster.nodes {
+		go func(addr string, node *Client) {
+			req := <-node.requests
+			res := protocol.Result{}
+			for range req.req.Keys {
+				res.Results = append(res.Results, success(addr))
+			}
+			req.res <- clientRes{Result: res}
+		}(addr, node)
+	}
+
+	keys := ringKeys(50)
+	results, err := cluster.MGet(keys...)
+	require.NoError(t, err)
+	require.Len(t, results, len(keys))
+	for i, key := range keys {
+		addr, _, err := cluster.Node(key)
+		require.NoError(t, err)
+		require.Equal(t, KeyResult{Value: addr}, results[i], key)
+	}
+
+	_, err = cluster.MGet("key", "")
+	require.Error(t, err)
+	_, err = (&Cluster{ring: newRing(1)}).Get("key")
+	require.Error(t, err)
+}
+
+func slotClient(ranges ...slots.Range) (*Client, map[string]*Client) {
+	table, err := slots.NewTable(ranges)
+	if err != nil {
+		panic(err)
+	}
+
+	nodes := map[string]*Client{}
+	for _, r := range ranges {
+		nodes[r.Addr] = setupClient()
+	}
+
+	router := &slotRouter{
+		table:        *table,
+		nodes:        nodes,
+		seeds:        []string{ranges[0].Addr},
+		maxRedirects: constants.DefaultMaxRedirects,
+	}
+	return &Client{router: router, requestTimeout: constants.ClientRequestTimeout}, nodes
+}
+
+func TestClusterModeOptions(t *testing.T) {
+	t.Parallel()
+	_, err := StartClusterMode(ClusterModeOptions{})
+	require.Error(t, err)
+	_, err = StartClusterMode(ClusterModeOptions{Addrs: []string{"localhost:1"}, MaxRedirects: -1})
+	require.Error(t, err)
+	_, err = StartClusterMode(ClusterModeOptions{Addrs: []string{"localhost:1"}, Node: Options{RequestTimeout: -1}})
+	require.Error(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	addr := listener.Addr().String()
+	slotMap := success(constants.OK)
+	slotMap.Values = slots.AppendRanges(nil, []slots.Range{{Start: 0, End: slots.Count - 1, Addr: addr}})
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// the node client pings before the slot map is read
+		for _, response := range []protocol.Result{success(constants.PONG), slotMap} {
+			header := make([]byte, constants.HeaderSize)
+			if _, err = io.ReadFull(conn, header); err != nil {
+				return
+			}
+			if _, err = io.ReadFull(conn, make([]byte, binary.LittleEndian.Uint32(header))); err != nil {
+				return
+			}
+
+			encoded, _ := (&protocol.BatchedResponse{Results: []protocol.Result{response}}).MarshalMsg(nil)
+			if _, err = conn.Write(protocol.AppendFrame(nil, encoded)); err != nil {
+				return
+			}
+		}
+	}()
+
+	c, err := StartClusterMode(ClusterModeOptions{
+		Addrs: []string{addr},
+		Node:  Options{PoolSize: 1, RequestTimeout: 3 * time.Second},
+	})
+	require.NoError(t, err)
+	defer c.Stop()
+	require.Equal(t, 3*time.Second, c.requestTimeout)
+}
+
+func TestClusterModeRouting(t *testing.T) {
+	t.Parallel()
+	key, ok := routingKey(protocol.Operation{Type: protocol.GET, Key: []byte("key")})
+	require.True(t, ok)
+	require.Equal(t, "key", string(key))
+	_, ok = routingKey(protocol.Operation{Type: protocol.PING})
+	require.False(t, ok)
+	key, ok = routingKey(protocol.Operation{Type: protocol.TX, Ops: []protocol.Operation{
+		{Type: protocol.PING}, {Type: protocol.INCR, Key: []byte("counter")},
+	}})
+	require.True(t, ok)
+	require.Equal(t, "counter", string(key))
+
+	require.False(t, multiSlot(protocol.Operation{Type: protocol.MGET, Keys: toBytes([]string{"{user}1", "{user}2"})}))
+	require.True(t, multiSlot(protocol.Operation{Type: protocol.MGET, Keys: toBytes([]string{"foo", "bar"})}))
+}
+
+func TestClusterModeEmptyMultiKey(t *testing.T) {
+	t.Parallel()
+	empty := protocol.Operation{Type: protocol.MGET}
+	require.False(t, multiSlot(empty))
+	_, ok := routingKey(empty)
+	require.False(t, ok)
+
+	c, nodes := slotClient(
+		slots.Range{Start: 0, End: 8191, Addr: "a:1"},
+		slots.Range{Start: 8192, End: slots.Count - 1, Addr: "b:1"},
+	)
+	go func() {
+		req := <-nodes["a:1"].requests
+		require.Empty(t, req.req.Keys)
+		req.res <- clientRes{Result: protocol.Result{Status: protocol.INVALID_ARGUMENT, Message: []byte("no keys")}}
+	}()
+
+	_, err := c.sendRequest(context.Background(), empty)
+	require.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestClusterModeRedirects(t *testing.T) {
+	t.Parallel()
+	c, nodes := slotClient(slots.Range{Start: 0, End: slots.Count - 1, Addr: "a:1"})
+	c.router.nodes["b:1"] = setupClient()
+	a, b := nodes["a:1"], c.router.nodes["b:1"]
+	slot := slots.Slot([]byte("foo"))
+
+	// a moved the slot to b, which serves the key and the new slot map
+	go func() {
+		req := <-a.requests
+		req.res <- clientRes{Result: protocol.Result{
+			Status: protocol.MOVED, Version: uint64(slot), Values: [][]byte{[]byte("b:1")},
+		}}
+	}()
+	go func() {
+		for i := 0; i < 2; i++ {
+			req := <-b.requests
+			if req.req.Type == protocol.CLUSTERSLOTS {
+				ranges := []slots.Range{{Start: 0, End: slots.Count - 1, Addr: "b:1"}}
+				req.res <- clientRes{Result: protocol.Result{Values: slots.AppendRanges(nil, ranges)}}
+				continue
+			}
+			req.res <- clientRes{Result: success("moved")}
+		}
+	}()
+
+	val, err := c.Get("foo")
+	require.NoError(t, err)
+	require.Equal(t, "moved", val)
+	c.router.wg.Wait()
+	require.Equal(t, "b:1", c.router.table[slot])
+	require.Equal(t, "b:1", c.router.table[0])
+
+	// b is migrating the slot to a, which serves it only after ASKING
+	go func() {
+		req := <-b.requests
+		req.res <- clientRes{Result: protocol.Result{
+			Status: protocol.ASK, Version: uint64(slot), Values: [][]byte{[]byte("a:1")},
+		}}
+	}()
+	go func() {
+		reqs := <-a.pipelines
+		require.Equal(t, protocol.ASKING, reqs[0].req.Type)
+		reqs[0].res <- clientRes{Result: success("ok")}
+		reqs[1].res <- clientRes{Result: success("asked")}
+	}()
+
+	val, err = c.Get("foo")
+	require.NoError(t, err)
+	require.Equal(t, "asked", val)
+	require.Equal(t, "b:1", c.router.table[slot])
+}
+
+func TestClusterModeSplitsMultiKey(t *testing.T) {
+	t.Parallel()
+	c, nodes := slotClient(
+		slots.Range{Start: 0, End: 8191, Addr: "a:1"},
+		slots.Range{Start: 8192, End: slots.Count - 1, Addr: "b:1"},
+	)
+
+	// every node answers each key with its own address
+	for addr, node := range nodes {
+		go func(addr string, node *Client) {
+			req := <-node.requests
+			res := protocol.Result{}
+			for range req.req.Keys {
+				res.Results = append(res.Results, success(addr))
+			}
+			req.res <- clientRes{Result: res}
+		}(addr, node)
+	}
+
+	// keys of one node share a slot, the server rejects keys of several
+	results, err := c.MGet("bar", "foo", "{bar}baz")
+	require.NoError(t, err)
+	require.Equal(t, []KeyResult{{Value: "a:1"}, {Value: "b:1"}, {Value: "a:1"}}, results)
+}
@@ -0,0 +1,382 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+type ClusterOptions struct {
+	Addrs        []string
+	VirtualNodes int
+	Node         Options
+}
+
+// Cluster shards keys over standalone servers by consistent hashing. Moved
+// keys are not copied, so they read as missing until written again.
+type Cluster struct {
+	mu    sync.RWMutex
+	ring  *ring
+	nodes map[string]*Client
+	opts  ClusterOptions
+}
+
+func fillDefaultClusterOptions(opts ClusterOptions) ClusterOptions {
+	if opts.VirtualNodes == 0 {
+		opts.VirtualNodes = constants.DefaultVirtualNodes
+	}
+	return opts
+}
+
+func validateClusterOptions(opts ClusterOptions) error {
+	if len(opts.Addrs) == 0 {
+		return errors.New(constants.NoNodesErr)
+	}
+
+	if opts.VirtualNodes < 1 {
+		return fmt.Errorf(constants.InvalidVirtualNodesErr, opts.VirtualNodes)
+	}
+
+	seen := make(map[string]bool, len(opts.Addrs))
+	for _, addr := range opts.Addrs {
+		if seen[addr] {
+			return fmt.Errorf(constants.DuplicateNodeErr, addr)
+		}
+		seen[addr] = true
+	}
+	return nil
+}
+
+func StartCluster(opts ClusterOptions) (*Cluster, error) {
+	opts = fillDefaultClusterOptions(opts)
+	if err := validateClusterOptions(opts); err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		ring:  newRing(opts.VirtualNodes),
+		nodes: make(map[string]*Client, len(opts.Addrs)),
+		opts:  opts,
+	}
+	for _, addr := range opts.Addrs {
+		if err := c.AddNode(addr); err != nil {
+			return nil, errors.Join(err, c.Stop())
+		}
+	}
+	return c, nil
+}
+
+func (c *Cluster) AddNode(addr string) error {
+	c.mu.RLock()
+	_, exists := c.nodes[addr]
+	c.mu.RUnlock()
+	if exists {
+		return fmt.Errorf(constants.DuplicateNodeErr, addr)
+	}
+
+	opts, err := nodeOptions(addr, c.opts.Node)
+	if err != nil {
+		return err
+	}
+
+	node, err := StartOptions(opts)
+	if err != nil {
+		if node != nil {
+			err = errors.Join(err, node.Stop())
+		}
+		return err
+	}
+
+	c.mu.Lock()
+	if _, exists = c.nodes[addr]; exists {
+		c.mu.Unlock()
+		return errors.Join(fmt.Errorf(constants.DuplicateNodeErr, addr), node.Stop())
+	}
+	c.nodes[addr] = node
+	c.ring.add(addr)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cluster) RemoveNode(addr string) error {
+	c.mu.Lock()
+	node, ok := c.nodes[addr]
+	if ok {
+		delete(c.nodes, addr)
+		c.ring.remove(addr)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf(constants.UnknownNodeErr, addr)
+	}
+	return node.Stop()
+}
+
+func nodeOptions(addr string, opts Options) (Options, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return Options{}, fmt.Errorf("%s: %w", constants.InvalidAddrErr, err)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return Options{}, fmt.Errorf("%s: %w", constants.InvalidAddrErr, err)
+	}
+
+	opts.Host, opts.Port = host, port
+	return opts, nil
+}
+
+func (c *Cluster) Nodes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	addrs := make([]string, 0, len(c.nodes))
+	for addr := range c.nodes {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func (c *Cluster) Node(key string) (string, *Client, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	addr, ok := c.ring.get(key)
+	if !ok {
+		return "", nil, errors.New(constants.NoNodesErr)
+	}
+	return addr, c.nodes[addr], nil
+}
+
+func (c *Cluster) client(key string) (*Client, error) {
+	_, node, err := c.Node(key)
+	return node, err
+}
+
+func (c *Cluster) Stop() error {
+	c.mu.Lock()
+	nodes := c.nodes
+	c.nodes = map[string]*Client{}
+	c.ring = newRing(c.opts.VirtualNodes)
+	c.mu.Unlock()
+
+	var err error
+	for _, node := range nodes {
+		err = errors.Join(err, node.Stop())
+	}
+	return err
+}
+
+func (c *Cluster) Ping() error {
+	return c.PingCtx(context.Background())
+}
+
+func (c *Cluster) PingCtx(ctx context.Context) error {
+	c.mu.RLock()
+	nodes := make(map[string]*Client, len(c.nodes))
+	for addr, node := range c.nodes {
+		nodes[addr] = node
+	}
+	c.mu.RUnlock()
+
+	var err error
+	for addr, node := range nodes {
+		if pingErr := node.PingCtx(ctx); pingErr != nil {
+			err = errors.Join(err, fmt.Errorf("%s: %w", addr, pingErr))
+		}
+	}
+	return err
+}
+
+func (c *Cluster) Get(key string) (string, error) {
+	return c.GetCtx(context.Background(), key)
+}
+
+func (c *Cluster) GetCtx(ctx context.Context, key string) (string, error) {
+	node, err := c.client(key)
+	if err != nil {
+		return "", err
+	}
+	return node.GetCtx(ctx, key)
+}
+
+func (c *Cluster) Set(key, value string) error {
+	return c.SetCtx(context.Background(), key, value)
+}
+
+func (c *Cluster) SetCtx(ctx context.Context, key, value string) error {
+	node, err := c.client(key)
+	if err != nil {
+		return err
+	}
+	return node.SetCtx(ctx, key, value)
+}
+
+func (c *Cluster) SetEx(key, value string, ttl time.Duration) error {
+	return c.SetExCtx(context.Background(), key, value, ttl)
+}
+
+func (c *Cluster) SetExCtx(ctx context.Context, key, value string, ttl time.Duration) error {
+	node, err := c.client(key)
+	if err != nil {
+		return err
+	}
+	return node.SetExCtx(ctx, key, value, ttl)
+}
+
+func (c *Cluster) Del(key string) error {
+	return c.DelCtx(context.Background(), key)
+}
+
+func (c *Cluster) DelCtx(ctx context.Context, key string) error {
+	node, err := c.client(key)
+	if err != nil {
+		return err
+	}
+	return node.DelCtx(ctx, key)
+}
+
+func (c *Cluster) MGet(keys ...string) ([]KeyResult, error) {
+	return c.MGetCtx(context.Background(), keys...)
+}
+
+func (c *Cluster) MGetCtx(ctx context.Context, keys ...string) ([]KeyResult, error) {
+	if err := validateParams(keys...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.sendMulti(ctx, protocol.MGET, keys, nil)
+	if err != nil {
+		return nil, err
+	}
+	return keyResults(results), nil
+}
+
+func (c *Cluster) MSet(keys, values []string) ([]error, error) {
+	return c.MSetCtx(context.Background(), keys, values)
+}
+
+func (c *Cluster) MSetCtx(ctx context.Context, keys, values []string) ([]error, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf(constants.MismatchedErr, len(keys), len(values))
+	}
+
+	if err := validateParams(append(append([]string{}, keys...), values...)...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.sendMulti(ctx, protocol.MSET, keys, values)
+	if err != nil {
+		return nil, err
+	}
+	return keyErrors(constants.MSET, results), nil
+}
+
+func (c *Cluster) MDel(keys ...string) ([]error, error) {
+	return c.MDelCtx(context.Background(), keys...)
+}
+
+func (c *Cluster) MDelCtx(ctx context.Context, keys ...string) ([]error, error) {
+	if err := validateParams(keys...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.sendMulti(ctx, protocol.MDEL, keys, nil)
+	if err != nil {
+		return nil, err
+	}
+	return keyErrors(constants.MDEL, results), nil
+}
+
+type nodeKeys struct {
+	node    *Client
+	keys    []string
+	values  []string
+	indexes []int
+}
+
+func (c *Cluster) split(keys, values []string) ([]*nodeKeys, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	groups := map[string]*nodeKeys{}
+	var ordered []*nodeKeys
+	for i, key := range keys {
+		addr, ok := c.ring.get(key)
+		if !ok {
+			return nil, errors.New(constants.NoNodesErr)
+		}
+
+		group, ok := groups[addr]
+		if !ok {
+			group = &nodeKeys{node: c.nodes[addr]}
+			groups[addr] = group
+			ordered = append(ordered, group)
+		}
+
+		group.keys = append(group.keys, key)
+		group.indexes = append(group.indexes, i)
+		if values != nil {
+			group.values = append(group.values, values[i])
+		}
+	}
+	return ordered, nil
+}
+
+func (c *Cluster) sendMulti(
+	ctx context.Context, opType protocol.OperationType, keys, values []string,
+) ([]protocol.Result, error) {
+	groups, err := c.split(keys, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]protocol.Result, len(keys))
+		errs    = make([]error, len(groups))
+	)
+	for i, group := range groups {
+		wg.Add(1)
+		go func(i int, group *nodeKeys) {
+			defer wg.Done()
+			var values [][]byte
+			if group.values != nil {
+				values = toBytes(group.values)
+			}
+
+			res, err := group.node.sendMulti(ctx, opType, toBytes(group.keys), values)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			for j, index := range group.indexes {
+				results[index] = res[j]
+			}
+		}(i, group)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func validateParams(params ...string) error {
+	for _, param := range params {
+		if param == "" {
+			return errors.New(constants.EmptyParamErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/slots"
+)
+
+type SlotRange = slots.Range
+
+const (
+	SlotNode      = slots.StateNode
+	SlotMigrating = slots.StateMigrating
+	SlotImporting = slots.StateImporting
+	SlotStable    = slots.StateStable
+)
+
+type ClusterModeOptions struct {
+	Addrs        []string
+	Node         Options
+	MaxRedirects int
+}
+
+// StartClusterMode routes every key to the node serving its hash slot, unlike
+// Cluster which shards standalone servers itself.
+func StartClusterMode(opts ClusterModeOptions) (*Client, error) {
+	if opts.MaxRedirects == 0 {
+		opts.MaxRedirects = constants.DefaultMaxRedirects
+	}
+
+	if len(opts.Addrs) == 0 {
+		return nil, errors.New(constants.NoNodesErr)
+	}
+
+	if opts.MaxRedirects < 0 {
+		return nil, fmt.Errorf(constants.InvalidRedirectsErr, opts.MaxRedirects)
+	}
+
+	opts.Node = fillDefaultOptions(&opts.Node)
+	if err := validateOptions(opts.Node); err != nil {
+		return nil, err
+	}
+
+	r := &slotRouter{
+		nodes:        map[string]*Client{},
+		node:         opts.Node,
+		seeds:        opts.Addrs,
+		maxRedirects: opts.MaxRedirects,
+	}
+
+	var err error
+	for _, addr := range opts.Addrs {
+		refreshErr := r.refresh(context.Background(), addr)
+		if refreshErr == nil {
+			return &Client{router: r, requestTimeout: opts.Node.RequestTimeout}, nil
+		}
+		err = errors.Join(err, refreshErr)
+	}
+	return nil, errors.Join(err, r.stop())
+}
+
+func (c *Client) ClusterSlots() ([]SlotRange, uint64, error) {
+	return c.ClusterSlotsCtx(context.Background())
+}
+
+func (c *Client) ClusterSlotsCtx(ctx context.Context) ([]SlotRange, uint64, error) {
+	if err := c.validateClient(); err != nil {
+		return nil, 0, err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{Type: protocol.CLUSTERSLOTS})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ranges, err := slots.ParseRanges(response.Values)
+	return ranges, response.Version, err
+}
+
+// SetSlot only changes the map of this node, so slots are set through a
+// client of every node.
+func (c *Client) SetSlot(start, end int, state, addr string) error {
+	return c.SetSlotCtx(context.Background(), start, end, state, addr)
+}
+
+func (c *Client) SetSlotCtx(ctx context.Context, start, end int, state, addr string) error {
+	if err := c.validateClient(); err != nil {
+		return err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type:  protocol.SETSLOT,
+		Start: int64(start),
+		Stop:  int64(end),
+		State: state,
+		Value: []byte(addr),
+	})
+	if err != nil {
+		return err
+	}
+	return expectResponse(constants.SETSLOT, constants.OK, response)
+}
+
+type slotRouter struct {
+	mu           sync.RWMutex
+	table        slots.Table
+	nodes        map[string]*Client
+	node         Options
+	seeds        []string
+	maxRedirects int
+	refreshing   atomic.Bool
+	wg           sync.WaitGroup
+}
+
+func (r *slotRouter) client(addr string) (*Client, error) {
+	r.mu.RLock()
+	node, ok := r.nodes[addr]
+	r.mu.RUnlock()
+	if ok {
+		return node, nil
+	}
+
+	opts, err := nodeOptions(addr, r.node)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err = StartOptions(opts)
+	if err != nil {
+		if node != nil {
+			err = errors.Join(err, node.Stop())
+		}
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.nodes[addr]; ok {
+		return existing, node.Stop()
+	}
+	r.nodes[addr] = node
+	return node, nil
+}
+
+func (r *slotRouter) refresh(ctx context.Context, addr string) error {
+	node, err := r.client(addr)
+	if err != nil {
+		return err
+	}
+
+	ranges, _, err := node.ClusterSlotsCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	table, err := slots.NewTable(ranges)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.table = *table
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *slotRouter) refreshAsync(addr string) {
+	if !r.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.refreshing.Store(false)
+		_ = r.refresh(context.Background(), addr)
+	}()
+}
+
+func (r *slotRouter) owner(slot uint16, keyed bool) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if keyed && r.table[slot] != "" {
+		return r.table[slot]
+	}
+
+	for _, addr := range r.table {
+		if addr != "" {
+			return addr
+		}
+	}
+	return r.seeds[0]
+}
+
+func (r *slotRouter) moved(slot uint16, addr string) {
+	r.mu.Lock()
+	r.table[slot] = addr
+	r.mu.Unlock()
+	r.refreshAsync(addr)
+}
+
+func (r *slotRouter) send(ctx context.Context, op protocol.Operation) (protocol.Result, error) {
+	if multiSlot(op) {
+		return r.sendMulti(ctx, op)
+	}
+
+	key, keyed := routingKey(op)
+	addr := r.owner(slots.Slot(key), keyed)
+	asking := false
+	var (
+		res protocol.Result
+		err error
+	)
+	for redirects := 0; redirects <= r.maxRedirects; redirects++ {
+		var node *Client
+		if node, err = r.client(addr); err != nil {
+			return protocol.Result{}, err
+		}
+
+		res, err = sendTo(ctx, node, op, asking)
+		if (res.Status != protocol.MOVED && res.Status != protocol.ASK) || len(res.Values) != 1 {
+			return res, err
+		}
+
+		addr, asking = string(res.Values[0]), res.Status == protocol.ASK
+		if !asking {
+			r.moved(uint16(res.Version), addr)
+		}
+	}
+	return res, err
+}
+
+// sendTo pipelines ASKING so it shares a frame with nothing else.
+func sendTo(ctx context.Context, node *Client, op protocol.Operation, asking bool) (protocol.Result, error) {
+	if !asking && !op.Type.Blocking() {
+		return node.sendRequest(ctx, op)
+	}
+
+	ops := []protocol.Operation{op}
+	if asking {
+		ops = []protocol.Operation{{Type: protocol.ASKING}, op}
+	}
+
+	send := node.sendPipeline
+	if op.Type.Blocking() {
+		send = node.sendBlocking
+	}
+
+	results, err := send(ctx, ops)
+	if err != nil {
+		return protocol.Result{}, err
+	}
+
+	res := results[len(results)-1]
+	return res, resultErr(res)
+}
+
+func (r *slotRouter) sendMulti(ctx context.Context, op protocol.Operation) (protocol.Result, error) {
+	indexes := map[uint16][]int{}
+	var order []uint16
+	for i, key := range op.Keys {
+		slot := slots.Slot(key)
+		if _, ok := indexes[slot]; !ok {
+			order = append(order, slot)
+		}
+		indexes[slot] = append(indexes[slot], i)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]protocol.Result, len(op.Keys))
+		errs    = make([]error, len(order))
+	)
+	for i, slot := range order {
+		slotOp := protocol.Operation{Type: op.Type}
+		for _, index := range indexes[slot] {
+			slotOp.Keys = append(slotOp.Keys, op.Keys[index])
+			if op.Values != nil {
+				slotOp.Values = append(slotOp.Values, op.Values[index])
+			}
+		}
+
+		wg.Add(1)
+		go func(i int, slotOp protocol.Operation, indexes []int) {
+			defer wg.Done()
+			res, err := r.send(ctx, slotOp)
+			if err == nil && len(res.Results) != len(indexes) {
+				err = fmt.Errorf(constants.MultiResultsErr, len(indexes), slotOp.Type, len(res.Results))
+			}
+
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			for j, index := range indexes {
+				results[index] = res.Results[j]
+			}
+		}(i, slotOp, indexes[slot])
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return protocol.Result{}, err
+	}
+	return protocol.Result{Results: results}, nil
+}
+
+func (r *slotRouter) sendPipeline(ctx context.Context, ops []protocol.Operation) ([]protocol.Result, error) {
+	results := make([]protocol.Result, len(ops))
+	for i, op := range ops {
+		res, err := r.send(ctx, op)
+		var status *statusError
+		if err != nil && !errors.As(err, &status) {
+			return nil, err
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+func (r *slotRouter) stop() error {
+	r.wg.Wait()
+	r.mu.Lock()
+	nodes := r.nodes
+	r.nodes = map[string]*Client{}
+	r.mu.Unlock()
+
+	var err error
+	for _, node := range nodes {
+		err = errors.Join(err, node.Stop())
+	}
+	return err
+}
+
+func multiSlot(op protocol.Operation) bool {
+	switch op.Type {
+	case protocol.MGET, protocol.MSET, protocol.MDEL:
+	default:
+		return false
+	}
+
+	if len(op.Keys) == 0 {
+		return false
+	}
+
+	for _, key := range op.Keys[1:] {
+		if slots.Slot(key) != slots.Slot(op.Keys[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func routingKey(op protocol.Operation) ([]byte, bool) {
+	switch op.Type {
+	case protocol.PING, protocol.SCAN, protocol.SAVE, protocol.REPLICATION, protocol.CLUSTERSLOTS,
+		protocol.SETSLOT:
+		return nil, false
+	case protocol.MGET, protocol.MSET, protocol.MDEL:
+		if len(op.Keys) == 0 {
+			return nil, false
+		}
+		return op.Keys[0], true
+	case protocol.TX:
+		if len(op.Keys) > 0 {
+			return op.Keys[0], true
+		}
+
+		for _, txOp := range op.Ops {
+			if key, ok := routingKey(txOp); ok {
+				return key, true
+			}
+		}
+		return nil, false
+	default:
+		return op.Key, true
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+func (c *Client) SetNX(key, val string) (uint64, error) {
+	return c.SetNXCtx(context.Background(), key, val)
+}
+
+func (c *Client) SetNXCtx(ctx context.Context, key, val string) (uint64, error) {
+	return c.setIf(ctx, constants.SETNX, protocol.Operation{
+		Type:  protocol.SETNX,
+		Key:   []byte(key),
+		Value: []byte(val),
+	})
+}
+
+func (c *Client) SetXX(key, val string) (uint64, error) {
+	return c.SetXXCtx(context.Background(), key, val)
+}
+
+func (c *Client) SetXXCtx(ctx context.Context, key, val string) (uint64, error) {
+	return c.setIf(ctx, constants.SETXX, protocol.Operation{
+		Type:  protocol.SETXX,
+		Key:   []byte(key),
+		Value: []byte(val),
+	})
+}
+
+func (c *Client) CompareAndSwap(key string, version uint64, val string) (uint64, error) {
+	return c.CompareAndSwapCtx(context.Background(), key, version, val)
+}
+
+func (c *Client) CompareAndSwapCtx(
+	ctx context.Context, key string, version uint64, val string,
+) (uint64, error) {
+	return c.setIf(ctx, constants.CAS, protocol.Operation{
+		Type:    protocol.CAS,
+		Key:     []byte(key),
+		Value:   []byte(val),
+		Version: version,
+	})
+}
+
+func (c *Client) setIf(ctx context.Context, command string, op protocol.Operation) (uint64, error) {
+	if err := c.validateParams(string(op.Key), string(op.Value)); err != nil {
+		return 0, err
+	}
+
+	response, err := c.sendRequest(ctx, op)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = expectResponse(command, constants.OK, response); err != nil {
+		return 0, err
+	}
+	return response.Version, nil
+}
+
+// GetVersion still reports the version of a hash, list or sorted set along
+// with ErrWrongType so it can be watched.
+func (c *Client) GetVersion(key string) (string, uint64, error) {
+	return c.GetVersionCtx(context.Background(), key)
+}
+
+func (c *Client) GetVersionCtx(ctx context.Context, key string) (string, uint64, error) {
+	if err := c.validateParams(key); err != nil {
+		return "", 0, err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.GETVERSION,
+		Key:  []byte(key),
+	})
+	if errors.Is(err, ErrWrongType) {
+		return "", response.Version, err
+	}
+
+	if err != nil {
+		return "", 0, err
+	}
+	return string(response.Message), response.Version, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+func (c *Client) Incr(key string) (int64, error) {
+	return c.IncrCtx(context.Background(), key)
+}
+
+func (c *Client) IncrCtx(ctx context.Context, key string) (int64, error) {
+	return c.incr(ctx, constants.INCR, protocol.Operation{Type: protocol.INCR, Key: []byte(key)})
+}
+
+func (c *Client) Decr(key string) (int64, error) {
+	return c.DecrCtx(context.Background(), key)
+}
+
+func (c *Client) DecrCtx(ctx context.Context, key string) (int64, error) {
+	return c.incr(ctx, constants.DECR, protocol.Operation{Type: protocol.DECR, Key: []byte(key)})
+}
+
+func (c *Client) IncrBy(key string, delta int64) (int64, error) {
+	return c.IncrByCtx(context.Background(), key, delta)
+}
+
+func (c *Client) IncrByCtx(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.incr(ctx, constants.INCRBY, protocol.Operation{
+		Type:  protocol.INCRBY,
+		Key:   []byte(key),
+		Delta: delta,
+	})
+}
+
+func (c *Client) incr(ctx context.Context, command string, op protocol.Operation) (int64, error) {
+	if err := c.validateKeys(op.Key); err != nil {
+		return 0, err
+	}
+
+	response, err := c.sendRequest(ctx, op)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(string(response.Message), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(constants.InvalidIntErr, command, response.Message)
+	}
+	return n, nil
+}
+
+func (c *Client) IncrByFloat(key string, delta float64) (float64, error) {
+	return c.IncrByFloatCtx(context.Background(), key, delta)
+}
+
+func (c *Client) IncrByFloatCtx(ctx context.Context, key string, delta float64) (float64, error) {
+	if err := c.validateParams(key); err != nil {
+		return 0, err
+	}
+
+	if math.IsInf(delta, 0) || math.IsNaN(delta) {
+		return 0, fmt.Errorf(constants.InvalidIncrementErr, delta)
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type:  protocol.INCRBYFLOAT,
+		Key:   []byte(key),
+		Value: strconv.AppendFloat(nil, delta, 'f', -1, 64),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(string(response.Message), 64)
+	if err != nil {
+		return 0, fmt.Errorf(constants.InvalidFloatErr, constants.INCRBYFLOAT, response.Message)
+	}
+	return f, nil
+}
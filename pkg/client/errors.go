@@ -0,0 +1,61 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+var (
+	ErrTimeout      = errors.New("timed out reading response")
+	ErrCorruptFrame = errors.New("corrupt response frame")
+	ErrDisconnected = errors.New("worker is disconnected")
+)
+
+var (
+	ErrFailure          = errors.New("request failed")
+	ErrNotFound         = errors.New("key not found")
+	ErrWrongType        = errors.New("wrong type for key")
+	ErrOutOfMemory      = errors.New("server out of memory")
+	ErrBatchTooLarge    = errors.New("batch too large")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrUnknownOperation = errors.New("unknown operation")
+	ErrOverflow         = errors.New("numeric overflow")
+	ErrConflict         = errors.New("write conflict")
+	ErrReadOnly         = errors.New("server is a read-only replica")
+	ErrMoved            = errors.New("slot moved to another node")
+	ErrAsk              = errors.New("slot migrating to another node")
+)
+
+var statusErrors = map[protocol.ResultStatus]error{
+	protocol.FAILURE:           ErrFailure,
+	protocol.NOT_FOUND:         ErrNotFound,
+	protocol.WRONG_TYPE:        ErrWrongType,
+	protocol.OUT_OF_MEMORY:     ErrOutOfMemory,
+	protocol.BATCH_TOO_LARGE:   ErrBatchTooLarge,
+	protocol.UNAUTHORIZED:      ErrUnauthorized,
+	protocol.INVALID_ARGUMENT:  ErrInvalidArgument,
+	protocol.UNKNOWN_OPERATION: ErrUnknownOperation,
+	protocol.OVERFLOW:          ErrOverflow,
+	protocol.CONFLICT:          ErrConflict,
+	protocol.READ_ONLY:         ErrReadOnly,
+	protocol.MOVED:             ErrMoved,
+	protocol.ASK:               ErrAsk,
+}
+
+type statusError struct {
+	status  protocol.ResultStatus
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func (e *statusError) Unwrap() error {
+	if err, ok := statusErrors[e.status]; ok {
+		return err
+	}
+	return ErrFailure
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+func (c *Client) HSet(key string, fields map[string]string) (int, error) {
+	return c.HSetCtx(context.Background(), key, fields)
+}
+
+func (c *Client) HSetCtx(ctx context.Context, key string, fields map[string]string) (int, error) {
+	if len(fields) == 0 {
+		return 0, fmt.Errorf(constants.EmptyValueErr, key)
+	}
+
+	names := make([]string, 0, len(fields))
+	values := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values = append(values, fields[name])
+	}
+
+	if err := c.validateParams(append([]string{key}, names...)...); err != nil {
+		return 0, err
+	}
+
+	if err := c.validateParams(values...); err != nil {
+		return 0, err
+	}
+
+	return c.sendCount(ctx, constants.HSET, protocol.Operation{
+		Type:   protocol.HSET,
+		Key:    []byte(key),
+		Keys:   toBytes(names),
+		Values: toBytes(values),
+	})
+}
+
+func (c *Client) HGet(key, field string) (string, error) {
+	return c.HGetCtx(context.Background(), key, field)
+}
+
+func (c *Client) HGetCtx(ctx context.Context, key, field string) (string, error) {
+	if err := c.validateParams(key, field); err != nil {
+		return "", err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type:   protocol.HGET,
+		Key:    []byte(key),
+		Member: []byte(field),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(response.Message), nil
+}
+
+func (c *Client) HDel(key string, fields ...string) (int, error) {
+	return c.HDelCtx(context.Background(), key, fields...)
+}
+
+func (c *Client) HDelCtx(ctx context.Context, key string, fields ...string) (int, error) {
+	if err := c.validateParams(append([]string{key}, fields...)...); err != nil {
+		return 0, err
+	}
+
+	return c.sendCount(ctx, constants.HDEL, protocol.Operation{
+		Type: protocol.HDEL,
+		Key:  []byte(key),
+		Keys: toBytes(fields),
+	})
+}
+
+func (c *Client) sendCount(ctx context.Context, command string, op protocol.Operation) (int, error) {
+	response, err := c.sendRequest(ctx, op)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(string(response.Message))
+	if err != nil {
+		return 0, fmt.Errorf(constants.InvalidIntErr, command, response.Message)
+	}
+	return n, nil
+}
+
+func (c *Client) HGetAll(key string) (map[string]string, error) {
+	return c.HGetAllCtx(context.Background(), key)
+}
+
+func (c *Client) HGetAllCtx(ctx context.Context, key string) (map[string]string, error) {
+	if err := c.validateParams(key); err != nil {
+		return nil, err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type: protocol.HGETALL,
+		Key:  []byte(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Values)%2 != 0 {
+		return nil, fmt.Errorf(constants.InvalidPairsErr, constants.HGETALL, len(response.Values))
+	}
+
+	fields := make(map[string]string, len(response.Values)/2)
+	for i := 0; i < len(response.Values); i += 2 {
+		fields[string(response.Values[i])] = string(response.Values[i+1])
+	}
+	return fields, nil
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+func (c *Client) LPush(key string, values ...string) (int, error) {
+	return c.LPushCtx(context.Background(), key, values...)
+}
+
+func (c *Client) LPushCtx(ctx context.Context, key string, values ...string) (int, error) {
+	return c.push(ctx, constants.LPUSH, protocol.LPUSH, key, values)
+}
+
+func (c *Client) RPush(key string, values ...string) (int, error) {
+	return c.RPushCtx(context.Background(), key, values...)
+}
+
+func (c *Client) RPushCtx(ctx context.Context, key string, values ...string) (int, error) {
+	return c.push(ctx, constants.RPUSH, protocol.RPUSH, key, values)
+}
+
+func (c *Client) push(
+	ctx context.Context, command string, typ protocol.OperationType, key string, values []string,
+) (int, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf(constants.EmptyValueErr, key)
+	}
+
+	if err := c.validateParams(append([]string{key}, values...)...); err != nil {
+		return 0, err
+	}
+
+	return c.sendCount(ctx, command, protocol.Operation{
+		Type:   typ,
+		Key:    []byte(key),
+		Values: toBytes(values),
+	})
+}
+
+func (c *Client) LPop(key string) (string, error) {
+	return c.LPopCtx(context.Background(), key)
+}
+
+func (c *Client) LPopCtx(ctx context.Context, key string) (string, error) {
+	return c.pop(ctx, protocol.Operation{Type: protocol.LPOP, Key: []byte(key)})
+}
+
+func (c *Client) RPop(key string) (string, error) {
+	return c.RPopCtx(context.Background(), key)
+}
+
+func (c *Client) RPopCtx(ctx context.Context, key string) (string, error) {
+	return c.pop(ctx, protocol.Operation{Type: protocol.RPOP, Key: []byte(key)})
+}
+
+func (c *Client) pop(ctx context.Context, op protocol.Operation) (string, error) {
+	if err := c.validateKeys(op.Key); err != nil {
+		return "", err
+	}
+
+	response, err := c.sendRequest(ctx, op)
+	if err != nil {
+		return "", err
+	}
+	return string(response.Message), nil
+}
+
+// BLPop is sent on a connection of its own, the server parks it until a
+// value arrives or timeout passes.
+func (c *Client) BLPop(key string, timeout time.Duration) (string, error) {
+	return c.BLPopCtx(context.Background(), key, timeout)
+}
+
+func (c *Client) BLPopCtx(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return c.blockingPop(ctx, protocol.BLPOP, key, timeout)
+}
+
+func (c *Client) BRPop(key string, timeout time.Duration) (string, error) {
+	return c.BRPopCtx(context.Background(), key, timeout)
+}
+
+func (c *Client) BRPopCtx(ctx context.Context, key string, timeout time.Duration) (string, error) {
+	return c.blockingPop(ctx, protocol.BRPOP, key, timeout)
+}
+
+func (c *Client) blockingPop(
+	ctx context.Context, typ protocol.OperationType, key string, timeout time.Duration,
+) (string, error) {
+	if err := c.validateParams(key); err != nil {
+		return "", err
+	}
+
+	if timeout < time.Millisecond {
+		return "", fmt.Errorf(constants.InvalidTimeoutErr, timeout)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout+c.requestTimeout)
+		defer cancel()
+	}
+
+	op := protocol.Operation{
+		Type: typ,
+		Key:  []byte(key),
+		TTL:  timeout.Milliseconds(),
+	}
+	if c.router != nil {
+		response, err := c.router.send(ctx, op)
+		return string(response.Message), err
+	}
+
+	results, err := c.sendBlocking(ctx, []protocol.Operation{op})
+	if err != nil {
+		return "", err
+	}
+
+	if err = resultErr(results[0]); err != nil {
+		return "", err
+	}
+	return string(results[0].Message), nil
+}
+
+func (c *Client) LRange(key string, start, stop int64) ([]string, error) {
+	return c.LRangeCtx(context.Background(), key, start, stop)
+}
+
+func (c *Client) LRangeCtx(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if err := c.validateParams(key); err != nil {
+		return nil, err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type:  protocol.LRANGE,
+		Key:   []byte(key),
+		Start: start,
+		Stop:  stop,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(response.Values))
+	for i, value := range response.Values {
+		values[i] = string(value)
+	}
+	return values, nil
+}
+
+func (c *Client) LLen(key string) (int, error) {
+	return c.LLenCtx(context.Background(), key)
+}
+
+func (c *Client) LLenCtx(ctx context.Context, key string) (int, error) {
+	if err := c.validateParams(key); err != nil {
+		return 0, err
+	}
+
+	return c.sendCount(ctx, constants.LLEN, protocol.Operation{Type: protocol.LLEN, Key: []byte(key)})
+}
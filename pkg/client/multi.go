@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+type KeyResult struct {
+	Value string
+	Err   error
+}
+
+func (c *Client) MGet(keys ...string) ([]KeyResult, error) {
+	return c.MGetCtx(context.Background(), keys...)
+}
+
+func (c *Client) MGetCtx(ctx context.Context, keys ...string) ([]KeyResult, error) {
+	if err := c.validateParams(keys...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.sendMulti(ctx, protocol.MGET, toBytes(keys), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyResults(results), nil
+}
+
+func (c *Client) MSet(keys, values []string) ([]error, error) {
+	return c.MSetCtx(context.Background(), keys, values)
+}
+
+func (c *Client) MSetCtx(ctx context.Context, keys, values []string) ([]error, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf(constants.MismatchedErr, len(keys), len(values))
+	}
+
+	if err := c.validateParams(keys...); err != nil {
+		return nil, err
+	}
+
+	if err := c.validateParams(values...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.sendMulti(ctx, protocol.MSET, toBytes(keys), toBytes(values))
+	if err != nil {
+		return nil, err
+	}
+	return keyErrors(constants.MSET, results), nil
+}
+
+func (c *Client) MDel(keys ...string) ([]error, error) {
+	return c.MDelCtx(context.Background(), keys...)
+}
+
+func (c *Client) MDelCtx(ctx context.Context, keys ...string) ([]error, error) {
+	if err := c.validateParams(keys...); err != nil {
+		return nil, err
+	}
+
+	results, err := c.sendMulti(ctx, protocol.MDEL, toBytes(keys), nil)
+	if err != nil {
+		return nil, err
+	}
+	return keyErrors(constants.MDEL, results), nil
+}
+
+func (c *Client) sendMulti(
+	ctx context.Context, opType protocol.OperationType, keys, values [][]byte,
+) ([]protocol.Result, error) {
+	var (
+		wg      sync.WaitGroup
+		results = make([]protocol.Result, len(keys))
+		errs    = make([]error, len(keys)/constants.MaxRequestBatch+1)
+	)
+
+	for start := 0; start < len(keys); start += constants.MaxRequestBatch {
+		end := start + constants.MaxRequestBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		op := protocol.Operation{Type: opType, Keys: keys[start:end]}
+		if values != nil {
+			op.Values = values[start:end]
+		}
+
+		wg.Add(1)
+		go func(start int, op protocol.Operation) {
+			defer wg.Done()
+			res, err := c.sendRequest(ctx, op)
+			if err == nil && len(res.Results) != len(op.Keys) {
+				err = fmt.Errorf(constants.MultiResultsErr, len(op.Keys), op.Type, len(res.Results))
+			}
+
+			if err != nil {
+				errs[start/constants.MaxRequestBatch] = err
+				return
+			}
+			copy(results[start:], res.Results)
+		}(start, op)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func keyErrors(command string, results []protocol.Result) []error {
+	errs := make([]error, len(results))
+	for i, res := range results {
+		if errs[i] = resultErr(res); errs[i] == nil {
+			errs[i] = expectResponse(command, constants.OK, res)
+		}
+	}
+	return errs
+}
+
+func toBytes(params []string) [][]byte {
+	converted := make([][]byte, len(params))
+	for i, param := range params {
+		converted[i] = []byte(param)
+	}
+	return converted
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+// Pipeline sends its operations as one batch, unlike Tx they are not atomic.
+type Pipeline struct {
+	queue
+	client *Client
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{client: c}
+}
+
+func (p *Pipeline) Exec() ([]KeyResult, error) {
+	return p.ExecCtx(context.Background())
+}
+
+func (p *Pipeline) ExecCtx(ctx context.Context) ([]KeyResult, error) {
+	if err := p.client.validateClient(); err != nil {
+		return nil, err
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if len(p.ops) > constants.MaxRequestBatch {
+		return nil, fmt.Errorf(constants.PipelineTooLargeErr, len(p.ops), constants.MaxRequestBatch)
+	}
+
+	if len(p.ops) == 0 {
+		return []KeyResult{}, nil
+	}
+
+	results, err := p.client.sendPipeline(ctx, p.ops)
+	if err != nil {
+		return nil, err
+	}
+	return keyResults(results), nil
+}
+
+func (c *Client) sendPipeline(
+	ctx context.Context, ops []protocol.Operation,
+) ([]protocol.Result, error) {
+	if c.router != nil {
+		return c.router.sendPipeline(ctx, ops)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	requests := make([]clientReq, len(ops))
+	for i, op := range ops {
+		requests[i] = clientReq{
+			ctx: ctx,
+			req: op,
+			res: make(chan clientRes, 1),
+		}
+	}
+
+	select {
+	case c.pipelines <- requests:
+	case <-ctx.Done():
+		return nil, fmt.Errorf(constants.ClientRequestCancelledErr, constants.PIPELINE, ctx.Err())
+	}
+
+	results := make([]protocol.Result, len(requests))
+	for i, req := range requests {
+		select {
+		case res := <-req.res:
+			if res.err != nil {
+				return nil, res.err
+			}
+			results[i] = res.Result
+		case <-ctx.Done():
+			return nil, fmt.Errorf(constants.ClientRequestCancelledErr, constants.PIPELINE, ctx.Err())
+		}
+	}
+	return results, nil
+}
+
+func (c *Client) sendBlocking(
+	ctx context.Context, ops []protocol.Operation,
+) ([]protocol.Result, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	typ := ops[len(ops)-1].Type
+	conn, err := net.DialTimeout(c.network, c.addr, c.dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Worker{conn: conn, readTimeout: c.readTimeout}
+	defer func() {
+		_ = w.close()
+	}()
+
+	// unblock the read as soon as the caller gives up
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	requests := make([]clientReq, len(ops))
+	for i, op := range ops {
+		requests[i] = clientReq{
+			ctx: ctx,
+			req: op,
+			res: make(chan clientRes, 1),
+		}
+	}
+	w.processPipeline(requests)
+
+	// the worker answers synchronously, unless the caller gave up first
+	results := make([]protocol.Result, len(requests))
+	for i, req := range requests {
+		var res clientRes
+		select {
+		case res = <-req.res:
+		default:
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf(constants.ClientRequestCancelledErr, typ, ctx.Err())
+		}
+
+		if res.err != nil {
+			return nil, res.err
+		}
+		results[i] = res.Result
+	}
+	return results, nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+type queue struct {
+	ops []protocol.Operation
+	err error
+}
+
+func (q *queue) add(op protocol.Operation, params ...string) {
+	for _, param := range params {
+		if param == "" && q.err == nil {
+			q.err = errors.New(constants.EmptyParamErr)
+		}
+	}
+	q.ops = append(q.ops, op)
+}
+
+func (q *queue) Get(key string) {
+	q.add(protocol.Operation{Type: protocol.GET, Key: []byte(key)}, key)
+}
+
+func (q *queue) Set(key, val string) {
+	q.add(protocol.Operation{Type: protocol.SET, Key: []byte(key), Value: []byte(val)}, key, val)
+}
+
+func (q *queue) SetEx(key, val string, ttl time.Duration) {
+	if err := validateTTL(ttl); err != nil && q.err == nil {
+		q.err = err
+	}
+
+	q.add(protocol.Operation{
+		Type:  protocol.SETEX,
+		Key:   []byte(key),
+		Value: []byte(val),
+		TTL:   ttl.Milliseconds(),
+	}, key, val)
+}
+
+func (q *queue) Del(key string) {
+	q.add(protocol.Operation{Type: protocol.DELETE, Key: []byte(key)}, key)
+}
+
+func (q *queue) Incr(key string) {
+	q.add(protocol.Operation{Type: protocol.INCR, Key: []byte(key)}, key)
+}
+
+func (q *queue) IncrBy(key string, delta int64) {
+	q.add(protocol.Operation{Type: protocol.INCRBY, Key: []byte(key), Delta: delta}, key)
+}
+
+func (q *queue) Decr(key string) {
+	q.add(protocol.Operation{Type: protocol.DECR, Key: []byte(key)}, key)
+}
+
+func keyResults(results []protocol.Result) []KeyResult {
+	values := make([]KeyResult, len(results))
+	for i, res := range results {
+		if values[i].Err = resultErr(res); values[i].Err == nil {
+			values[i].Value = string(res.Message)
+		}
+	}
+	return values
+}
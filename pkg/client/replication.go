@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+type ReplicationInfo struct {
+	Role      string
+	Offset    uint64
+	Replicas  int
+	Leader    string
+	Connected bool
+	Lag       time.Duration
+}
+
+func (c *Client) Replication() (ReplicationInfo, error) {
+	return c.ReplicationCtx(context.Background())
+}
+
+func (c *Client) ReplicationCtx(ctx context.Context) (ReplicationInfo, error) {
+	if err := c.validateClient(); err != nil {
+		return ReplicationInfo{}, err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{Type: protocol.REPLICATION})
+	if err != nil {
+		return ReplicationInfo{}, err
+	}
+
+	if len(response.Values)%2 != 0 {
+		return ReplicationInfo{}, fmt.Errorf(
+			constants.InvalidPairsErr, constants.REPLICATION, len(response.Values),
+		)
+	}
+
+	info := ReplicationInfo{Role: string(response.Message), Offset: response.Version}
+	for i := 0; i < len(response.Values); i += 2 {
+		value := string(response.Values[i+1])
+		switch string(response.Values[i]) {
+		case "replicas":
+			info.Replicas, err = strconv.Atoi(value)
+		case "leader":
+			info.Leader = value
+		case "connected":
+			info.Connected, err = strconv.ParseBool(value)
+		case "lag":
+			var ms int64
+			ms, err = strconv.ParseInt(value, 10, 64)
+			info.Lag = time.Duration(ms) * time.Millisecond
+		}
+
+		if err != nil {
+			return ReplicationInfo{}, fmt.Errorf(constants.InvalidIntErr, constants.REPLICATION, value)
+		}
+	}
+	return info, nil
+}
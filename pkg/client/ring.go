@@ -0,0 +1,74 @@
+package client
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+type point struct {
+	hash uint64
+	node string
+}
+
+type ring struct {
+	virtualNodes int
+	points       []point
+}
+
+func newRing(virtualNodes int) *ring {
+	return &ring{virtualNodes: virtualNodes}
+}
+
+func (r *ring) add(node string) {
+	for i := 0; i < r.virtualNodes; i++ {
+		r.points = append(r.points, point{hash: hashKey(node + "#" + strconv.Itoa(i)), node: node})
+	}
+
+	// ties are broken by node so every client orders the ring the same way
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].node < r.points[j].node
+	})
+}
+
+func (r *ring) remove(node string) {
+	points := r.points[:0]
+	for _, p := range r.points {
+		if p.node != node {
+			points = append(points, p)
+		}
+	}
+	r.points = points
+}
+
+func (r *ring) get(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	hash := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node, true
+}
+
+// hashKey adds a finalizer since FNV alone clusters virtual node names that
+// differ only in their last bytes.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	hash := h.Sum64()
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
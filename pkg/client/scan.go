@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+func (c *Client) ScanPage(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	return c.ScanPageCtx(context.Background(), cursor, pattern, count)
+}
+
+func (c *Client) ScanPageCtx(
+	ctx context.Context, cursor uint64, pattern string, count int,
+) ([]string, uint64, error) {
+	if err := c.validateClient(); err != nil {
+		return nil, 0, err
+	}
+
+	response, err := c.sendRequest(ctx, protocol.Operation{
+		Type:    protocol.SCAN,
+		Pattern: []byte(pattern),
+		Cursor:  cursor,
+		Count:   int64(count),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keys := make([]string, len(response.Values))
+	for i, key := range response.Values {
+		keys[i] = string(key)
+	}
+	return keys, response.Version, nil
+}
+
+// Scanner returns every key that exists for the whole walk exactly once.
+type Scanner struct {
+	client  *Client
+	ctx     context.Context
+	pattern string
+	count   int
+	cursor  uint64
+	keys    []string
+	key     string
+	done    bool
+	err     error
+}
+
+func (c *Client) Scan(pattern string, count int) *Scanner {
+	return c.ScanCtx(context.Background(), pattern, count)
+}
+
+func (c *Client) ScanCtx(ctx context.Context, pattern string, count int) *Scanner {
+	return &Scanner{
+		client:  c,
+		ctx:     ctx,
+		pattern: pattern,
+		count:   count,
+	}
+}
+
+func (it *Scanner) Next() bool {
+	for len(it.keys) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		it.keys, it.cursor, it.err = it.client.ScanPageCtx(it.ctx, it.cursor, it.pattern, it.count)
+		it.done = it.cursor == 0
+	}
+
+	it.key, it.keys = it.keys[0], it.keys[1:]
+	return true
+}
+
+func (it *Scanner) Key() string {
+	return it.key
+}
+
+func (it *Scanner) Err() error {
+	return it.err
+}
+
+// Keys walks the whole keyspace, it is meant for debugging.
+func (c *Client) Keys(pattern string) ([]string, error) {
+	return c.KeysCtx(context.Background(), pattern)
+}
+
+func (c *Client) KeysCtx(ctx context.Context, pattern string) ([]string, error) {
+	keys := []string{}
+	it := c.ScanCtx(ctx, pattern, constants.MaxScanCount)
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	return keys, it.Err()
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+type Tx struct {
+	queue
+	client   *Client
+	keys     [][]byte
+	versions []uint64
+}
+
+func (c *Client) Tx() *Tx {
+	return &Tx{client: c}
+}
+
+func (tx *Tx) Watch(key string, version uint64) {
+	if key == "" && tx.err == nil {
+		tx.err = errors.New(constants.EmptyParamErr)
+	}
+
+	tx.keys = append(tx.keys, []byte(key))
+	tx.versions = append(tx.versions, version)
+}
+
+func (tx *Tx) Exec() ([]KeyResult, error) {
+	return tx.ExecCtx(context.Background())
+}
+
+func (tx *Tx) ExecCtx(ctx context.Context) ([]KeyResult, error) {
+	if err := tx.client.validateClient(); err != nil {
+		return nil, err
+	}
+
+	if tx.err != nil {
+		return nil, tx.err
+	}
+
+	if len(tx.ops) > constants.MaxRequestBatch {
+		return nil, fmt.Errorf(constants.TxTooLargeErr, len(tx.ops), constants.MaxRequestBatch)
+	}
+
+	response, err := tx.client.sendRequest(ctx, protocol.Operation{
+		Type:     protocol.TX,
+		Ops:      tx.ops,
+		Keys:     tx.keys,
+		Versions: tx.versions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Results) != len(tx.ops) {
+		return nil, fmt.Errorf(constants.MultiResultsErr, len(tx.ops), protocol.TX, len(response.Results))
+	}
+	return keyResults(response.Results), nil
+}
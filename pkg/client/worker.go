@@ -0,0 +1,421 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/util"
+)
+
+type Worker struct {
+	mu          sync.Mutex
+	conn        net.Conn
+	reconnects  atomic.Uint64
+	shutdown    chan bool
+	stopOnce    sync.Once
+	requests    chan clientReq
+	pipelines   chan []clientReq
+	readBuf     []byte
+	writeBuf    []byte
+	network     string
+	addr        string
+	dialTimeout time.Duration
+	batchWindow time.Duration
+	maxBatch    int
+	readTimeout time.Duration
+}
+
+type WorkerHealth struct {
+	Connected  bool
+	Reconnects uint64
+}
+
+func (w *Worker) Health() WorkerHealth {
+	return WorkerHealth{
+		Connected:  w.connection() != nil,
+		Reconnects: w.reconnects.Load(),
+	}
+}
+
+func (w *Worker) connection() net.Conn {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn
+}
+
+func (w *Worker) setConnection(conn net.Conn) {
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+}
+
+func (w *Worker) stop() {
+	w.stopOnce.Do(func() {
+		close(w.shutdown)
+	})
+}
+
+func (w *Worker) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *Worker) scheduler() {
+	var (
+		timer    = time.NewTimer(w.batchWindow)
+		batch    = &protocol.BatchedRequest{}
+		requests = make([]clientReq, 0, w.maxBatch)
+	)
+	stopTimer(timer)
+	defer func() {
+		_ = w.close()
+	}()
+
+	for {
+		if w.connection() == nil && !w.reconnect() {
+			return
+		}
+
+		select {
+		case <-w.shutdown:
+			stopTimer(timer)
+			return
+		case req := <-w.requests:
+			if len(requests) == 0 {
+				timer.Reset(w.batchWindow)
+			}
+
+			requests = append(requests, req)
+			if len(requests) < w.maxBatch {
+				continue
+			}
+			stopTimer(timer)
+		case pipeline := <-w.pipelines:
+			w.processPipeline(pipeline)
+			continue
+		case <-timer.C:
+		}
+
+		w.processBatch(batch, requests)
+		requests = requests[:0]
+	}
+}
+
+func (w *Worker) reconnect() bool {
+	wait := constants.ConnRetryWait
+	for {
+		conn, err := net.DialTimeout(w.network, w.addr, w.dialTimeout)
+		if err == nil {
+			w.setConnection(conn)
+			w.reconnects.Add(1)
+			return true
+		}
+
+		select {
+		case <-w.shutdown:
+			return false
+		case <-time.After(jitter(wait)):
+		}
+
+		wait *= 2
+		if wait > constants.ReconnectMaxWait {
+			wait = constants.ReconnectMaxWait
+		}
+	}
+}
+
+func jitter(wait time.Duration) time.Duration {
+	half := int64(wait / 2)
+	return time.Duration(half + rand.Int63n(half+1)) //nolint:gosec // jitter needs no secure source
+}
+
+func (w *Worker) disconnect(err error, requests []clientReq) {
+	_ = w.close()
+	batchError(err, requests)
+}
+
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+func (w *Worker) processBatch(
+	batch *protocol.BatchedRequest, requests []clientReq,
+) {
+	requests = dropCancelled(requests)
+	if len(requests) == 0 {
+		return
+	}
+
+	ops, requestIndex := requestDeduplication(operations(requests))
+	w.sendBatch(batch, requests, ops, requestIndex)
+}
+
+// processPipeline skips deduplication, a pipeline may read a key before and
+// after writing it.
+func (w *Worker) processPipeline(requests []clientReq) {
+	requests = dropCancelled(requests)
+	if len(requests) == 0 {
+		return
+	}
+
+	requestIndex := make(map[int][]int, len(requests))
+	for i := range requests {
+		requestIndex[i] = []int{i}
+	}
+	w.sendBatch(&protocol.BatchedRequest{}, requests, operations(requests), requestIndex)
+}
+
+func (w *Worker) sendBatch(
+	batch *protocol.BatchedRequest, requests []clientReq,
+	ops []protocol.Operation, requestIndex map[int][]int,
+) {
+	defer w.releaseBuffers()
+	batch.Operations = ops
+
+	var err error
+	w.writeBuf, err = batch.MarshalMsg(grow(w.writeBuf, constants.HeaderSize))
+	if err != nil {
+		batchError(err, requests)
+		return
+	}
+	binary.LittleEndian.PutUint32(w.writeBuf, uint32(len(w.writeBuf)-constants.HeaderSize))
+
+	conn := w.connection()
+	if conn == nil {
+		batchError(ErrDisconnected, requests)
+		return
+	}
+
+	deadline := batchDeadline(requests, w.readTimeout)
+	if err = conn.SetWriteDeadline(deadline); err != nil {
+		w.disconnect(err, requests)
+		return
+	}
+
+	_, err = conn.Write(w.writeBuf)
+	if err != nil {
+		w.disconnect(err, requests)
+		return
+	}
+
+	responseBytes, err := w.readFrame(conn, deadline)
+	if err != nil {
+		w.disconnect(err, requests)
+		return
+	}
+
+	batchResponse := &protocol.BatchedResponse{}
+	_, unmarshalErr := batchResponse.UnmarshalMsg(responseBytes)
+	if unmarshalErr != nil {
+		w.disconnect(fmt.Errorf("%w: %w", ErrCorruptFrame, unmarshalErr), requests)
+		return
+	}
+
+	responses := batchResponse.Results
+	if len(responses) != len(batch.Operations) {
+		switch {
+		case len(responses) == 1 && responses[0].Status != protocol.SUCCESS:
+			err = resultErr(responses[0])
+		case len(responses) == 1:
+			err = fmt.Errorf(
+				"received 1 response: (%s) %s, requests: %v",
+				responses[0].Status,
+				responses[0].Message,
+				batch.Operations[0],
+			)
+		default:
+			err = fmt.Errorf(
+				"expected %d responses, received %d",
+				len(requests), len(responses),
+			)
+		}
+		batchError(err, requests)
+		return
+	}
+
+	propagateBatch(responses, requests, requestIndex)
+}
+
+func dropCancelled(requests []clientReq) []clientReq {
+	live := make([]clientReq, 0, len(requests))
+	for _, req := range requests {
+		if req.ctx == nil || req.ctx.Err() == nil {
+			live = append(live, req)
+		}
+	}
+	return live
+}
+
+func operations(requests []clientReq) []protocol.Operation {
+	ops := make([]protocol.Operation, len(requests))
+	for i, req := range requests {
+		ops[i] = req.req
+	}
+	return ops
+}
+
+// batchDeadline is the latest deadline in the batch so no caller is cut short
+// by another's.
+func batchDeadline(requests []clientReq, readTimeout time.Duration) time.Time {
+	now := time.Now()
+	latest := now.Add(readTimeout)
+	for i, req := range requests {
+		deadline := now.Add(readTimeout)
+		if req.req.Type.Blocking() {
+			deadline = deadline.Add(time.Duration(req.req.TTL) * time.Millisecond)
+		}
+
+		if req.ctx != nil {
+			if ctxDeadline, ok := req.ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+				deadline = ctxDeadline
+			}
+		}
+
+		if i == 0 || deadline.After(latest) {
+			latest = deadline
+		}
+	}
+	return latest
+}
+
+func requestDeduplication(operations []protocol.Operation) ([]protocol.Operation, map[int][]int) {
+	deduplicated := []protocol.Operation{}
+	index := make(map[int][]int)
+	seen := make(map[string]int)
+
+	for i, op := range operations {
+		if !op.Type.Idempotent() {
+			index[len(deduplicated)] = []int{i}
+			deduplicated = append(deduplicated, op)
+			continue
+		}
+
+		hash := op.Index()
+		if updatedInx, opSeen := seen[hash]; opSeen {
+			index[updatedInx] = append(index[updatedInx], i)
+		} else {
+			newInx := len(deduplicated)
+			seen[hash] = newInx
+			deduplicated = append(deduplicated, op)
+			index[newInx] = []int{i}
+		}
+	}
+
+	return deduplicated, index
+}
+
+func batchError(err error, requests []clientReq) {
+	for _, req := range requests {
+		req.res <- clientRes{err: err}
+	}
+}
+
+func copyResult(res protocol.Result) protocol.Result {
+	copied := protocol.Result{
+		Status:  res.Status,
+		Message: append([]byte{}, res.Message...),
+		Results: copyResults(res.Results),
+		Version: res.Version,
+	}
+	if res.Values != nil {
+		copied.Values = make([][]byte, len(res.Values))
+		for i, value := range res.Values {
+			copied.Values[i] = append([]byte{}, value...)
+		}
+	}
+	return copied
+}
+
+func copyResults(results []protocol.Result) []protocol.Result {
+	if results == nil {
+		return nil
+	}
+
+	copied := make([]protocol.Result, len(results))
+	for i, res := range results {
+		copied[i] = copyResult(res)
+	}
+	return copied
+}
+
+func propagateBatch(responses []protocol.Result, requests []clientReq, index map[int][]int) {
+	for i, res := range responses {
+		for j, dup := range index[i] {
+			if j > 0 {
+				res = copyResult(res)
+			}
+			requests[dup].res <- clientRes{Result: res}
+		}
+	}
+}
+
+// readFrame returns the worker's read buffer, only valid until the next call.
+func (w *Worker) readFrame(conn net.Conn, deadline time.Time) ([]byte, error) {
+	err := conn.SetReadDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	w.readBuf = grow(w.readBuf, constants.HeaderSize)
+	if _, err = io.ReadFull(conn, w.readBuf); err != nil {
+		return nil, readErr(err)
+	}
+
+	length := binary.LittleEndian.Uint32(w.readBuf)
+	if length > constants.MaxFrameSize {
+		return nil, fmt.Errorf(
+			"%w: "+constants.FrameTooLargeErr, ErrCorruptFrame, length, constants.MaxFrameSize,
+		)
+	}
+
+	w.readBuf = grow(w.readBuf, int(length))
+	if _, err = io.ReadFull(conn, w.readBuf); err != nil {
+		return nil, readErr(err)
+	}
+
+	return w.readBuf, nil
+}
+
+func grow(buf []byte, size int) []byte {
+	if cap(buf) < size {
+		return make([]byte, size)
+	}
+	return buf[:size]
+}
+
+func readErr(err error) error {
+	if util.IsTimeout(err) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+func (w *Worker) releaseBuffers() {
+	if cap(w.readBuf) > constants.MaxRetainedBuffer {
+		w.readBuf = nil
+	}
+
+	if cap(w.writeBuf) > constants.MaxRetainedBuffer {
+		w.writeBuf = nil
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+func (c *Client) ZAdd(key string, members map[string]float64) (int, error) {
+	return c.ZAddCtx(context.Background(), key, members)
+}
+
+func (c *Client) ZAddCtx(ctx context.Context, key string, members map[string]float64) (int, error) {
+	if len(members) == 0 {
+		return 0, fmt.Errorf(constants.EmptyValueErr, key)
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scores := make([]float64, len(names))
+	for i, name := range names {
+		score := members[name]
+		if math.IsInf(score, 0) || math.IsNaN(score) {
+			return 0, fmt.Errorf(constants.InvalidScoreErr, score)
+		}
+		scores[i] = score
+	}
+
+	if err := c.validateParams(append([]string{key}, names...)...); err != nil {
+		return 0, err
+	}
+
+	return c.sendCount(ctx, constants.ZADD, protocol.Operation{
+		Type:   protocol.ZADD,
+		Key:    []byte(key),
+		Keys:   toBytes(names),
+		Scores: scores,
+	})
+}
+
+func (c *Client) ZRem(key string, members ...string) (int, error) {
+	return c.ZRemCtx(context.Background(), key, members...)
+}
+
+func (c *Client) ZRemCtx(ctx context.Context, key string, members ...string) (int, error) {
+	if err := c.validateParams(append([]string{key}, members...)...); err != nil {
+		return 0, err
+	}
+
+	return c.sendCount(ctx, constants.ZREM, protocol.Operation{
+		Type: protocol.ZREM,
+		Key:  []byte(key),
+		Keys: toBytes(members),
+	})
+}
+
+func (c *Client) ZScore(key, member string) (float64, error) {
+	return c.ZScoreCtx(context.Background(), key, member)
+}
+
+func (c *Client) ZScoreCtx(ctx context.Context, key, member string) (float64, error) {
+	if err := c.validateParams(key, member); err != nil {
+		return 0, err
+	}
+
+	return c.sendScore(ctx, constants.ZSCORE, protocol.Operation{
+		Type:   protocol.ZSCORE,
+		Key:    []byte(key),
+		Member: []byte(member),
+	})
+}
+
+func (c *Client) ZIncrBy(key, member string, delta float64) (float64, error) {
+	return c.ZIncrByCtx(context.Background(), key, member, delta)
+}
+
+func (c *Client) ZIncrByCtx(ctx context.Context, key, member string, delta float64) (float64, error) {
+	if err := c.validateParams(key, member); err != nil {
+		return 0, err
+	}
+
+	if math.IsInf(delta, 0) || math.IsNaN(delta) {
+		return 0, fmt.Errorf(constants.InvalidIncrementErr, delta)
+	}
+
+	return c.sendScore(ctx, constants.ZINCRBY, protocol.Operation{
+		Type:   protocol.ZINCRBY,
+		Key:    []byte(key),
+		Keys:   [][]byte{[]byte(member)},
+		Scores: []float64{delta},
+	})
+}
+
+func (c *Client) ZRange(key string, start, stop int64) ([]ZMember, error) {
+	return c.ZRangeCtx(context.Background(), key, start, stop)
+}
+
+func (c *Client) ZRangeCtx(ctx context.Context, key string, start, stop int64) ([]ZMember, error) {
+	if err := c.validateParams(key); err != nil {
+		return nil, err
+	}
+
+	return c.sendMembers(ctx, constants.ZRANGE, protocol.Operation{
+		Type:  protocol.ZRANGE,
+		Key:   []byte(key),
+		Start: start,
+		Stop:  stop,
+	})
+}
+
+func (c *Client) ZRangeByScore(key string, min, max float64) ([]ZMember, error) {
+	return c.ZRangeByScoreCtx(context.Background(), key, min, max)
+}
+
+func (c *Client) ZRangeByScoreCtx(ctx context.Context, key string, min, max float64) ([]ZMember, error) {
+	if err := c.validateParams(key); err != nil {
+		return nil, err
+	}
+
+	for _, bound := range []float64{min, max} {
+		if math.IsNaN(bound) {
+			return nil, fmt.Errorf(constants.InvalidScoreErr, bound)
+		}
+	}
+
+	return c.sendMembers(ctx, constants.ZRANGEBYSCORE, protocol.Operation{
+		Type:   protocol.ZRANGEBYSCORE,
+		Key:    []byte(key),
+		Scores: []float64{min, max},
+	})
+}
+
+func (c *Client) sendScore(ctx context.Context, command string, op protocol.Operation) (float64, error) {
+	response, err := c.sendRequest(ctx, op)
+	if err != nil {
+		return 0, err
+	}
+
+	score, err := strconv.ParseFloat(string(response.Message), 64)
+	if err != nil {
+		return 0, fmt.Errorf(constants.InvalidFloatErr, command, response.Message)
+	}
+	return score, nil
+}
+
+func (c *Client) sendMembers(ctx context.Context, command string, op protocol.Operation) ([]ZMember, error) {
+	response, err := c.sendRequest(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Values)%2 != 0 {
+		return nil, fmt.Errorf(constants.InvalidScoresErr, command, len(response.Values))
+	}
+
+	members := make([]ZMember, 0, len(response.Values)/2)
+	for i := 0; i < len(response.Values); i += 2 {
+		score, err := strconv.ParseFloat(string(response.Values[i+1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf(constants.InvalidFloatErr, command, response.Values[i+1])
+		}
+		members = append(members, ZMember{Member: string(response.Values[i]), Score: score})
+	}
+	return members, nil
+}
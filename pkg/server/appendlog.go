@@ -0,0 +1,201 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/oplog"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/snapshot"
+	"github.com/kevindweb/cache/internal/storage"
+)
+
+type SyncPolicy = oplog.SyncPolicy
+
+const (
+	SyncEverySecond = oplog.EverySecond
+	SyncAlways      = oplog.Always
+	SyncNever       = oplog.Never
+)
+
+func (s *Server) replayLog() (bool, error) {
+	if s.appendLogPath == "" {
+		return false, nil
+	}
+
+	if _, err := os.Stat(s.appendLogPath); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	_, err := oplog.Replay(s.appendLogPath, func(base []byte) error {
+		_, err := snapshot.Decode(base, s.kv)
+		return err
+	}, s.replayOp)
+	if err != nil {
+		return true, fmt.Errorf(constants.ReplayLogErr, s.appendLogPath, err)
+	}
+	return true, nil
+}
+
+// replayOp shortens ttls by the time since op was logged, keys that expired
+// meanwhile get a millisecond so later operations replay against them.
+func (s *Server) replayOp(op protocol.Operation, at time.Time) error {
+	s.processRequest(rebase(op, time.Since(at).Milliseconds()))
+	return nil
+}
+
+func rebase(op protocol.Operation, elapsed int64) protocol.Operation {
+	if op.TTL > 0 {
+		op.TTL -= elapsed
+		if op.TTL < 1 {
+			op.TTL = 1
+		}
+	}
+
+	if len(op.Ops) > 0 {
+		ops := make([]protocol.Operation, len(op.Ops))
+		for i := range op.Ops {
+			ops[i] = rebase(op.Ops[i], elapsed)
+		}
+		op.Ops = ops
+	}
+	return op
+}
+
+func (s *Server) openLog(compact bool) error {
+	if s.appendLogPath == "" {
+		return nil
+	}
+
+	l, err := oplog.Open(s.appendLogPath, s.appendLogSync)
+	if err != nil {
+		return err
+	}
+	s.appendLog = l
+
+	if !compact || !l.StartCompaction() {
+		return nil
+	}
+
+	data, err := snapshot.Encode(s.kv)
+	if err != nil {
+		l.AbortCompaction()
+		return err
+	}
+	return l.FinishCompaction(data)
+}
+
+// logged rewrites op to apply again regardless of timing or versions.
+func logged(op protocol.Operation) protocol.Operation {
+	switch op.Type {
+	case protocol.BLPOP:
+		op.Type, op.TTL = protocol.LPOP, 0
+	case protocol.BRPOP:
+		op.Type, op.TTL = protocol.RPOP, 0
+	case protocol.SETNX, protocol.SETXX, protocol.CAS:
+		op.Type, op.Version = protocol.SET, 0
+		if op.TTL > 0 {
+			op.Type = protocol.SETEX
+		}
+	case protocol.TX:
+		ops := make([]protocol.Operation, len(op.Ops))
+		for i := range op.Ops {
+			ops[i] = logged(op.Ops[i])
+		}
+		op.Ops, op.Keys, op.Versions = ops, nil, nil
+	}
+	return op
+}
+
+func (s *Server) record(sess *session, op protocol.Operation, res protocol.Result) error {
+	var err error
+	if op.Type.Recorded() && res.Status == protocol.SUCCESS {
+		err = s.recordOp(sess, logged(op))
+	}
+
+	if len(s.evicted) == 0 {
+		return err
+	}
+
+	for _, key := range s.evicted {
+		if _, _, getErr := s.kv.GetVersion(key); errors.Is(getErr, storage.ErrNotFound) && err == nil {
+			err = s.recordOp(sess, protocol.Operation{Type: protocol.DELETE, Key: key})
+		}
+	}
+	s.evicted = s.evicted[:0]
+	return err
+}
+
+func (s *Server) recordOp(sess *session, op protocol.Operation) error {
+	if s.replicas.active() {
+		sess.replicated = append(sess.replicated, op)
+	}
+
+	if s.appendLog == nil {
+		return nil
+	}
+
+	var err error
+	sess.records, err = oplog.AppendOp(sess.records, op, time.Now())
+	return err
+}
+
+func (s *Server) evict(key []byte) {
+	if s.appendLog != nil || s.replicas.active() {
+		s.evicted = append(s.evicted, key)
+	}
+}
+
+func (s *Server) flushRecords(sess *session) error {
+	var err error
+	if len(sess.replicated) > 0 {
+		err = s.replicas.send(sess.replicated, time.Now())
+		sess.replicated = sess.replicated[:0]
+	}
+
+	if len(sess.records) > 0 {
+		err = errors.Join(err, s.appendLog.Write(sess.records))
+		sess.records = sess.records[:0]
+	}
+	return err
+}
+
+func (s *Server) compactIfDue() {
+	if s.appendLog == nil {
+		return
+	}
+
+	size, base := s.appendLog.Size()
+	if size < s.compactLogSize || size < 2*base {
+		return
+	}
+
+	s.txLock.Lock()
+	started := s.appendLog.StartCompaction()
+	var data []byte
+	var err error
+	if started {
+		data, err = snapshot.Encode(s.kv)
+	}
+	s.txLock.Unlock()
+	if !started {
+		return
+	}
+
+	if err != nil {
+		s.appendLog.AbortCompaction()
+		s.logger.Printf(constants.CompactLogErr, s.appendLogPath, err)
+		return
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		if err := s.appendLog.FinishCompaction(data); err != nil {
+			s.logger.Printf(constants.CompactLogErr, s.appendLogPath, err)
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/evio"
+)
+
+func logServer(t *testing.T, opts Options) *Server {
+	t.Helper()
+	server, err := New(opts)
+	require.NoError(t, err)
+	return server
+}
+
+func handleAll(t *testing.T, server *Server, ops ...protocol.Operation) []protocol.Result {
+	t.Helper()
+	out, action := server.handle(newSession(), frame(t, ops...))
+	assert.Equal(t, evio.None, action)
+	responses := readResponses(t, out)
+	require.Len(t, responses, 1)
+	return responses[0].Results
+}
+
+func TestAppendLogReplay(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "ops.log")
+	opts := Options{AppendLogPath: path, AppendLogSync: SyncAlways}
+	server := logServer(t, opts)
+
+	results := handleAll(t, server,
+		set("key", "v1"),
+		protocol.Operation{Type: protocol.SETEX, Key: []byte("temp"), Value: []byte("val"), TTL: time.Minute.Milliseconds()},
+		push(protocol.RPUSH, "list", "a", "b"),
+		protocol.Operation{Type: protocol.LPOP, Key: []byte("list")},
+		protocol.Operation{Type: protocol.SETNX, Key: []byte("key"), Value: []byte("ignored")},
+		get("key"),
+	)
+	assert.Equal(t, protocol.SUCCESS, results[3].Status)
+	assert.NotEqual(t, protocol.SUCCESS, results[4].Status)
+
+	res := server.processRequest(protocol.Operation{Type: protocol.GETVERSION, Key: []byte("key")})
+	handleAll(t, server, protocol.Operation{
+		Type: protocol.CAS, Key: []byte("key"), Value: []byte("v2"), Version: res.Version,
+	})
+	require.NoError(t, server.appendLog.Close())
+
+	restored := logServer(t, opts)
+	defer restored.appendLog.Close()
+	res = restored.processRequest(get("key"))
+	assert.Equal(t, "v2", string(res.Message))
+	res = restored.processRequest(protocol.Operation{Type: protocol.LRANGE, Key: []byte("list"), Start: 0, Stop: -1})
+	assert.Equal(t, [][]byte{[]byte("b")}, res.Values)
+	ttl, err := restored.kv.TTL([]byte("temp"))
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 50*time.Second)
+}
+
+func TestAppendLogCorrupt(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "ops.log")
+	require.NoError(t, os.WriteFile(path, []byte("not a log"), 0o600))
+	_, err := New(Options{AppendLogPath: path})
+	assert.Error(t, err)
+}
+
+func TestAppendLogOptions(t *testing.T) {
+	t.Parallel()
+	_, err := New(Options{AppendLogPath: "ops.log", AppendLogSync: SyncPolicy(7)})
+	assert.Error(t, err)
+	_, err = New(Options{AppendLogPath: "ops.log", CompactLogSize: -1})
+	assert.Error(t, err)
+}
+
+func TestAppendLogLoadsSnapshot(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	opts := Options{SnapshotPath: filepath.Join(dir, "dump.snap")}
+	server := logServer(t, opts)
+	server.processRequest(set("saved", "val"))
+	require.NoError(t, server.save())
+
+	// enabling the log later starts it from the snapshot
+	opts.AppendLogPath = filepath.Join(dir, "ops.log")
+	server = logServer(t, opts)
+	handleAll(t, server, set("logged", "val"))
+	require.NoError(t, server.appendLog.Close())
+	require.NoError(t, os.Remove(opts.SnapshotPath))
+
+	restored := logServer(t, opts)
+	defer restored.appendLog.Close()
+	for _, key := range []string{"saved", "logged"} {
+		res := restored.processRequest(get(key))
+		assert.Equal(t, "val", string(res.Message), key)
+	}
+}
+
+func TestCompactIfDue(t *testing.T) {
+	t.Parallel()
+	opts := Options{AppendLogPath: filepath.Join(t.TempDir(), "ops.log"), CompactLogSize: 1}
+	server := logServer(t, opts)
+	for i := 0; i < 10; i++ {
+		handleAll(t, server, set("key", "val"+string(rune('0'+i))))
+	}
+
+	size, _ := server.appendLog.Size()
+	server.compactIfDue()
+	server.background.Wait()
+	compacted, base := server.appendLog.Size()
+	assert.Less(t, compacted, size)
+	assert.Equal(t, base, compacted)
+
+	server.compactIfDue()
+	handleAll(t, server, set("after", "val"))
+	server.background.Wait()
+	require.NoError(t, server.appendLog.Close())
+
+	restored := logServer(t, opts)
+	defer restored.appendLog.Close()
+	res := restored.processRequest(get("key"))
+	assert.Equal(t, "val9", string(res.Message))
+	res = restored.processRequest(get("after"))
+	assert.Equal(t, "val", string(res.Message))
+}
+
+func TestLogged(t *testing.T) {
+	t.Parallel()
+	key := []byte("key")
+	tests := []struct {
+		op   protocol.Operation
+		want protocol.Operation
+	}{
+		{
+			op:   blpop("list", time.Second),
+			want: protocol.Operation{Type: protocol.LPOP, Key: []byte("list")},
+		},
+		{
+			op:   protocol.Operation{Type: protocol.CAS, Key: key, Version: 3},
+			want: protocol.Operation{Type: protocol.SET, Key: key},
+		},
+		{
+			op:   protocol.Operation{Type: protocol.SETNX, Key: key, TTL: 5},
+			want: protocol.Operation{Type: protocol.SETEX, Key: key, TTL: 5},
+		},
+		{
+			op: protocol.Operation{
+				Type:     protocol.TX,
+				Keys:     [][]byte{key},
+				Versions: []uint64{1},
+				Ops:      []protocol.Operation{{Type: protocol.SETXX, Key: key}, get("key")},
+			},
+			want: protocol.Operation{
+				Type: protocol.TX,
+				Ops:  []protocol.Operation{{Type: protocol.SET, Key: key}, get("key")},
+			},
+		},
+		{
+			op:   set("key", "val"),
+			want: set("key", "val"),
+		},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, logged(tc.op), tc.op.Type.String())
+	}
+}
+
+func TestRebase(t *testing.T) {
+	t.Parallel()
+	op := protocol.Operation{Type: protocol.TX, Ops: []protocol.Operation{
+		{Type: protocol.SETEX, TTL: 100},
+		{Type: protocol.SETEX, TTL: 10},
+		set("key", "val"),
+	}}
+	rebased := rebase(op, 50)
+	assert.Equal(t, int64(50), rebased.Ops[0].TTL)
+	assert.Equal(t, int64(1), rebased.Ops[1].TTL)
+	assert.Equal(t, int64(0), rebased.Ops[2].TTL)
+	assert.Equal(t, int64(100), op.Ops[0].TTL, "the logged operation is left as is")
+}
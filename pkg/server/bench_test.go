@@ -14,15 +14,18 @@ func encode(b *testing.B, data protocol.BatchedRequest) []byte {
 	if encoded, err = data.MarshalMsg(nil); err != nil {
 		b.Fatal(err)
 	}
-	return encoded
+	return protocol.AppendFrame(nil, encoded)
 }
 
 func startUniqueServer(b *testing.B) (*Server, func()) {
-	server, err := StartOptions(Options{
-		Host:    constants.DefaultHost,
-		Port:    util.GetUniquePort(),
-		Network: constants.DefaultNetwork,
-	})
+	return startUniqueServerOptions(b, Options{})
+}
+
+func startUniqueServerOptions(b *testing.B, opts Options) (*Server, func()) {
+	opts.Host = constants.DefaultHost
+	opts.Port = util.GetUniquePort()
+	opts.Network = constants.DefaultNetwork
+	server, err := StartOptions(opts)
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -49,11 +52,12 @@ func BenchmarkSingleSet(b *testing.B) {
 	encoded := encode(b, batch)
 	server, stop := startUniqueServer(b)
 	defer stop()
+	sess := newSession()
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		server.eventHandler(nil, encoded)
+		server.handle(sess, encoded)
 	}
 	b.StopTimer()
 }
@@ -75,11 +79,12 @@ func BenchmarkBulkSet(b *testing.B) {
 	encoded := encode(b, batch)
 	server, stop := startUniqueServer(b)
 	defer stop()
+	sess := newSession()
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		server.eventHandler(nil, encoded)
+		server.handle(sess, encoded)
 	}
 	b.StopTimer()
 }
@@ -98,7 +103,8 @@ func BenchmarkSingleGet(b *testing.B) {
 	encodedSet := encode(b, batch)
 	server, stop := startUniqueServer(b)
 	defer stop()
-	server.eventHandler(nil, encodedSet)
+	sess := newSession()
+	server.handle(sess, encodedSet)
 
 	batchGet := protocol.BatchedRequest{
 		Operations: []protocol.Operation{
@@ -113,7 +119,7 @@ func BenchmarkSingleGet(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		server.eventHandler(nil, encodedGet)
+		server.handle(sess, encodedGet)
 	}
 	b.StopTimer()
 }
@@ -132,7 +138,8 @@ func BenchmarkBulkGet(b *testing.B) {
 	encodedSet := encode(b, setOperation)
 	server, stop := startUniqueServer(b)
 	defer stop()
-	server.eventHandler(nil, encodedSet)
+	sess := newSession()
+	server.handle(sess, encodedSet)
 
 	numSetOperations := constants.MaxRequestBatch
 	operations := make([]protocol.Operation, 0, numSetOperations)
@@ -150,7 +157,44 @@ func BenchmarkBulkGet(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		server.eventHandler(nil, encodedGet)
+		server.handle(sess, encodedGet)
 	}
 	b.StopTimer()
 }
+
+func BenchmarkParallelMultiLoopGet(b *testing.B) {
+	setOperation := protocol.BatchedRequest{
+		Operations: []protocol.Operation{
+			{
+				Type:  protocol.SET,
+				Key:   []byte("world"),
+				Value: []byte("hello"),
+			},
+		},
+	}
+
+	encodedSet := encode(b, setOperation)
+	server, stop := startUniqueServerOptions(b, Options{Loops: -1})
+	defer stop()
+	server.handle(newSession(), encodedSet)
+
+	batchGet := protocol.BatchedRequest{
+		Operations: []protocol.Operation{
+			{
+				Type: protocol.GET,
+				Key:  []byte("world"),
+			},
+		},
+	}
+
+	encodedGet := encode(b, batchGet)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		sess := newSession()
+		for pb.Next() {
+			server.handle(sess, encodedGet)
+		}
+	})
+	b.StopTimer()
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+)
+
+// parked holds a frame stopped at a blocking operation until it resumes.
+type parked struct {
+	index    int
+	key      []byte
+	deadline time.Time
+	out      []byte
+}
+
+type waiters struct {
+	mu   sync.Mutex
+	keys map[string]map[*session]time.Time
+}
+
+func (w *waiters) add(key []byte, sess *session, deadline time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.keys == nil {
+		w.keys = map[string]map[*session]time.Time{}
+	}
+
+	sessions, ok := w.keys[string(key)]
+	if !ok {
+		sessions = map[*session]time.Time{}
+		w.keys[string(key)] = sessions
+	}
+	sessions[sess] = deadline
+}
+
+func (w *waiters) remove(key []byte, sess *session) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sessions := w.keys[string(key)]
+	delete(sessions, sess)
+	if len(sessions) == 0 {
+		delete(w.keys, string(key))
+	}
+}
+
+func (w *waiters) notify(key []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for sess := range w.keys[string(key)] {
+		sess.conn.Wake()
+	}
+}
+
+func (w *waiters) wakeExpired(now time.Time) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var next time.Duration
+	for _, sessions := range w.keys {
+		for sess, deadline := range sessions {
+			wait := deadline.Sub(now)
+			if wait <= 0 {
+				sess.conn.Wake()
+			} else if next == 0 || wait < next {
+				next = wait
+			}
+		}
+	}
+	return next
+}
+
+func (s *Server) nextTick() time.Duration {
+	next := s.waiters.wakeExpired(time.Now())
+	if next == 0 || next > constants.ExpireInterval {
+		return constants.ExpireInterval
+	}
+
+	if next < time.Millisecond {
+		return time.Millisecond
+	}
+	return next
+}
+
+func (s *Server) blockingPop(sess *session, op protocol.Operation, index int) (protocol.Result, bool) {
+	res := s.processRequest(op)
+	if res.Status != protocol.NOT_FOUND {
+		s.unpark(sess)
+		return res, true
+	}
+
+	if sess.parked == nil {
+		deadline := time.Now().Add(millis(op.TTL))
+		sess.parked = &parked{index: index, key: append([]byte{}, op.Key...), deadline: deadline}
+		s.waiters.add(op.Key, sess, deadline)
+
+		// a push between the first pop and registering would never wake us
+		if res = s.processRequest(op); res.Status != protocol.NOT_FOUND {
+			s.unpark(sess)
+			return res, true
+		}
+		return res, false
+	}
+
+	if time.Now().Before(sess.parked.deadline) {
+		return res, false
+	}
+
+	s.unpark(sess)
+	res.Message = []byte(fmt.Sprintf(constants.BlockTimeoutErr, millis(op.TTL), op.Key))
+	return res, true
+}
+
+func (s *Server) unpark(sess *session) {
+	if sess.parked == nil {
+		return
+	}
+
+	s.waiters.remove(sess.parked.key, sess)
+	sess.parked = nil
+}
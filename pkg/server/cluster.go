@@ -0,0 +1,205 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/slots"
+	"github.com/kevindweb/cache/internal/storage"
+)
+
+const (
+	SlotNode      = slots.StateNode
+	SlotMigrating = slots.StateMigrating
+	SlotImporting = slots.StateImporting
+	SlotStable    = slots.StateStable
+)
+
+type SlotRange = slots.Range
+
+// cluster serves a migrating slot for keys that have not moved yet, and an
+// importing slot only to operations sent after ASKING.
+type cluster struct {
+	mu        sync.RWMutex
+	self      string
+	table     *slots.Table
+	migrating map[uint16]string
+	importing map[uint16]string
+	epoch     uint64
+}
+
+func newCluster(opts Options) (*cluster, error) {
+	if opts.ClusterAddr == "" {
+		return nil, nil
+	}
+
+	table, err := slots.NewTable(opts.ClusterSlots)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cluster{
+		self:      opts.ClusterAddr,
+		table:     table,
+		migrating: map[uint16]string{},
+		importing: map[uint16]string{},
+		epoch:     1,
+	}, nil
+}
+
+func (c *cluster) slot(slot uint16) (string, string, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.table[slot], c.migrating[slot], c.importing[slot]
+}
+
+func (s *Server) route(op protocol.Operation, asking bool) (protocol.Result, bool) {
+	keys := opKeys(op)
+	if len(keys) == 0 {
+		return protocol.Result{}, false
+	}
+
+	slot := slots.Slot(keys[0])
+	for _, key := range keys[1:] {
+		if slots.Slot(key) != slot {
+			return protocol.Result{
+				Status:  protocol.INVALID_ARGUMENT,
+				Message: []byte(fmt.Sprintf(constants.CrossSlotErr, op.Type)),
+			}, true
+		}
+	}
+
+	owner, migrating, importing := s.cluster.slot(slot)
+	switch {
+	case owner == s.cluster.self:
+		// keys missing here were moved already or are created on the target
+		if migrating != "" && !s.present(keys) {
+			return redirect(protocol.ASK, constants.AskErr, slot, keys[0], migrating), true
+		}
+		return protocol.Result{}, false
+	case importing != "" && asking:
+		return protocol.Result{}, false
+	case owner == "":
+		return protocol.Result{
+			Status:  protocol.FAILURE,
+			Message: []byte(fmt.Sprintf(constants.UnservedSlotErr, slot)),
+		}, true
+	default:
+		return redirect(protocol.MOVED, constants.MovedErr, slot, keys[0], owner), true
+	}
+}
+
+func redirect(status protocol.ResultStatus, format string, slot uint16, key []byte, addr string) protocol.Result {
+	return protocol.Result{
+		Status:  status,
+		Message: []byte(fmt.Sprintf(format, slot, key, addr)),
+		Version: uint64(slot),
+		Values:  [][]byte{[]byte(addr)},
+	}
+}
+
+func (s *Server) present(keys [][]byte) bool {
+	for _, key := range keys {
+		if _, err := s.kv.TTL(key); errors.Is(err, storage.ErrNotFound) {
+			return false
+		}
+	}
+	return true
+}
+
+func opKeys(op protocol.Operation) [][]byte {
+	switch op.Type {
+	case protocol.PING, protocol.SCAN, protocol.SAVE, protocol.REPLICATE, protocol.REPLICATION,
+		protocol.CLUSTERSLOTS, protocol.ASKING, protocol.SETSLOT:
+		return nil
+	case protocol.MGET, protocol.MSET, protocol.MDEL:
+		return op.Keys
+	case protocol.TX:
+		keys := append([][]byte{}, op.Keys...)
+		for _, txOp := range op.Ops {
+			keys = append(keys, opKeys(txOp)...)
+		}
+		return keys
+	default:
+		return [][]byte{op.Key}
+	}
+}
+
+func (s *Server) processClusterSlots() protocol.Result {
+	res := protocol.Result{}
+	if s.cluster == nil {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(constants.NotClusterErr)
+		return res
+	}
+
+	s.cluster.mu.RLock()
+	defer s.cluster.mu.RUnlock()
+	res.Values = slots.AppendRanges(nil, s.cluster.table.Ranges())
+	res.Version = s.cluster.epoch
+	return res
+}
+
+func (s *Server) setSlot(op protocol.Operation) protocol.Result {
+	res := protocol.Result{Message: s.ok}
+	if s.cluster == nil {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(constants.NotClusterErr)
+		return res
+	}
+
+	if op.Start < 0 || op.Start > op.Stop || op.Stop >= slots.Count {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(fmt.Sprintf(constants.InvalidSlotRangeErr, op.Start, op.Stop, slots.Count-1))
+		return res
+	}
+
+	state, addr := op.State, string(op.Value)
+	switch state {
+	case SlotNode, SlotMigrating, SlotImporting, SlotStable:
+	default:
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(fmt.Sprintf(constants.InvalidSlotStateErr, state))
+		return res
+	}
+
+	if state != SlotStable && addr == "" {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(fmt.Sprintf(constants.MissingSlotAddrErr, op.Start, op.Stop))
+		return res
+	}
+
+	c := s.cluster
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state == SlotMigrating {
+		for slot := op.Start; slot <= op.Stop; slot++ {
+			if c.table[slot] != c.self {
+				res.Status = protocol.INVALID_ARGUMENT
+				res.Message = []byte(fmt.Sprintf(constants.SlotNotOwnedErr, slot))
+				return res
+			}
+		}
+	}
+
+	for slot := uint16(op.Start); slot <= uint16(op.Stop); slot++ {
+		switch state {
+		case SlotNode:
+			c.table[slot] = addr
+			delete(c.migrating, slot)
+			delete(c.importing, slot)
+		case SlotMigrating:
+			c.migrating[slot] = addr
+		case SlotImporting:
+			c.importing[slot] = addr
+		default:
+			delete(c.migrating, slot)
+			delete(c.importing, slot)
+		}
+	}
+	c.epoch++
+	return res
+}
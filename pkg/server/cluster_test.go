@@ -0,0 +1,155 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/slots"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clusterServer(t *testing.T, self string) *Server {
+	t.Helper()
+	return logServer(t, Options{
+		ClusterAddr: self,
+		ClusterSlots: []SlotRange{
+			{Start: 0, End: 8191, Addr: "n1:1"},
+			{Start: 8192, End: 14999, Addr: "n2:2"},
+		},
+	})
+}
+
+func setSlot(start, stop int64, state, addr string) protocol.Operation {
+	return protocol.Operation{
+		Type: protocol.SETSLOT, Start: start, Stop: stop, State: state, Value: []byte(addr),
+	}
+}
+
+func TestClusterRoute(t *testing.T) {
+	t.Parallel()
+	server := clusterServer(t, "n1:1")
+	results := handleAll(t, server,
+		set("bar", "val"),
+		get("bar"),
+		get("foo"),
+		get("a"),
+		protocol.Operation{Type: protocol.MGET, Keys: [][]byte{[]byte("bar"), []byte("foo")}},
+		protocol.Operation{Type: protocol.MGET, Keys: [][]byte{[]byte("{user}1"), []byte("{user}2")}},
+		protocol.Operation{Type: protocol.PING},
+	)
+	assert.Equal(t, protocol.SUCCESS, results[0].Status)
+	assert.Equal(t, "val", string(results[1].Message))
+
+	assert.Equal(t, protocol.MOVED, results[2].Status)
+	assert.Equal(t, uint64(slots.Slot([]byte("foo"))), results[2].Version)
+	assert.Equal(t, [][]byte{[]byte("n2:2")}, results[2].Values)
+
+	assert.Equal(t, protocol.FAILURE, results[3].Status)
+	assert.Equal(t, protocol.INVALID_ARGUMENT, results[4].Status)
+	assert.Equal(t, protocol.SUCCESS, results[5].Status)
+	assert.Equal(t, protocol.SUCCESS, results[6].Status)
+}
+
+func TestClusterMigration(t *testing.T) {
+	t.Parallel()
+	slot := int64(slots.Slot([]byte("bar")))
+	source, target := clusterServer(t, "n1:1"), clusterServer(t, "n2:2")
+
+	results := handleAll(t, source,
+		set("bar", "val"),
+		setSlot(slot, slot, SlotMigrating, "n2:2"),
+		get("bar"),
+		get("{bar}new"),
+	)
+	assert.Equal(t, protocol.SUCCESS, results[1].Status)
+	assert.Equal(t, "val", string(results[2].Message))
+	assert.Equal(t, protocol.ASK, results[3].Status)
+	assert.Equal(t, [][]byte{[]byte("n2:2")}, results[3].Values)
+
+	results = handleAll(t, target,
+		setSlot(slot, slot, SlotImporting, "n1:1"),
+		get("{bar}new"),
+		protocol.Operation{Type: protocol.ASKING},
+		set("{bar}new", "val"),
+		get("{bar}new"),
+	)
+	assert.Equal(t, protocol.MOVED, results[1].Status)
+	assert.Equal(t, protocol.SUCCESS, results[3].Status)
+	assert.Equal(t, protocol.MOVED, results[4].Status)
+
+	for _, server := range []*Server{source, target} {
+		results = handleAll(t, server, setSlot(slot, slot, SlotNode, "n2:2"), get("{bar}new"))
+		assert.Equal(t, protocol.SUCCESS, results[0].Status)
+	}
+	assert.Equal(t, protocol.MOVED, handleAll(t, source, get("bar"))[0].Status)
+	assert.Equal(t, "val", string(handleAll(t, target, get("{bar}new"))[0].Message))
+}
+
+func TestClusterSlots(t *testing.T) {
+	t.Parallel()
+	server := clusterServer(t, "n1:1")
+	res := server.processClusterSlots()
+	require.Equal(t, protocol.SUCCESS, res.Status)
+	ranges, err := slots.ParseRanges(res.Values)
+	require.NoError(t, err)
+	assert.Equal(t, []SlotRange{
+		{Start: 0, End: 8191, Addr: "n1:1"},
+		{Start: 8192, End: 14999, Addr: "n2:2"},
+	}, ranges)
+
+	results := handleAll(t, server,
+		setSlot(15000, 16383, SlotNode, "n3:3"),
+		setSlot(0, 10, "unknown", "n3:3"),
+		setSlot(8192, 8192, SlotMigrating, "n3:3"),
+		setSlot(0, slots.Count, SlotNode, "n3:3"),
+		setSlot(0, 0, SlotNode, ""),
+	)
+	assert.Equal(t, protocol.SUCCESS, results[0].Status)
+	for _, res := range results[1:] {
+		assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	}
+
+	next := server.processClusterSlots()
+	assert.Equal(t, res.Version+1, next.Version)
+	assert.Len(t, next.Values, 9)
+
+	standalone := logServer(t, Options{})
+	results = handleAll(t, standalone, protocol.Operation{Type: protocol.CLUSTERSLOTS}, setSlot(0, 0, SlotNode, "n1:1"))
+	assert.Equal(t, constants.NotClusterErr, string(results[0].Message))
+	assert.Equal(t, constants.NotClusterErr, string(results[1].Message))
+}
+
+func TestSetSlotNotReplicated(t *testing.T) {
+	t.Parallel()
+	server := clusterServer(t, "n1:1")
+	conn := &wakeConn{}
+	sess := newSession()
+	sess.conn = conn
+	conn.SetContext(sess)
+	server.handle(sess, frame(t, protocol.Operation{Type: protocol.REPLICATE}))
+	_, _, ops := syncReplica(t, server, sess)
+	assert.Empty(t, ops)
+	conn.wakes.Store(0)
+
+	// every node keeps its own slot map, replicas included
+	results := handleAll(t, server, setSlot(15000, 16383, SlotNode, "n3:3"))
+	assert.Equal(t, protocol.SUCCESS, results[0].Status)
+	assert.Zero(t, conn.wakes.Load())
+	server.closed(conn, nil)
+}
+
+func TestClusterOptions(t *testing.T) {
+	t.Parallel()
+	_, err := New(Options{ClusterSlots: []SlotRange{{Start: 0, End: 1, Addr: "n1:1"}}})
+	assert.Error(t, err)
+	_, err = New(Options{ClusterAddr: "n1:1", ReplicaOf: "localhost:1"})
+	assert.Error(t, err)
+	_, err = New(Options{ClusterAddr: "n1:1", ClusterSlots: []SlotRange{
+		{Start: 0, End: 10, Addr: "n1:1"},
+		{Start: 10, End: 20, Addr: "n2:2"},
+	}})
+	assert.Error(t, err)
+}
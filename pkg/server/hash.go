@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/storage"
+)
+
+func (s *Server) processHash(op protocol.Operation) protocol.Result {
+	res := protocol.Result{}
+	switch op.Type {
+	case protocol.HSET:
+		if len(op.Keys) == 0 || len(op.Keys) != len(op.Values) {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.MismatchedFieldsErr, len(op.Keys), len(op.Values)))
+			return res
+		}
+
+		added, version, err := storage.HSet(s.kv, op.Key, op.Keys, op.Values)
+		handleOperationResult(&res, []byte(strconv.Itoa(added)), err)
+		res.Version = version
+	case protocol.HGET:
+		val, err := storage.HGet(s.kv, op.Key, op.Member)
+		handleOperationResult(&res, val, err)
+	case protocol.HDEL:
+		removed, err := storage.HDel(s.kv, op.Key, op.Keys)
+		handleOperationResult(&res, []byte(strconv.Itoa(removed)), err)
+	case protocol.HGETALL:
+		fields, values, err := storage.HGetAll(s.kv, op.Key)
+		handleOperationResult(&res, s.ok, err)
+		for i := range fields {
+			res.Values = append(res.Values, fields[i], values[i])
+		}
+	default:
+		res.Status = protocol.UNKNOWN_OPERATION
+		res.Message = []byte(fmt.Sprintf(constants.UndefinedOpErr, op.Type))
+	}
+	return res
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hset(key string, fieldValues ...string) protocol.Operation {
+	op := protocol.Operation{Type: protocol.HSET, Key: []byte(key)}
+	for i := 0; i < len(fieldValues); i += 2 {
+		op.Keys = append(op.Keys, []byte(fieldValues[i]))
+		op.Values = append(op.Values, []byte(fieldValues[i+1]))
+	}
+	return op
+}
+
+func TestProcessHash(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(hset("user", "name", "ada", "age", "36"))
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, "2", string(res.Message))
+	assert.NotZero(t, res.Version)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.HGET, Key: []byte("user"), Member: []byte("age")})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, "36", string(res.Message))
+
+	res = server.processRequest(protocol.Operation{Type: protocol.HGET, Key: []byte("user"), Member: []byte("city")})
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.HGETALL, Key: []byte("user")})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, [][]byte{[]byte("age"), []byte("36"), []byte("name"), []byte("ada")}, res.Values)
+
+	res = server.processRequest(protocol.Operation{
+		Type: protocol.HDEL,
+		Key:  []byte("user"),
+		Keys: [][]byte{[]byte("age"), []byte("city")},
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, "1", string(res.Message))
+
+	res = server.processRequest(get("user"))
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+	res = server.processRequest(set("str", "v"))
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	res = server.processRequest(hset("str", "f", "v"))
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+
+	res = server.processRequest(protocol.Operation{
+		Type:   protocol.HSET,
+		Key:    []byte("user"),
+		Keys:   [][]byte{[]byte("f")},
+		Values: nil,
+	})
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+}
+
+func TestTransactionRollsBackHash(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(hset("user", "name", "ada"))
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.NoError(t, server.kv.Expire([]byte("user"), time.Minute))
+
+	res = server.processRequest(protocol.Operation{
+		Type: protocol.TX,
+		Ops: []protocol.Operation{
+			// the field is not a key, so it must not be rolled back as one
+			set("name", "key"),
+			hset("user", "name", "grace", "age", "45"),
+			set("user", "overwritten"),
+			{Type: protocol.INCR, Key: []byte("user")},
+			{Type: protocol.INCR, Key: []byte("name")},
+		},
+	})
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.HGETALL, Key: []byte("user")})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, [][]byte{[]byte("name"), []byte("ada")}, res.Values)
+	ttl, err := server.kv.TTL([]byte("user"))
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+
+	res = server.processRequest(get("name"))
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/storage"
+)
+
+func (s *Server) processList(op protocol.Operation) protocol.Result {
+	res := protocol.Result{}
+	switch op.Type {
+	case protocol.LPUSH, protocol.RPUSH:
+		if len(op.Values) == 0 {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.EmptyPushErr, op.Key))
+			return res
+		}
+
+		push := storage.LPush
+		if op.Type == protocol.RPUSH {
+			push = storage.RPush
+		}
+
+		n, err := push(s.kv, op.Key, op.Values)
+		handleOperationResult(&res, []byte(strconv.Itoa(n)), err)
+		if err == nil {
+			s.waiters.notify(op.Key)
+		}
+	case protocol.LPOP, protocol.RPOP, protocol.BLPOP, protocol.BRPOP:
+		if op.Type.Blocking() && op.TTL <= 0 {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.InvalidBlockTimeoutErr, op.TTL))
+			return res
+		}
+
+		pop := storage.LPop
+		if op.Type == protocol.RPOP || op.Type == protocol.BRPOP {
+			pop = storage.RPop
+		}
+
+		val, err := pop(s.kv, op.Key)
+		handleOperationResult(&res, val, err)
+	case protocol.LRANGE:
+		values, err := storage.LRange(s.kv, op.Key, op.Start, op.Stop)
+		handleOperationResult(&res, s.ok, err)
+		res.Values = values
+	case protocol.LLEN:
+		n, err := storage.LLen(s.kv, op.Key)
+		handleOperationResult(&res, []byte(strconv.Itoa(n)), err)
+	default:
+		res.Status = protocol.UNKNOWN_OPERATION
+		res.Message = []byte(fmt.Sprintf(constants.UndefinedOpErr, op.Type))
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/evio"
+)
+
+// wakeConn counts wakes in place of an event loop connection.
+type wakeConn struct {
+	ctx   any
+	wakes atomic.Int32
+}
+
+func (c *wakeConn) Context() any         { return c.ctx }
+func (c *wakeConn) SetContext(ctx any)   { c.ctx = ctx }
+func (c *wakeConn) AddrIndex() int       { return 0 }
+func (c *wakeConn) LocalAddr() net.Addr  { return nil }
+func (c *wakeConn) RemoteAddr() net.Addr { return nil }
+func (c *wakeConn) Wake()                { c.wakes.Add(1) }
+
+func push(typ protocol.OperationType, key string, values ...string) protocol.Operation {
+	op := protocol.Operation{Type: typ, Key: []byte(key)}
+	for _, value := range values {
+		op.Values = append(op.Values, []byte(value))
+	}
+	return op
+}
+
+func blpop(key string, timeout time.Duration) protocol.Operation {
+	return protocol.Operation{Type: protocol.BLPOP, Key: []byte(key), TTL: timeout.Milliseconds()}
+}
+
+func TestProcessList(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(push(protocol.RPUSH, "q", "b", "c"))
+	assert.Equal(t, "2", string(res.Message))
+	res = server.processRequest(push(protocol.LPUSH, "q", "a"))
+	assert.Equal(t, "3", string(res.Message))
+
+	res = server.processRequest(protocol.Operation{Type: protocol.LRANGE, Key: []byte("q"), Start: 1, Stop: -1})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, [][]byte{[]byte("b"), []byte("c")}, res.Values)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.RPOP, Key: []byte("q")})
+	assert.Equal(t, "c", string(res.Message))
+	res = server.processRequest(protocol.Operation{Type: protocol.LLEN, Key: []byte("q")})
+	assert.Equal(t, "2", string(res.Message))
+
+	res = server.processRequest(push(protocol.RPUSH, "q"))
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	res = server.processRequest(blpop("q", 0))
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	res = server.processRequest(protocol.Operation{Type: protocol.LPOP, Key: []byte("missing")})
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+	res = server.processRequest(get("q"))
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+}
+
+func TestBlockingPopParks(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	waiting, pusher := newSession(), newSession()
+	conn := &wakeConn{}
+	waiting.conn, pusher.conn = conn, &wakeConn{}
+
+	// the pop parks mid frame, holding back the frame read after it
+	reads := append(frame(t, get("q"), blpop("q", time.Minute), get("after")), frame(t, set("after", "1"))...)
+	out, action := server.handle(waiting, append(frame(t, set("before", "1")), reads...))
+	assert.Equal(t, evio.None, action)
+	assert.Empty(t, out)
+	assert.NotNil(t, waiting.parked)
+
+	// a spurious wake keeps waiting
+	out, _ = server.handle(waiting, nil)
+	assert.Empty(t, out)
+
+	out, _ = server.handle(pusher, frame(t, push(protocol.RPUSH, "q", "job")))
+	assert.Len(t, readResponses(t, out), 1)
+	assert.Equal(t, int32(1), conn.wakes.Load())
+
+	out, _ = server.handle(waiting, nil)
+	responses := readResponses(t, out)
+	assert.Len(t, responses, 3)
+	assert.Equal(t, protocol.SUCCESS, responses[0].Results[0].Status)
+	assert.Equal(t, protocol.NOT_FOUND, responses[1].Results[0].Status)
+	assert.Equal(t, "job", string(responses[1].Results[1].Message))
+	assert.Equal(t, protocol.NOT_FOUND, responses[1].Results[2].Status)
+	assert.Equal(t, protocol.SUCCESS, responses[2].Results[0].Status)
+	assert.Nil(t, waiting.parked)
+	assert.Empty(t, server.waiters.keys)
+}
+
+func TestBlockingPopTimesOut(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	sess := newSession()
+	conn := &wakeConn{}
+	sess.conn = conn
+
+	out, _ := server.handle(sess, frame(t, blpop("q", 5*time.Millisecond)))
+	assert.Empty(t, out)
+	next := server.nextTick()
+	assert.Greater(t, next, time.Duration(0))
+	assert.LessOrEqual(t, next, 5*time.Millisecond, "the tick follows the deadline")
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, constants.ExpireInterval, server.nextTick())
+	assert.Equal(t, int32(1), conn.wakes.Load())
+
+	out, _ = server.handle(sess, nil)
+	responses := readResponses(t, out)
+	assert.Len(t, responses, 1)
+	assert.Equal(t, protocol.NOT_FOUND, responses[0].Results[0].Status)
+	assert.Contains(t, string(responses[0].Results[0].Message), "timed out")
+	assert.Empty(t, server.waiters.keys)
+}
+
+func TestBlockingPopClosedWhileParked(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	sess := newSession()
+	conn := &wakeConn{ctx: sess}
+	sess.conn = conn
+	out, _ := server.handle(sess, frame(t, blpop("q", time.Minute)))
+	assert.Empty(t, out)
+	assert.Len(t, server.waiters.keys, 1)
+
+	server.closed(conn, nil)
+	assert.Empty(t, server.waiters.keys)
+	server.processRequest(push(protocol.RPUSH, "q", "job"))
+	res := server.processRequest(protocol.Operation{Type: protocol.LPOP, Key: []byte("q")})
+	assert.Equal(t, "job", string(res.Message), "a closed waiter pops nothing")
+}
@@ -0,0 +1,516 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/snapshot"
+	"github.com/kevindweb/cache/internal/storage"
+
+	"github.com/tidwall/evio"
+)
+
+const (
+	RoleLeader  = "leader"
+	RoleReplica = "replica"
+)
+
+type replica struct {
+	mu       sync.Mutex
+	conn     evio.Conn
+	out      []byte
+	snapshot []byte
+	syncing  bool
+	dropped  bool
+}
+
+func (r *replica) drain(sess *session) ([]byte, evio.Action) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dropped {
+		return nil, evio.Close
+	}
+
+	if r.syncing {
+		return nil, evio.None
+	}
+
+	out, err := appendSnapshot(sess.outBuffer[:0], r.snapshot)
+	if err != nil {
+		return nil, evio.Close
+	}
+
+	sess.outBuffer = append(out, r.out...)
+	r.out, r.snapshot = r.out[:0], nil
+	return sess.outBuffer, evio.None
+}
+
+func (r *replica) loaded(data []byte, err error) {
+	r.mu.Lock()
+	r.syncing, r.snapshot = false, data
+	if err != nil {
+		r.dropped, r.out = true, nil
+	}
+	r.mu.Unlock()
+	r.conn.Wake()
+}
+
+// appendSnapshot splits data into REPLICATE chunks so no frame outgrows
+// MaxFrameSize.
+func appendSnapshot(out []byte, data []byte) ([]byte, error) {
+	var payload []byte
+	for sent := 0; sent < len(data); sent += constants.ReplicaChunkSize {
+		chunk := data[sent:]
+		if len(chunk) > constants.ReplicaChunkSize {
+			chunk = chunk[:constants.ReplicaChunkSize]
+		}
+
+		batch := protocol.BatchedRequest{Operations: []protocol.Operation{{
+			Type:  protocol.REPLICATE,
+			Value: chunk,
+			Count: int64(len(data)),
+		}}}
+
+		var err error
+		if payload, err = batch.MarshalMsg(payload[:0]); err != nil {
+			return nil, err
+		}
+		out = protocol.AppendFrame(out, payload)
+	}
+	return out, nil
+}
+
+type replicas struct {
+	mu       sync.Mutex
+	conns    map[*replica]struct{}
+	count    atomic.Int32
+	offset   uint64
+	lastSent time.Time
+	frame    []byte
+}
+
+func (r *replicas) active() bool {
+	return r.count.Load() > 0
+}
+
+func (r *replicas) add(rep *replica) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conns == nil {
+		r.conns = map[*replica]struct{}{}
+	}
+
+	r.conns[rep] = struct{}{}
+	r.count.Store(int32(len(r.conns)))
+	return r.offset
+}
+
+func (r *replicas) remove(rep *replica) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.conns, rep)
+	r.count.Store(int32(len(r.conns)))
+}
+
+func (r *replicas) send(ops []protocol.Operation, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.conns) == 0 {
+		return nil
+	}
+
+	r.offset += uint64(len(ops))
+	batch := protocol.BatchedRequest{Operations: []protocol.Operation{{
+		Type:    protocol.REPLICATE,
+		Ops:     ops,
+		Version: r.offset,
+		SentAt:  now.UnixMilli(),
+	}}}
+
+	payload, err := batch.MarshalMsg(r.frame[:0])
+	if err != nil {
+		return err
+	}
+	r.frame = payload
+	r.lastSent = now
+
+	for rep := range r.conns {
+		rep.mu.Lock()
+		if !rep.dropped {
+			rep.out = protocol.AppendFrame(rep.out, payload)
+			if len(rep.out) > constants.MaxReplicaBacklog {
+				rep.dropped, rep.out = true, nil
+			}
+		}
+		rep.mu.Unlock()
+		rep.conn.Wake()
+	}
+	return nil
+}
+
+func (r *replicas) heartbeat(now time.Time) error {
+	r.mu.Lock()
+	due := len(r.conns) > 0 && now.Sub(r.lastSent) >= constants.ReplicaHeartbeat
+	r.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return r.send(nil, now)
+}
+
+func (r *replicas) status() (uint64, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.offset, len(r.conns)
+}
+
+// attachReplica copies the keyspace while running alone, so no write lands
+// both in the snapshot and in the stream.
+func (s *Server) attachReplica(sess *session) protocol.Result {
+	res := protocol.Result{}
+	if s.follower != nil {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(constants.ReplicaChainErr)
+		return res
+	}
+
+	snap, err := snapshot.Take(s.kv)
+	if err != nil {
+		handleOperationResult(&res, nil, err)
+		return res
+	}
+
+	rep := &replica{conn: sess.conn, syncing: true}
+	sess.replica = rep
+	res.Message = s.ok
+	res.Version = s.replicas.add(rep)
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		data, err := snap.Encode()
+		if err != nil {
+			s.logger.Printf("encoding replica snapshot: %v", err)
+		}
+		rep.loaded(data, err)
+	}()
+	return res
+}
+
+func (s *Server) detachReplica(sess *session) {
+	if sess.replica == nil {
+		return
+	}
+
+	sess.replica.mu.Lock()
+	if sess.replica.dropped {
+		s.logger.Printf(constants.ReplicaDroppedErr, constants.MaxReplicaBacklog)
+	}
+	sess.replica.mu.Unlock()
+	s.replicas.remove(sess.replica)
+	sess.replica = nil
+}
+
+type follower struct {
+	leader   string
+	network  string
+	mu       sync.Mutex
+	conn     net.Conn
+	done     chan struct{}
+	stopOnce sync.Once
+	readBuf  []byte
+	offset   atomic.Uint64
+	sent     atomic.Int64
+	synced   atomic.Bool
+}
+
+func newFollower(opts Options) *follower {
+	if opts.ReplicaOf == "" {
+		return nil
+	}
+
+	return &follower{
+		leader:  opts.ReplicaOf,
+		network: opts.Network,
+		done:    make(chan struct{}),
+	}
+}
+
+func (f *follower) setConnection(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	select {
+	case <-f.done:
+		return false
+	default:
+	}
+
+	f.conn = conn
+	return true
+}
+
+func (f *follower) stop() {
+	f.stopOnce.Do(func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		close(f.done)
+		if f.conn != nil {
+			f.conn.Close()
+		}
+	})
+}
+
+func (s *Server) follow() {
+	defer s.background.Done()
+	f := s.follower
+	wait := constants.ConnRetryWait
+	for {
+		synced, err := s.sync()
+		f.synced.Store(false)
+		select {
+		case <-f.done:
+			return
+		default:
+		}
+
+		s.logger.Printf(constants.ReplicaSyncErr, f.leader, err)
+		if synced {
+			wait = constants.ConnRetryWait
+		}
+
+		select {
+		case <-f.done:
+			return
+		case <-time.After(wait):
+		}
+
+		wait *= 2
+		if wait > constants.ReconnectMaxWait {
+			wait = constants.ReconnectMaxWait
+		}
+	}
+}
+
+func (s *Server) sync() (bool, error) {
+	f := s.follower
+	conn, err := net.DialTimeout(f.network, f.leader, constants.DialTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if !f.setConnection(conn) {
+		return false, net.ErrClosed
+	}
+
+	request := protocol.BatchedRequest{Operations: []protocol.Operation{{Type: protocol.REPLICATE}}}
+	payload, err := request.MarshalMsg(nil)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = conn.Write(protocol.AppendFrame(nil, payload)); err != nil {
+		return false, err
+	}
+
+	if err = s.loadLeaderSnapshot(conn); err != nil {
+		return false, err
+	}
+
+	for {
+		frame, err := f.readFrame(conn)
+		if err != nil {
+			return true, err
+		}
+
+		if err = s.applyStream(frame); err != nil {
+			return true, err
+		}
+	}
+}
+
+func (s *Server) loadLeaderSnapshot(conn net.Conn) error {
+	f := s.follower
+	frame, err := f.readFrame(conn)
+	if err != nil {
+		return err
+	}
+
+	var response protocol.BatchedResponse
+	if _, err = response.UnmarshalMsg(frame); err != nil {
+		return err
+	}
+
+	if len(response.Results) != 1 || response.Results[0].Status != protocol.SUCCESS {
+		status, message := protocol.FAILURE, []byte{}
+		if len(response.Results) > 0 {
+			status, message = response.Results[0].Status, response.Results[0].Message
+		}
+		return fmt.Errorf(constants.UnexpectedSyncErr, status, message)
+	}
+
+	data, err := f.readSnapshot(conn)
+	if err != nil {
+		return err
+	}
+
+	s.txLock.Lock()
+	defer s.txLock.Unlock()
+	if err = s.clear(); err != nil {
+		return err
+	}
+
+	if _, err = snapshot.Decode(data, s.kv); err != nil {
+		return err
+	}
+
+	f.offset.Store(response.Results[0].Version)
+	f.sent.Store(time.Now().UnixMilli())
+	f.synced.Store(true)
+	return nil
+}
+
+// applyStream only logs failures, the writes already succeeded on the leader.
+func (s *Server) applyStream(frame []byte) error {
+	f := s.follower
+	var batch protocol.BatchedRequest
+	if _, err := batch.UnmarshalMsg(frame); err != nil {
+		return err
+	}
+
+	s.txLock.Lock()
+	defer s.txLock.Unlock()
+	for _, stream := range batch.Operations {
+		if stream.Type != protocol.REPLICATE {
+			return fmt.Errorf(constants.UnexpectedStreamErr, stream.Type)
+		}
+
+		for _, op := range stream.Ops {
+			if res := s.processRequest(op); failed(res) {
+				s.logger.Printf("replicating %s on key %s: %s", op.Type, op.Key, res.Message)
+			}
+		}
+		f.offset.Store(stream.Version)
+		f.sent.Store(stream.SentAt)
+	}
+	return nil
+}
+
+func (s *Server) clear() error {
+	var keys [][]byte
+	s.kv.Range(func(key []byte, _ []byte, _ storage.Object, _ time.Duration) bool {
+		keys = append(keys, append([]byte{}, key...))
+		return true
+	})
+
+	for _, key := range keys {
+		if err := s.kv.Del(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *follower) readSnapshot(conn net.Conn) ([]byte, error) {
+	var data []byte
+	for {
+		frame, err := f.readFrame(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		var batch protocol.BatchedRequest
+		if _, err = batch.UnmarshalMsg(frame); err != nil {
+			return nil, err
+		}
+
+		if len(batch.Operations) != 1 {
+			return nil, fmt.Errorf(constants.SnapshotChunkErr, len(batch.Operations))
+		}
+
+		chunk := batch.Operations[0]
+		if chunk.Type != protocol.REPLICATE {
+			return nil, fmt.Errorf(constants.UnexpectedStreamErr, chunk.Type)
+		}
+
+		data = append(data, chunk.Value...)
+		if int64(len(data)) >= chunk.Count {
+			return data, nil
+		}
+	}
+}
+
+func (f *follower) readFrame(conn net.Conn) ([]byte, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(constants.ReplicaTimeout)); err != nil {
+		return nil, err
+	}
+
+	f.readBuf = grow(f.readBuf, constants.HeaderSize)
+	if _, err := io.ReadFull(conn, f.readBuf); err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(f.readBuf)
+	if length > constants.MaxFrameSize {
+		return nil, fmt.Errorf(constants.FrameTooLargeErr, length, constants.MaxFrameSize)
+	}
+
+	f.readBuf = grow(f.readBuf, int(length))
+	if _, err := io.ReadFull(conn, f.readBuf); err != nil {
+		return nil, err
+	}
+	return f.readBuf, nil
+}
+
+func grow(buf []byte, size int) []byte {
+	if cap(buf) < size {
+		return make([]byte, size)
+	}
+	return buf[:size]
+}
+
+func (s *Server) processReplication() protocol.Result {
+	if s.follower == nil {
+		offset, count := s.replicas.status()
+		return protocol.Result{
+			Message: []byte(RoleLeader),
+			Version: offset,
+			Values:  [][]byte{[]byte("replicas"), []byte(strconv.Itoa(count))},
+		}
+	}
+
+	f := s.follower
+	res := protocol.Result{
+		Message: []byte(RoleReplica),
+		Version: f.offset.Load(),
+		Values: [][]byte{
+			[]byte("leader"), []byte(f.leader),
+			[]byte("connected"), []byte(strconv.FormatBool(f.synced.Load())),
+		},
+	}
+
+	if sent := f.sent.Load(); sent > 0 {
+		lag := time.Now().UnixMilli() - sent
+		if lag < 0 {
+			lag = 0
+		}
+		res.Values = append(res.Values, []byte("lag"), []byte(strconv.FormatInt(lag, 10)))
+	}
+	return res
+}
+
+func readOnly(op protocol.Operation) protocol.Result {
+	return protocol.Result{
+		Status:  protocol.READ_ONLY,
+		Message: []byte(fmt.Sprintf(constants.ReadOnlyErr, op.Type)),
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/snapshot"
+	"github.com/kevindweb/cache/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/evio"
+)
+
+// stream decodes the replication frames queued for a replica session.
+func stream(t *testing.T, server *Server, sess *session) []protocol.Operation {
+	t.Helper()
+	out, action := server.eventHandler(sess.conn, nil)
+	assert.Equal(t, evio.None, action)
+
+	var ops []protocol.Operation
+	for len(out) > 0 {
+		payload, ok, err := protocol.NextFrame(out)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var batch protocol.BatchedRequest
+		_, err = batch.UnmarshalMsg(payload)
+		require.NoError(t, err)
+		ops = append(ops, batch.Operations...)
+		out = out[constants.HeaderSize+len(payload):]
+	}
+	return ops
+}
+
+// syncReplica waits for the snapshot queued for a replica session and
+// decodes its chunks, returning how many there were and the stream frames
+// queued behind it.
+func syncReplica(t *testing.T, server *Server, sess *session) (storage.KeyValue, int, []protocol.Operation) {
+	t.Helper()
+	rep := sess.replica
+	require.Eventually(t, func() bool {
+		rep.mu.Lock()
+		defer rep.mu.Unlock()
+		return !rep.syncing
+	}, time.Second, time.Millisecond)
+
+	var (
+		data   []byte
+		chunks []protocol.Operation
+	)
+	ops := stream(t, server, sess)
+	for len(ops) > 0 && ops[0].Count > 0 {
+		chunks = append(chunks, ops[0])
+		data = append(data, ops[0].Value...)
+		ops = ops[1:]
+	}
+	for _, chunk := range chunks {
+		assert.Equal(t, protocol.REPLICATE, chunk.Type)
+		assert.Equal(t, int64(len(data)), chunk.Count)
+		assert.LessOrEqual(t, len(chunk.Value), constants.ReplicaChunkSize)
+	}
+
+	kv := storage.NewCacheMap()
+	_, err := snapshot.Decode(data, kv)
+	require.NoError(t, err)
+	return kv, len(chunks), ops
+}
+
+func TestAttachReplica(t *testing.T) {
+	t.Parallel()
+	server := logServer(t, Options{})
+	server.processRequest(set("key", "val"))
+
+	conn := &wakeConn{}
+	sess := newSession()
+	sess.conn = conn
+	conn.SetContext(sess)
+	out, _ := server.handle(sess, frame(t, protocol.Operation{Type: protocol.REPLICATE}))
+	responses := readResponses(t, out)
+	require.Len(t, responses, 1)
+
+	res := responses[0].Results[0]
+	require.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, uint64(0), res.Version)
+
+	// writes after the snapshot was taken are streamed behind it
+	handleAll(t, server, set("late", "val"))
+	kv, chunks, ops := syncReplica(t, server, sess)
+	assert.Equal(t, 1, chunks)
+	val, err := kv.Get([]byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, "val", string(val))
+	_, err = kv.Get([]byte("late"))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	require.Len(t, ops, 1)
+	assert.Equal(t, []protocol.Operation{set("late", "val")}, ops[0].Ops)
+	conn.wakes.Store(0)
+
+	// reads are not streamed, conditional writes replay unconditionally
+	handleAll(t, server,
+		get("key"),
+		protocol.Operation{Type: protocol.SETNX, Key: []byte("lock"), Value: []byte("held")},
+		protocol.Operation{Type: protocol.SETNX, Key: []byte("lock"), Value: []byte("again")},
+	)
+	assert.Equal(t, int32(1), conn.wakes.Load())
+	ops = stream(t, server, sess)
+	require.Len(t, ops, 1)
+	assert.Equal(t, protocol.REPLICATE, ops[0].Type)
+	assert.Equal(t, uint64(2), ops[0].Version)
+	assert.Equal(t, []protocol.Operation{
+		{Type: protocol.SET, Key: []byte("lock"), Value: []byte("held")},
+	}, ops[0].Ops)
+
+	require.NoError(t, server.replicas.heartbeat(time.Now().Add(time.Second)))
+	ops = stream(t, server, sess)
+	require.Len(t, ops, 1)
+	assert.Empty(t, ops[0].Ops)
+	assert.Equal(t, uint64(2), ops[0].Version)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.REPLICATION})
+	assert.Equal(t, RoleLeader, string(res.Message))
+	assert.Equal(t, [][]byte{[]byte("replicas"), []byte("1")}, res.Values)
+
+	server.closed(conn, nil)
+	assert.False(t, server.replicas.active())
+}
+
+func TestAttachReplicaChunksSnapshot(t *testing.T) {
+	t.Parallel()
+	server := logServer(t, Options{})
+	value := string(make([]byte, constants.ReplicaChunkSize))
+	for i := 0; i < 3; i++ {
+		server.processRequest(set("key"+strconv.Itoa(i), value))
+	}
+
+	conn := &wakeConn{}
+	sess := newSession()
+	sess.conn = conn
+	conn.SetContext(sess)
+	server.handle(sess, frame(t, protocol.Operation{Type: protocol.REPLICATE}))
+	kv, chunks, _ := syncReplica(t, server, sess)
+	assert.Equal(t, 4, chunks)
+	val, err := kv.Get([]byte("key2"))
+	require.NoError(t, err)
+	assert.Equal(t, value, string(val))
+	server.closed(conn, nil)
+}
+
+func TestReplicaReceivesEvictions(t *testing.T) {
+	t.Parallel()
+	// every key and value is two bytes, so three keys fit in 12 bytes
+	server := logServer(t, Options{MaxMemory: 12, Eviction: LRU})
+	handleAll(t, server, set("k1", "v1"), set("k2", "v2"), set("k3", "v3"))
+
+	conn := &wakeConn{}
+	sess := newSession()
+	sess.conn = conn
+	conn.SetContext(sess)
+	server.handle(sess, frame(t, protocol.Operation{Type: protocol.REPLICATE}))
+	syncReplica(t, server, sess)
+
+	handleAll(t, server, set("k4", "v4"))
+	ops := stream(t, server, sess)
+	require.Len(t, ops, 1)
+	assert.Equal(t, []protocol.Operation{
+		set("k4", "v4"),
+		{Type: protocol.DELETE, Key: []byte("k1")},
+	}, ops[0].Ops)
+
+	// k2 is evicted and written again within the transaction, only k3 stays gone
+	tx := protocol.Operation{Type: protocol.TX, Ops: []protocol.Operation{set("k5", "v5"), set("k2", "v2")}}
+	handleAll(t, server, tx)
+	ops = stream(t, server, sess)
+	require.Len(t, ops, 1)
+	assert.Equal(t, []protocol.Operation{
+		tx,
+		{Type: protocol.DELETE, Key: []byte("k3")},
+	}, ops[0].Ops)
+	server.closed(conn, nil)
+}
+
+func TestReplicaDroppedWhenBehind(t *testing.T) {
+	t.Parallel()
+	server := logServer(t, Options{})
+	conn := &wakeConn{}
+	sess := newSession()
+	sess.conn = conn
+	conn.SetContext(sess)
+	server.handle(sess, frame(t, protocol.Operation{Type: protocol.REPLICATE}))
+
+	value := make([]byte, 1<<20)
+	for i := 0; i < 65; i++ {
+		handleAll(t, server, protocol.Operation{Type: protocol.SET, Key: []byte("key"), Value: value})
+	}
+
+	_, action := server.eventHandler(conn, nil)
+	assert.Equal(t, evio.Close, action)
+}
+
+func TestReplicaRejectsWrites(t *testing.T) {
+	t.Parallel()
+	server := logServer(t, Options{ReplicaOf: "localhost:1"})
+	results := handleAll(t, server,
+		set("key", "val"),
+		blpop("list", time.Second),
+		get("key"),
+		protocol.Operation{Type: protocol.REPLICATE},
+		protocol.Operation{Type: protocol.REPLICATION},
+	)
+	assert.Equal(t, protocol.READ_ONLY, results[0].Status)
+	assert.Equal(t, protocol.READ_ONLY, results[1].Status)
+	assert.Equal(t, protocol.NOT_FOUND, results[2].Status)
+	assert.Equal(t, constants.ReplicaChainErr, string(results[3].Message))
+	assert.Equal(t, RoleReplica, string(results[4].Message))
+	assert.Equal(t, [][]byte{
+		[]byte("leader"), []byte("localhost:1"),
+		[]byte("connected"), []byte("false"),
+	}, results[4].Values)
+
+	_, err := New(Options{ReplicaOf: "localhost:1", AppendLogPath: "ops.log"})
+	assert.Error(t, err)
+}
+
+func TestFollowerStopTwice(t *testing.T) {
+	t.Parallel()
+	f := newFollower(Options{ReplicaOf: "localhost:1"})
+	f.stop()
+	assert.NotPanics(t, f.stop)
+	assert.False(t, f.setConnection(nil))
+}
+
+func TestApplyStream(t *testing.T) {
+	t.Parallel()
+	server := logServer(t, Options{ReplicaOf: "localhost:1"})
+	sent := time.Now().Add(-time.Second)
+	batch := protocol.BatchedRequest{Operations: []protocol.Operation{{
+		Type:    protocol.REPLICATE,
+		Ops:     []protocol.Operation{set("key", "val"), push(protocol.RPUSH, "list", "a")},
+		Version: 9,
+		SentAt:  sent.UnixMilli(),
+	}}}
+	payload, err := batch.MarshalMsg(nil)
+	require.NoError(t, err)
+	require.NoError(t, server.applyStream(payload))
+
+	res := server.processRequest(get("key"))
+	assert.Equal(t, "val", string(res.Message))
+	res = server.processRequest(protocol.Operation{Type: protocol.REPLICATION})
+	assert.Equal(t, uint64(9), res.Version)
+	require.Len(t, res.Values, 6)
+	lag, err := strconv.Atoi(string(res.Values[5]))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, lag, 1000)
+
+	batch.Operations[0].Type = protocol.SET
+	payload, err = batch.MarshalMsg(nil)
+	require.NoError(t, err)
+	assert.Error(t, server.applyStream(payload))
+
+	// a resync starts over from the leader's snapshot
+	require.NoError(t, server.clear())
+	res = server.processRequest(get("key"))
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+}
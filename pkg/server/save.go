@@ -0,0 +1,87 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/snapshot"
+)
+
+func (s *Server) restore() error {
+	replayed, err := s.replayLog()
+	if err != nil {
+		return err
+	}
+
+	restored := 0
+	if !replayed && s.snapshotPath != "" {
+		restored, err = snapshot.Load(s.snapshotPath, s.kv)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf(constants.LoadSnapshotErr, s.snapshotPath, err)
+		}
+	}
+	return s.openLog(restored > 0)
+}
+
+func (s *Server) save() error {
+	seq := s.dumps.Add(1)
+	data, err := snapshot.Encode(s.kv)
+	if err != nil {
+		return err
+	}
+	return s.persist(seq, data)
+}
+
+func (s *Server) persist(seq uint64, data []byte) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	if seq < s.saved {
+		return nil
+	}
+
+	if err := snapshot.Save(s.snapshotPath, data); err != nil {
+		return err
+	}
+	s.saved = seq
+	return nil
+}
+
+func (s *Server) saveIfDue(now time.Time) {
+	if s.snapshotInterval == 0 || now.Before(s.nextSave) || s.saving.Load() {
+		return
+	}
+	s.nextSave = now.Add(s.snapshotInterval)
+
+	s.txLock.Lock()
+	seq := s.dumps.Add(1)
+	data, err := snapshot.Encode(s.kv)
+	s.txLock.Unlock()
+	if err != nil {
+		s.logger.Printf(constants.SaveSnapshotErr, s.snapshotPath, err)
+		return
+	}
+
+	s.saving.Store(true)
+	go func() {
+		defer s.saving.Store(false)
+		if err := s.persist(seq, data); err != nil {
+			s.logger.Printf(constants.SaveSnapshotErr, s.snapshotPath, err)
+		}
+	}()
+}
+
+func (s *Server) processSave() protocol.Result {
+	res := protocol.Result{}
+	if s.snapshotPath == "" {
+		res.Status = protocol.FAILURE
+		res.Message = []byte(constants.NoSnapshotPathErr)
+		return res
+	}
+
+	handleOperationResult(&res, s.ok, s.save())
+	return res
+}
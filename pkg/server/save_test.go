@@ -0,0 +1,90 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "dump.snap")
+	server, err := New(Options{SnapshotPath: path})
+	require.NoError(t, err, "a missing snapshot starts empty")
+
+	server.processRequest(set("key", "val"))
+	server.processRequest(zadd(protocol.ZADD, "board", "ada", "1"))
+	res := server.processRequest(protocol.Operation{Type: protocol.SAVE})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+
+	restored, err := New(Options{SnapshotPath: path})
+	require.NoError(t, err)
+	res = restored.processRequest(get("key"))
+	assert.Equal(t, "val", string(res.Message))
+	res = restored.processRequest(protocol.Operation{Type: protocol.ZSCORE, Key: []byte("board"), Member: []byte("ada")})
+	assert.Equal(t, "1", string(res.Message))
+
+	require.NoError(t, os.WriteFile(path, []byte("corrupt snapshot"), 0o600))
+	_, err = New(Options{SnapshotPath: path})
+	assert.Error(t, err)
+}
+
+func TestSaveOptions(t *testing.T) {
+	t.Parallel()
+	_, err := New(Options{SnapshotInterval: time.Second})
+	assert.Error(t, err)
+	_, err = New(Options{SnapshotPath: "dump.snap", SnapshotInterval: -time.Second})
+	assert.Error(t, err)
+
+	res := txServer().processRequest(protocol.Operation{Type: protocol.SAVE})
+	assert.Equal(t, protocol.FAILURE, res.Status)
+	assert.Equal(t, constants.NoSnapshotPathErr, string(res.Message))
+}
+
+func TestSaveIfDue(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "dump.snap")
+	server, err := New(Options{SnapshotPath: path, SnapshotInterval: time.Hour})
+	require.NoError(t, err)
+	server.processRequest(set("key", "val"))
+
+	server.saveIfDue(time.Now())
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the first interval has not passed")
+
+	server.saveIfDue(time.Now().Add(time.Hour))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil && !server.saving.Load()
+	}, time.Second, time.Millisecond)
+
+	// a dump older than the file on disk is never written over it
+	server.processRequest(set("key", "new"))
+	require.NoError(t, server.save())
+	require.NoError(t, server.persist(1, []byte("stale")))
+
+	restored, err := New(Options{SnapshotPath: path})
+	require.NoError(t, err)
+	res := restored.processRequest(get("key"))
+	assert.Equal(t, "new", string(res.Message))
+}
+
+func TestTransactionRejectsSave(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(protocol.Operation{Type: protocol.TX, Ops: []protocol.Operation{
+		set("key", "val"),
+		{Type: protocol.SAVE},
+	}})
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	assert.Equal(t, constants.TxSaveErr, string(res.Message))
+	res = server.processRequest(get("key"))
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+}
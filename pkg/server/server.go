@@ -1,13 +1,19 @@
 package server
 
 import (
-	"encoding/binary"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/oplog"
 	"github.com/kevindweb/cache/internal/protocol"
 	"github.com/kevindweb/cache/internal/storage"
 
@@ -18,25 +24,65 @@ const (
 	BatchTooLargeErr = "batch size %d too large, max is %d"
 )
 
+type EvictionPolicy = storage.EvictionPolicy
+
+const (
+	NoEviction  = storage.NoEviction
+	LRU         = storage.LRU
+	LFU         = storage.LFU
+	Random      = storage.Random
+	VolatileTTL = storage.VolatileTTL
+)
+
 type Server struct {
-	Address   string
-	shutdown  bool
-	stopped   chan (bool)
-	logger    *log.Logger
-	kv        storage.KeyValue
-	request   protocol.BatchedRequest
-	requests  []protocol.Operation
-	response  protocol.BatchedResponse
-	results   []protocol.Result
-	resBuffer []byte
-	outBuffer []byte
-	ok        []byte
+	Address     string
+	loops       int
+	loadBalance evio.LoadBalance
+	shutdown    atomic.Bool
+	txLock      sync.RWMutex
+	stopped     chan (bool)
+	logger      *log.Logger
+	kv          storage.KeyValue
+	sessions    sync.Pool
+	waiters     waiters
+	ok          []byte
+
+	snapshotPath     string
+	snapshotInterval time.Duration
+	nextSave         time.Time
+	saving           atomic.Bool
+	dumps            atomic.Uint64
+	saveMu           sync.Mutex
+	saved            uint64
+
+	appendLogPath  string
+	appendLogSync  SyncPolicy
+	compactLogSize int64
+	appendLog      *oplog.Log
+	background     sync.WaitGroup
+
+	replicas replicas
+	follower *follower
+	cluster  *cluster
+	evicted  [][]byte
 }
 
 type Options struct {
-	Host    string
-	Port    int
-	Network string
+	Host             string
+	Port             int
+	Network          string
+	MaxMemory        int64
+	Eviction         EvictionPolicy
+	Loops            int
+	LoadBalance      evio.LoadBalance
+	SnapshotPath     string
+	SnapshotInterval time.Duration
+	AppendLogPath    string
+	AppendLogSync    SyncPolicy
+	CompactLogSize   int64
+	ReplicaOf        string
+	ClusterAddr      string
+	ClusterSlots     []SlotRange
 }
 
 func New(opts Options) (*Server, error) {
@@ -45,22 +91,57 @@ func New(opts Options) (*Server, error) {
 		return nil, err
 	}
 
-	bufferSize := constants.MaxRequestBatch * constants.RequestSizeBytes
-	results := make([]protocol.Result, 0, constants.MaxRequestBatch)
-	return &Server{
-		Address: fmt.Sprintf("%s://%s:%d", opts.Network, opts.Host, opts.Port),
-		stopped: make(chan bool),
-		logger:  log.New(os.Stdout, "", 0),
-		kv:      storage.NewCacheMap(),
-		request: protocol.BatchedRequest{
-			Operations: make([]protocol.Operation, constants.MaxRequestBatch),
+	cluster, err := newCluster(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Address:     fmt.Sprintf("%s://%s:%d", opts.Network, opts.Host, opts.Port),
+		loops:       opts.Loops,
+		loadBalance: opts.LoadBalance,
+		stopped:     make(chan bool, 1),
+		logger:      log.New(os.Stdout, "", 0),
+		sessions: sync.Pool{
+			New: func() any {
+				return newSession()
+			},
 		},
-		results:   results,
-		response:  protocol.BatchedResponse{},
-		resBuffer: make([]byte, bufferSize),
-		outBuffer: make([]byte, bufferSize),
-		ok:        constants.Ok(),
-	}, nil
+		ok:               constants.Ok(),
+		snapshotPath:     opts.SnapshotPath,
+		snapshotInterval: opts.SnapshotInterval,
+		nextSave:         time.Now().Add(opts.SnapshotInterval),
+		appendLogPath:    opts.AppendLogPath,
+		appendLogSync:    opts.AppendLogSync,
+		compactLogSize:   opts.CompactLogSize,
+		follower:         newFollower(opts),
+		cluster:          cluster,
+	}
+	s.kv = newStorage(opts, s.evict)
+
+	if s.follower != nil {
+		return s, nil
+	}
+
+	if err := s.restore(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newStorage(opts Options, onEvict storage.EvictFunc) storage.KeyValue {
+	concurrent := opts.Loops > 1
+	switch {
+	case opts.MaxMemory > 0 && concurrent:
+		bounded := storage.NewNotifyingCache(storage.NewCacheMap(), opts.MaxMemory, opts.Eviction, onEvict)
+		return storage.NewLockedMap(bounded)
+	case opts.MaxMemory > 0:
+		return storage.NewNotifyingCache(storage.NewCacheMap(), opts.MaxMemory, opts.Eviction, onEvict)
+	case concurrent:
+		return storage.NewShardedMap(storage.DefaultShards)
+	default:
+		return storage.NewCacheMap()
+	}
 }
 
 func fillDefaultOptions(opts *Options) Options {
@@ -80,6 +161,16 @@ func fillDefaultOptions(opts *Options) Options {
 		opts.Network = constants.DefaultNetwork
 	}
 
+	if opts.CompactLogSize == 0 {
+		opts.CompactLogSize = constants.DefaultCompactLogSize
+	}
+
+	if opts.Loops == 0 {
+		opts.Loops = 1
+	} else if opts.Loops == -1 {
+		opts.Loops = runtime.NumCPU()
+	}
+
 	return *opts
 }
 
@@ -88,6 +179,50 @@ func validateOptions(opts Options) error {
 		return fmt.Errorf(constants.InvalidPortErr, opts.Port)
 	}
 
+	if opts.Loops < 1 {
+		return fmt.Errorf(constants.InvalidLoopsErr, opts.Loops)
+	}
+
+	if opts.MaxMemory < 0 {
+		return fmt.Errorf(constants.InvalidMaxMemoryErr, opts.MaxMemory)
+	}
+
+	if !opts.Eviction.Valid() {
+		return fmt.Errorf(constants.InvalidEvictionErr, opts.Eviction)
+	}
+
+	if opts.SnapshotInterval < 0 {
+		return fmt.Errorf(constants.InvalidSnapshotIntervalErr, opts.SnapshotInterval)
+	}
+
+	if opts.SnapshotInterval > 0 && opts.SnapshotPath == "" {
+		return fmt.Errorf(constants.MissingSnapshotPathErr, opts.SnapshotInterval)
+	}
+
+	if !opts.AppendLogSync.Valid() {
+		return fmt.Errorf(constants.InvalidSyncPolicyErr, opts.AppendLogSync)
+	}
+
+	if opts.CompactLogSize < 0 {
+		return fmt.Errorf(constants.InvalidCompactErr, opts.CompactLogSize)
+	}
+
+	if opts.ReplicaOf != "" && opts.AppendLogPath != "" {
+		return errors.New(constants.ReplicaLogErr)
+	}
+
+	if opts.ReplicaOf != "" && opts.MaxMemory > 0 {
+		return errors.New(constants.ReplicaMemoryErr)
+	}
+
+	if len(opts.ClusterSlots) > 0 && opts.ClusterAddr == "" {
+		return errors.New(constants.MissingClusterErr)
+	}
+
+	if opts.ReplicaOf != "" && opts.ClusterAddr != "" {
+		return errors.New(constants.ClusterReplicaErr)
+	}
+
 	return nil
 }
 
@@ -112,96 +247,292 @@ func StartOptions(opts Options) (*Server, error) {
 
 func (s *Server) Start() error {
 	events := evio.Events{
-		Data: s.eventHandler,
+		NumLoops:    s.loops,
+		LoadBalance: s.loadBalance,
+		Opened:      s.opened,
+		Closed:      s.closed,
+		Data:        s.eventHandler,
+		Tick:        s.tick,
+	}
+
+	if s.follower != nil {
+		s.background.Add(1)
+		go s.follow()
 	}
 	return evio.Serve(events, s.Address)
 }
 
 func (s *Server) Stop() error {
-	s.shutdown = true
+	s.shutdown.Store(true)
 	select {
 	case <-s.stopped:
 	case <-time.After(constants.ShutdownTimeout):
 	}
-	return s.free()
+
+	var err error
+	if s.snapshotPath != "" {
+		s.txLock.Lock()
+		err = s.save()
+		s.txLock.Unlock()
+	}
+
+	if s.follower != nil {
+		s.follower.stop()
+	}
+
+	s.background.Wait()
+	if s.appendLog != nil {
+		err = errors.Join(err, s.appendLog.Close())
+	}
+	return errors.Join(err, s.free())
 }
 
 func (s *Server) free() error {
-	s.request = protocol.BatchedRequest{}
-	s.requests = []protocol.Operation{}
-	s.response = protocol.BatchedResponse{}
-	s.results = []protocol.Result{}
-	s.resBuffer = []byte{}
-	s.outBuffer = []byte{}
 	return s.kv.Free()
 }
 
-func (s *Server) eventHandler(_ evio.Conn, in []byte) ([]byte, evio.Action) {
-	if s.shutdown {
-		s.stopped <- true
+func (s *Server) signalStopped() {
+	select {
+	case s.stopped <- true:
+	default:
+	}
+}
+
+func (s *Server) tick() (time.Duration, evio.Action) {
+	if s.shutdown.Load() {
+		s.signalStopped()
+		return 0, evio.Shutdown
+	}
+
+	s.txLock.RLock()
+	s.activeExpire()
+	s.txLock.RUnlock()
+	s.saveIfDue(time.Now())
+	s.compactIfDue()
+	if err := s.replicas.heartbeat(time.Now()); err != nil {
+		s.logger.Printf("sending replication heartbeat: %v", err)
+	}
+	return s.nextTick(), evio.None
+}
+
+// lock runs a frame alone when it holds a transaction, a snapshot or, while
+// writes are logged or replicated, a write, so they are recorded in order.
+func (s *Server) lock(requests []protocol.Operation) func() {
+	if s.loops <= 1 && s.follower == nil {
+		return func() {}
+	}
+
+	if s.exclusive(requests) {
+		s.txLock.Lock()
+		return s.txLock.Unlock
+	}
+
+	// a replica may have attached while waiting for the read lock
+	s.txLock.RLock()
+	if s.loops <= 1 || !s.exclusive(requests) {
+		return s.txLock.RUnlock
+	}
+
+	s.txLock.RUnlock()
+	s.txLock.Lock()
+	return s.txLock.Unlock
+}
+
+func (s *Server) exclusive(requests []protocol.Operation) bool {
+	ordered := s.appendLog != nil || s.replicas.active()
+	for _, op := range requests {
+		switch {
+		case op.Type == protocol.TX, op.Type == protocol.SAVE, op.Type == protocol.REPLICATE:
+			return true
+		case ordered && op.Type.Recorded():
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) activeExpire() {
+	deadline := time.Now().Add(constants.ExpireCycleBudget)
+	for s.kv.RemoveExpired(constants.ExpireSampleSize) > constants.ExpireSampleSize/4 {
+		if time.Now().After(deadline) {
+			return
+		}
+	}
+}
+
+func (s *Server) opened(c evio.Conn) ([]byte, evio.Options, evio.Action) {
+	sess := s.sessions.Get().(*session)
+	sess.conn = c
+	c.SetContext(sess)
+	return nil, evio.Options{}, evio.None
+}
+
+func (s *Server) closed(c evio.Conn, _ error) evio.Action {
+	if sess, ok := c.Context().(*session); ok {
+		s.unpark(sess)
+		s.detachReplica(sess)
+		sess.reset()
+		s.sessions.Put(sess)
+	}
+	return evio.None
+}
+
+func (s *Server) eventHandler(c evio.Conn, in []byte) ([]byte, evio.Action) {
+	if s.shutdown.Load() {
+		s.signalStopped()
 		return []byte{}, evio.Shutdown
 	}
 
-	if _, err := (&s.request).UnmarshalMsg(in); err != nil {
-		return s.processErr(err), evio.None
+	sess, ok := c.Context().(*session)
+	if !ok {
+		return []byte{}, evio.Close
 	}
 
-	s.requests = s.request.Operations
-	if len(s.requests) > constants.MaxRequestBatch {
-		err := fmt.Errorf(
-			BatchTooLargeErr, len(s.requests), constants.MaxRequestBatch,
-		)
-		return s.processErr(err), evio.None
+	if sess.replica != nil {
+		return sess.replica.drain(sess)
 	}
+	return s.handle(sess, in)
+}
 
-	if err := s.process(); err != nil {
-		return s.processErr(err), evio.None
+func (s *Server) handle(sess *session, in []byte) ([]byte, evio.Action) {
+	buf := sess.buffer(in)
+	out := sess.outBuffer[:0]
+	if sess.parked != nil {
+		out = append(out, sess.parked.out...)
+		res, parked := s.run(sess, sess.parked.index)
+		if parked {
+			sess.keep(buf)
+			return nil, evio.None
+		}
+		out = protocol.AppendFrame(out, res)
 	}
 
-	return s.writeHeader(s.resBuffer), evio.None
+	for {
+		frame, ok, err := protocol.NextFrame(buf)
+		if err != nil {
+			sess.outBuffer = protocol.AppendFrame(out, s.processErr(sess, protocol.FAILURE, err))
+			return sess.outBuffer, evio.Close
+		}
+
+		if !ok {
+			break
+		}
+
+		res, parked := s.handleFrame(sess, frame)
+		buf = buf[constants.HeaderSize+len(frame):]
+		if parked {
+			sess.parked.out = append(sess.parked.out, out...)
+			sess.keep(buf)
+			sess.outBuffer = out
+			return nil, evio.None
+		}
+		out = protocol.AppendFrame(out, res)
+	}
+
+	sess.keep(buf)
+	sess.outBuffer = out
+	return out, evio.None
 }
 
-func (s *Server) writeHeader(data []byte) []byte {
-	dataLength := len(data)
-	totalLength := constants.HeaderSize + dataLength
-	if cap(s.outBuffer) < totalLength {
-		s.outBuffer = make([]byte, totalLength)
+func (s *Server) handleFrame(sess *session, in []byte) ([]byte, bool) {
+	if err := sess.decode(in); err != nil {
+		return s.processErr(sess, protocol.FAILURE, err), false
+	}
+
+	requests := sess.request.Operations
+	if len(requests) > constants.MaxRequestBatch {
+		err := fmt.Errorf(
+			BatchTooLargeErr, len(requests), constants.MaxRequestBatch,
+		)
+		return s.processErr(sess, protocol.BATCH_TOO_LARGE, err), false
 	}
-	binary.LittleEndian.PutUint32(s.outBuffer[:constants.HeaderSize], uint32(dataLength))
-	copy(s.outBuffer[constants.HeaderSize:totalLength], data)
-	return s.outBuffer[:totalLength]
+
+	return s.run(sess, 0)
 }
 
-func (s *Server) processErr(err error) []byte {
-	s.response.Results = s.response.Results[:0]
-	s.response.Results[0] = protocol.Result{
-		Status:  protocol.FAILURE,
-		Message: []byte(err.Error()),
+func (s *Server) run(sess *session, start int) ([]byte, bool) {
+	requests := sess.request.Operations
+	unlock := s.lock(requests)
+	parked, err := s.process(sess, requests, start)
+	unlock()
+	if err != nil {
+		return s.processErr(sess, protocol.FAILURE, err), false
+	}
+
+	if parked {
+		return nil, true
 	}
+	return sess.resBuffer, false
+}
+
+func (s *Server) processErr(sess *session, status protocol.ResultStatus, err error) []byte {
+	sess.response.Results = append(sess.response.Results[:0], protocol.Result{
+		Status:  status,
+		Message: []byte(err.Error()),
+	})
 
 	var encodeErr error
-	if s.resBuffer, encodeErr = s.response.MarshalMsg(s.resBuffer[:0]); err != nil {
+	if sess.resBuffer, encodeErr = sess.response.MarshalMsg(sess.resBuffer[:0]); encodeErr != nil {
 		msg := fmt.Sprintf("processing error: %v, encoding error: %v", err, encodeErr)
-		s.logger.Printf(msg)
+		s.logger.Println(msg)
 		return []byte(msg)
 	}
 
-	return s.writeHeader(s.resBuffer)
+	return sess.resBuffer
 }
 
-func (s *Server) process() error {
-	results := s.results[:len(s.requests)]
-	for i, op := range s.requests {
-		results[i] = s.processRequest(op)
+func (s *Server) process(sess *session, requests []protocol.Operation, start int) (bool, error) {
+	results := sess.results[:len(requests)]
+	asking := start > 0 && requests[start-1].Type == protocol.ASKING
+	for i := start; i < len(requests); i++ {
+		op := requests[i]
+		if s.cluster != nil {
+			res, redirected := s.route(op, asking)
+			asking = op.Type == protocol.ASKING
+			if redirected {
+				results[i] = res
+				continue
+			}
+		}
+
+		switch {
+		case s.follower != nil && op.Type.Recorded():
+			results[i] = readOnly(op)
+			continue
+		case op.Type == protocol.REPLICATE:
+			results[i] = s.attachReplica(sess)
+			continue
+		}
+
+		if !op.Type.Blocking() {
+			results[i] = s.processRequest(op)
+			if err := s.record(sess, op, results[i]); err != nil {
+				return false, errors.Join(err, s.flushRecords(sess))
+			}
+			continue
+		}
+
+		res, done := s.blockingPop(sess, op, i)
+		if !done {
+			return true, s.flushRecords(sess)
+		}
+		results[i] = res
+		if err := s.record(sess, op, res); err != nil {
+			return false, errors.Join(err, s.flushRecords(sess))
+		}
+	}
+
+	if err := s.flushRecords(sess); err != nil {
+		return false, err
 	}
 
 	var err error
-	s.response.Results = results
-	if s.resBuffer, err = s.response.MarshalMsg(s.resBuffer[:0]); err != nil {
-		return err
+	sess.response.Results = results
+	if sess.resBuffer, err = sess.response.MarshalMsg(sess.resBuffer[:0]); err != nil {
+		return false, err
 	}
 
-	return nil
+	return false, nil
 }
 
 func (s *Server) processRequest(op protocol.Operation) protocol.Result {
@@ -218,18 +549,183 @@ func (s *Server) processRequest(op protocol.Operation) protocol.Result {
 	case protocol.DELETE:
 		err := s.kv.Del(op.Key)
 		handleOperationResult(&res, s.ok, err)
+	case protocol.SETEX:
+		err := s.kv.SetWithTTL(op.Key, op.Value, millis(op.TTL))
+		handleOperationResult(&res, s.ok, err)
+	case protocol.EXPIRE:
+		err := s.kv.Expire(op.Key, millis(op.TTL))
+		handleOperationResult(&res, s.ok, err)
+	case protocol.TTL:
+		ttl, err := s.kv.TTL(op.Key)
+		handleOperationResult(&res, formatTTL(ttl), err)
+	case protocol.PERSIST:
+		err := s.kv.Persist(op.Key)
+		handleOperationResult(&res, s.ok, err)
+	case protocol.MGET, protocol.MSET, protocol.MDEL:
+		res = s.processMulti(op)
+	case protocol.INCR, protocol.DECR, protocol.INCRBY:
+		n, err := storage.IncrBy(s.kv, op.Key, incrDelta(op))
+		handleOperationResult(&res, strconv.AppendInt(nil, n, 10), err)
+	case protocol.INCRBYFLOAT:
+		res = s.incrByFloat(op)
+	case protocol.SETNX:
+		res = s.setIf(op, storage.IfAbsent)
+	case protocol.SETXX:
+		res = s.setIf(op, storage.IfExists)
+	case protocol.CAS:
+		res = s.setIf(op, storage.IfVersion(op.Version))
+	case protocol.GETVERSION:
+		val, version, err := s.kv.GetVersion(op.Key)
+		handleOperationResult(&res, val, err)
+		res.Version = version
+	case protocol.TX:
+		res = s.transaction(op)
+	case protocol.SCAN:
+		res = s.scan(op)
+	case protocol.HSET, protocol.HGET, protocol.HDEL, protocol.HGETALL:
+		res = s.processHash(op)
+	case protocol.LPUSH, protocol.RPUSH, protocol.LPOP, protocol.RPOP,
+		protocol.LRANGE, protocol.LLEN, protocol.BLPOP, protocol.BRPOP:
+		res = s.processList(op)
+	case protocol.ZADD, protocol.ZREM, protocol.ZSCORE, protocol.ZINCRBY,
+		protocol.ZRANGE, protocol.ZRANGEBYSCORE:
+		res = s.processZSet(op)
+	case protocol.SAVE:
+		res = s.processSave()
+	case protocol.REPLICATE:
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(constants.ReplicateNestedErr)
+	case protocol.REPLICATION:
+		res = s.processReplication()
+	case protocol.CLUSTERSLOTS:
+		res = s.processClusterSlots()
+	case protocol.ASKING:
+		res.Message = s.ok
+	case protocol.SETSLOT:
+		res = s.setSlot(op)
 	default:
-		res.Status = protocol.FAILURE
+		res.Status = protocol.UNKNOWN_OPERATION
 		res.Message = []byte(fmt.Sprintf(constants.UndefinedOpErr, op.Type))
 	}
 	return res
 }
 
+func (s *Server) processMulti(op protocol.Operation) protocol.Result {
+	res := protocol.Result{Message: s.ok}
+	if len(op.Keys) > constants.MaxRequestBatch {
+		res.Status = protocol.BATCH_TOO_LARGE
+		res.Message = []byte(fmt.Sprintf(BatchTooLargeErr, len(op.Keys), constants.MaxRequestBatch))
+		return res
+	}
+
+	if op.Type == protocol.MSET && len(op.Values) != len(op.Keys) {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(fmt.Sprintf(constants.MismatchedErr, len(op.Keys), len(op.Values)))
+		return res
+	}
+
+	res.Results = make([]protocol.Result, len(op.Keys))
+	for i, key := range op.Keys {
+		switch op.Type {
+		case protocol.MGET:
+			val, err := s.kv.Get(key)
+			handleOperationResult(&res.Results[i], val, err)
+		case protocol.MSET:
+			err := s.kv.Set(key, op.Values[i])
+			handleOperationResult(&res.Results[i], s.ok, err)
+		default:
+			err := s.kv.Del(key)
+			handleOperationResult(&res.Results[i], s.ok, err)
+		}
+	}
+	return res
+}
+
+func incrDelta(op protocol.Operation) int64 {
+	switch op.Type {
+	case protocol.INCR:
+		return 1
+	case protocol.DECR:
+		return -1
+	default:
+		return op.Delta
+	}
+}
+
+func (s *Server) incrByFloat(op protocol.Operation) protocol.Result {
+	res := protocol.Result{}
+	delta, err := strconv.ParseFloat(string(op.Value), 64)
+	if err != nil || math.IsInf(delta, 0) || math.IsNaN(delta) {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(fmt.Sprintf(constants.InvalidIncrementErr, string(op.Value)))
+		return res
+	}
+
+	f, err := storage.IncrByFloat(s.kv, op.Key, delta)
+	handleOperationResult(&res, strconv.AppendFloat(nil, f, 'f', -1, 64), err)
+	return res
+}
+
+func (s *Server) setIf(op protocol.Operation, cond storage.Condition) protocol.Result {
+	res := protocol.Result{}
+	version, err := s.kv.SetIf(op.Key, op.Value, millis(op.TTL), cond)
+	handleOperationResult(&res, s.ok, err)
+	res.Version = version
+	return res
+}
+
+func (s *Server) scan(op protocol.Operation) protocol.Result {
+	count := int(op.Count)
+	if count <= 0 {
+		count = constants.DefaultScanCount
+	} else if count > constants.MaxScanCount {
+		count = constants.MaxScanCount
+	}
+
+	res := protocol.Result{}
+	keys, next, err := s.kv.Scan(op.Cursor, op.Pattern, count)
+	handleOperationResult(&res, s.ok, err)
+	res.Values, res.Version = keys, next
+	return res
+}
+
+func millis(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+func formatTTL(ttl time.Duration) []byte {
+	if ttl == constants.NoExpiration {
+		return []byte(constants.PERSISTENT)
+	}
+	return []byte(strconv.FormatInt(ttl.Milliseconds(), 10))
+}
+
 func handleOperationResult(res *protocol.Result, msg []byte, err error) {
 	if err != nil {
-		res.Status = protocol.FAILURE
+		res.Status = errorStatus(err)
 		res.Message = []byte(err.Error())
 	} else {
 		res.Message = msg
 	}
 }
+
+func errorStatus(err error) protocol.ResultStatus {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return protocol.NOT_FOUND
+	case errors.Is(err, storage.ErrOutOfMemory):
+		return protocol.OUT_OF_MEMORY
+	case errors.Is(err, storage.ErrInvalidTTL):
+		return protocol.INVALID_ARGUMENT
+	case errors.Is(err, storage.ErrNotNumber), errors.Is(err, storage.ErrWrongType):
+		return protocol.WRONG_TYPE
+	case errors.Is(err, storage.ErrOverflow):
+		return protocol.OVERFLOW
+	case errors.Is(err, storage.ErrConflict):
+		return protocol.CONFLICT
+	case errors.Is(err, storage.ErrInvalidPattern):
+		return protocol.INVALID_ARGUMENT
+	default:
+		return protocol.FAILURE
+	}
+}
@@ -3,13 +3,18 @@ package server
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
+	"runtime"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/kevindweb/cache/internal/constants"
 	"github.com/kevindweb/cache/internal/protocol"
 	"github.com/kevindweb/cache/internal/storage"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/evio"
 )
 
 func TestProcessRequestEmptyCache(t *testing.T) {
@@ -40,10 +45,33 @@ func TestProcessRequestEmptyCache(t *testing.T) {
 				Key:  key,
 			},
 			want: protocol.Result{
-				Status:  protocol.FAILURE,
+				Status:  protocol.NOT_FOUND,
 				Message: []byte(fmt.Sprintf(storage.UnsetKeyErr, key)),
 			},
 		},
+		{
+			name: "no key for ttl",
+			op: protocol.Operation{
+				Type: protocol.TTL,
+				Key:  key,
+			},
+			want: protocol.Result{
+				Status:  protocol.NOT_FOUND,
+				Message: []byte(fmt.Sprintf(storage.UnsetKeyErr, key)),
+			},
+		},
+		{
+			name: "invalid setex ttl",
+			op: protocol.Operation{
+				Type:  protocol.SETEX,
+				Key:   key,
+				Value: value,
+			},
+			want: protocol.Result{
+				Status:  protocol.INVALID_ARGUMENT,
+				Message: []byte(fmt.Sprintf(constants.KeyTTLErr, time.Duration(0), key)),
+			},
+		},
 		{
 			name: "valid empty delete",
 			op: protocol.Operation{
@@ -70,41 +98,420 @@ func TestProcessRequestEmptyCache(t *testing.T) {
 	}
 }
 
-func TestHeader(t *testing.T) {
+func frame(t *testing.T, ops ...protocol.Operation) []byte {
+	t.Helper()
+	batch := protocol.BatchedRequest{Operations: ops}
+	encoded, err := batch.MarshalMsg(nil)
+	assert.NoError(t, err)
+	return protocol.AppendFrame(nil, encoded)
+}
+
+func readResponses(t *testing.T, out []byte) []protocol.BatchedResponse {
+	t.Helper()
+	responses := []protocol.BatchedResponse{}
+	for {
+		payload, ok, err := protocol.NextFrame(out)
+		assert.NoError(t, err)
+		if !ok {
+			assert.Empty(t, out, "output should only hold complete frames")
+			return responses
+		}
+
+		res := protocol.BatchedResponse{}
+		_, err = res.UnmarshalMsg(payload)
+		assert.NoError(t, err)
+		responses = append(responses, res)
+		out = out[constants.HeaderSize+len(payload):]
+	}
+}
+
+func TestHandleFraming(t *testing.T) {
+	t.Parallel()
+	set := frame(t, protocol.Operation{
+		Type:  protocol.SET,
+		Key:   []byte("key"),
+		Value: []byte("value"),
+	})
+	get := frame(t, protocol.Operation{
+		Type: protocol.GET,
+		Key:  []byte("key"),
+	})
+	coalesced := append(append([]byte{}, set...), get...)
+	tests := []struct {
+		name  string
+		reads [][]byte
+	}{
+		{
+			name:  "one frame per read",
+			reads: [][]byte{set, get},
+		},
+		{
+			name:  "coalesced frames",
+			reads: [][]byte{coalesced},
+		},
+		{
+			name:  "split header",
+			reads: [][]byte{coalesced[:2], coalesced[2:]},
+		},
+		{
+			name: "split across frames",
+			reads: [][]byte{
+				coalesced[:len(set)-3],
+				coalesced[len(set)-3 : len(set)+5],
+				coalesced[len(set)+5:],
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			server := Server{
+				kv: storage.NewCacheMap(),
+				ok: constants.Ok(),
+			}
+			sess := newSession()
+			responses := []protocol.BatchedResponse{}
+			for _, read := range tc.reads {
+				out, action := server.handle(sess, read)
+				assert.Equal(t, evio.None, action)
+				responses = append(responses, readResponses(t, out)...)
+			}
+
+			assert.Len(t, responses, 2)
+			assert.Equal(t, constants.Ok(), responses[0].Results[0].Message)
+			assert.Equal(t, []byte("value"), responses[1].Results[0].Message)
+			assert.Empty(t, sess.in)
+		})
+	}
+}
+
+func TestHandleFrameTooLarge(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	header := make([]byte, constants.HeaderSize)
+	binary.LittleEndian.PutUint32(header, constants.MaxFrameSize+1)
+	out, action := server.handle(newSession(), header)
+	assert.Equal(t, evio.Close, action)
+	responses := readResponses(t, out)
+	assert.Len(t, responses, 1)
+	assert.Equal(t, protocol.FAILURE, responses[0].Results[0].Status)
+}
+
+func TestHandleBatchTooLarge(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	ops := make([]protocol.Operation, constants.MaxRequestBatch+1)
+	for i := range ops {
+		ops[i] = protocol.Operation{Type: protocol.PING}
+	}
+	out, action := server.handle(newSession(), frame(t, ops...))
+	assert.Equal(t, evio.None, action)
+	responses := readResponses(t, out)
+	assert.Len(t, responses, 1)
+	assert.Equal(t, protocol.BATCH_TOO_LARGE, responses[0].Results[0].Status)
+}
+
+func TestErrorStatus(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewBoundedCache(storage.NewCacheMap(), 4, storage.NoEviction),
+		ok: constants.Ok(),
+	}
+	tests := []struct {
+		name string
+		op   protocol.Operation
+		want protocol.ResultStatus
+	}{
+		{
+			name: "missing key",
+			op:   protocol.Operation{Type: protocol.GET, Key: []byte("missing")},
+			want: protocol.NOT_FOUND,
+		},
+		{
+			name: "out of memory",
+			op:   protocol.Operation{Type: protocol.SET, Key: []byte("key"), Value: []byte("value")},
+			want: protocol.OUT_OF_MEMORY,
+		},
+		{
+			name: "invalid ttl",
+			op:   protocol.Operation{Type: protocol.SETEX, Key: []byte("k"), Value: []byte("v"), TTL: -1},
+			want: protocol.INVALID_ARGUMENT,
+		},
+		{
+			name: "unknown operation",
+			op:   protocol.Operation{Type: protocol.OperationType(-1)},
+			want: protocol.UNKNOWN_OPERATION,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, server.processRequest(tc.op).Status)
+		})
+	}
+}
+
+func TestProcessRequestTTL(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	key := []byte("key")
+	res := server.processRequest(protocol.Operation{
+		Type:  protocol.SET,
+		Key:   key,
+		Value: key,
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.TTL, Key: key})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, constants.PERSISTENT, string(res.Message))
+
+	res = server.processRequest(protocol.Operation{
+		Type: protocol.EXPIRE,
+		Key:  key,
+		TTL:  time.Minute.Milliseconds(),
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.TTL, Key: key})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.NotEqual(t, constants.PERSISTENT, string(res.Message))
+}
+
+func TestValidateOptions(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name   string
-		input  []byte
-		buffer []byte
+		name    string
+		opts    Options
+		wantErr string
 	}{
 		{
-			name:   "large buffer should not overflow",
-			input:  []byte("h"),
-			buffer: []byte("this is a large buffer"),
+			name: "bounded memory",
+			opts: Options{MaxMemory: 1024, Eviction: LRU},
+		},
+		{
+			name:    "negative max memory",
+			opts:    Options{MaxMemory: -1},
+			wantErr: fmt.Sprintf(constants.InvalidMaxMemoryErr, -1),
 		},
 		{
-			name:   "empty data",
-			input:  []byte{},
-			buffer: make([]byte, 100),
+			name:    "unknown eviction policy",
+			opts:    Options{Eviction: EvictionPolicy(100)},
+			wantErr: fmt.Sprintf(constants.InvalidEvictionErr, EvictionPolicy(100)),
 		},
 		{
-			name:   "empty buffer",
-			input:  []byte("h"),
-			buffer: make([]byte, 0),
+			name:    "evicting replica",
+			opts:    Options{MaxMemory: 1024, ReplicaOf: "localhost:1"},
+			wantErr: constants.ReplicaMemoryErr,
 		},
 	}
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			server := &Server{
-				outBuffer: tc.buffer,
+			err := validateOptions(fillDefaultOptions(&tc.opts))
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
 			}
-			got := server.writeHeader(tc.input)
-			length := int(binary.LittleEndian.Uint32(got[:constants.HeaderSize]))
-			assert.Equal(t, len(tc.input), length)
-			assert.Equal(t, tc.input, got[constants.HeaderSize:])
-			assert.True(t, len(server.outBuffer) >= len(tc.input)+constants.HeaderSize)
 		})
 	}
 }
+
+func TestFillDefaultLoops(t *testing.T) {
+	t.Parallel()
+	opts := fillDefaultOptions(&Options{})
+	assert.Equal(t, 1, opts.Loops)
+	opts = fillDefaultOptions(&Options{Loops: -1})
+	assert.Equal(t, runtime.NumCPU(), opts.Loops)
+	opts = fillDefaultOptions(&Options{Loops: -2})
+	assert.EqualError(t, validateOptions(opts), fmt.Sprintf(constants.InvalidLoopsErr, -2))
+}
+
+func TestProcessMulti(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	keys := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+
+	res := server.processRequest(protocol.Operation{
+		Type:   protocol.MSET,
+		Keys:   keys[:2],
+		Values: [][]byte{[]byte("1"), []byte("2")},
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Len(t, res.Results, 2)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.MGET, Keys: keys})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, []protocol.ResultStatus{protocol.SUCCESS, protocol.SUCCESS, protocol.NOT_FOUND}, []protocol.ResultStatus{
+		res.Results[0].Status, res.Results[1].Status, res.Results[2].Status,
+	})
+	assert.Equal(t, []byte("1"), res.Results[0].Message)
+	assert.Equal(t, []byte("2"), res.Results[1].Message)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.MDEL, Keys: keys[:1]})
+	assert.Equal(t, protocol.SUCCESS, res.Results[0].Status)
+	_, err := server.kv.Get(keys[0])
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.MSET, Keys: keys, Values: keys[:1]})
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+
+	res = server.processRequest(protocol.Operation{
+		Type: protocol.MGET,
+		Keys: make([][]byte, constants.MaxRequestBatch+1),
+	})
+	assert.Equal(t, protocol.BATCH_TOO_LARGE, res.Status)
+}
+
+func TestProcessIncr(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	key := []byte("counter")
+	tests := []struct {
+		op      protocol.Operation
+		status  protocol.ResultStatus
+		message string
+	}{
+		{op: protocol.Operation{Type: protocol.INCR, Key: key}, message: "1"},
+		{op: protocol.Operation{Type: protocol.INCRBY, Key: key, Delta: 41}, message: "42"},
+		{op: protocol.Operation{Type: protocol.DECR, Key: key}, message: "41"},
+		{op: protocol.Operation{Type: protocol.INCRBYFLOAT, Key: key, Value: []byte("0.5")}, message: "41.5"},
+		{op: protocol.Operation{Type: protocol.INCR, Key: key}, status: protocol.WRONG_TYPE},
+		{op: protocol.Operation{Type: protocol.INCRBYFLOAT, Key: key, Value: []byte("abc")}, status: protocol.INVALID_ARGUMENT},
+		{op: protocol.Operation{Type: protocol.INCRBY, Key: key, Delta: math.MaxInt64}, status: protocol.WRONG_TYPE},
+		{op: protocol.Operation{Type: protocol.SET, Key: key, Value: []byte("1")}, message: constants.OK},
+		{op: protocol.Operation{Type: protocol.INCRBY, Key: key, Delta: math.MaxInt64}, status: protocol.OVERFLOW},
+	}
+
+	for _, tc := range tests {
+		res := server.processRequest(tc.op)
+		assert.Equal(t, tc.status, res.Status, tc.op.Type.String())
+		if tc.status == protocol.SUCCESS {
+			assert.Equal(t, tc.message, string(res.Message))
+		}
+	}
+}
+
+func TestProcessConditional(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	key := []byte("key")
+
+	res := server.processRequest(protocol.Operation{Type: protocol.SETXX, Key: key, Value: key})
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.SETNX, Key: key, Value: []byte("v1")})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	v1 := res.Version
+	assert.NotZero(t, v1)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.SETNX, Key: key, Value: []byte("v2")})
+	assert.Equal(t, protocol.CONFLICT, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.GETVERSION, Key: key})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, "v1", string(res.Message))
+	assert.Equal(t, v1, res.Version)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.CAS, Key: key, Value: []byte("v2"), Version: v1})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Greater(t, res.Version, v1)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.CAS, Key: key, Value: []byte("v3"), Version: v1})
+	assert.Equal(t, protocol.CONFLICT, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.SETXX, Key: key, Value: []byte("v3")})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.SETNX, Key: []byte("lock"), Value: key, TTL: 1})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	ttl, err := server.kv.TTL([]byte("lock"))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, ttl, time.Millisecond)
+}
+
+func TestProcessScan(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, server.kv.Set([]byte("key:"+strconv.Itoa(i)), []byte("v")))
+	}
+
+	keys := map[string]bool{}
+	cursor, pages := uint64(0), 0
+	for {
+		res := server.processRequest(protocol.Operation{Type: protocol.SCAN, Pattern: []byte("key:*"), Cursor: cursor})
+		assert.Equal(t, protocol.SUCCESS, res.Status)
+		assert.LessOrEqual(t, len(res.Values), constants.DefaultScanCount)
+		for _, key := range res.Values {
+			keys[string(key)] = true
+		}
+
+		pages++
+		if cursor = res.Version; cursor == 0 {
+			break
+		}
+	}
+	assert.Len(t, keys, 25)
+	assert.Equal(t, 3, pages)
+
+	res := server.processRequest(protocol.Operation{Type: protocol.SCAN, Count: 1 << 40})
+	assert.Len(t, res.Values, 25)
+	assert.Zero(t, res.Version)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.SCAN, Pattern: []byte("[key")})
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+}
+
+func TestSessionClearsOptionalFields(t *testing.T) {
+	t.Parallel()
+	server := Server{
+		kv: storage.NewCacheMap(),
+		ok: constants.Ok(),
+	}
+	sess := newSession()
+	keys := [][]byte{[]byte("a"), []byte("b")}
+	out, _ := server.handle(sess, frame(t, protocol.Operation{Type: protocol.MGET, Keys: keys}))
+	responses := readResponses(t, out)
+	assert.Len(t, responses[0].Results[0].Results, len(keys))
+
+	// the second frame omits keys and must not inherit them
+	out, _ = server.handle(sess, frame(t, protocol.Operation{Type: protocol.MGET}))
+	responses = readResponses(t, out)
+	assert.Empty(t, responses[0].Results[0].Results)
+
+	assert.NoError(t, server.kv.Set([]byte("a"), []byte("v")))
+	assert.NoError(t, server.kv.Set([]byte("b"), []byte("v")))
+	out, _ = server.handle(sess, frame(t, protocol.Operation{Type: protocol.SCAN, Pattern: []byte("a"), Count: 10}))
+	responses = readResponses(t, out)
+	assert.Len(t, responses[0].Results[0].Values, 1)
+
+	// nor the pattern of an earlier scan
+	out, _ = server.handle(sess, frame(t, protocol.Operation{Type: protocol.SCAN, Count: 10}))
+	responses = readResponses(t, out)
+	assert.Len(t, responses[0].Results[0].Values, 2)
+}
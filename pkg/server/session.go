@@ -0,0 +1,64 @@
+package server
+
+import (
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/tidwall/evio"
+)
+
+type session struct {
+	conn       evio.Conn
+	parked     *parked
+	in         []byte
+	request    protocol.BatchedRequest
+	response   protocol.BatchedResponse
+	results    []protocol.Result
+	resBuffer  []byte
+	outBuffer  []byte
+	records    []byte
+	replica    *replica
+	replicated []protocol.Operation
+}
+
+func newSession() *session {
+	bufferSize := constants.MaxRequestBatch * constants.RequestSizeBytes
+	return &session{
+		request: protocol.BatchedRequest{
+			Operations: make([]protocol.Operation, constants.MaxRequestBatch),
+		},
+		results:   make([]protocol.Result, 0, constants.MaxRequestBatch),
+		resBuffer: make([]byte, bufferSize),
+		outBuffer: make([]byte, bufferSize),
+	}
+}
+
+func (sess *session) decode(in []byte) error {
+	ops := sess.request.Operations
+	for i := range ops {
+		ops[i].ClearOptional()
+	}
+
+	_, err := sess.request.UnmarshalMsg(in)
+	return err
+}
+
+func (sess *session) buffer(in []byte) []byte {
+	if len(sess.in) == 0 {
+		return in
+	}
+
+	sess.in = append(sess.in, in...)
+	return sess.in
+}
+
+func (sess *session) keep(buf []byte) {
+	sess.in = append(sess.in[:0], buf...)
+}
+
+func (sess *session) reset() {
+	sess.in = sess.in[:0]
+	sess.conn = nil
+	sess.parked = nil
+	sess.replica = nil
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/storage"
+)
+
+// undo keeps the version of a key so a rolled back write never invalidates a
+// WATCH.
+type undo struct {
+	key     []byte
+	value   []byte
+	object  storage.Object
+	ttl     time.Duration
+	version uint64
+	exists  bool
+}
+
+func (s *Server) transaction(op protocol.Operation) protocol.Result {
+	res := protocol.Result{Message: s.ok}
+	if len(op.Ops) > constants.MaxRequestBatch {
+		res.Status = protocol.BATCH_TOO_LARGE
+		res.Message = []byte(fmt.Sprintf(BatchTooLargeErr, len(op.Ops), constants.MaxRequestBatch))
+		return res
+	}
+
+	if len(op.Keys) != len(op.Versions) {
+		res.Status = protocol.INVALID_ARGUMENT
+		res.Message = []byte(fmt.Sprintf(constants.MismatchedWatchErr, len(op.Keys), len(op.Versions)))
+		return res
+	}
+
+	for i, key := range op.Keys {
+		if err := s.checkVersion(key, op.Versions[i]); err != nil {
+			handleOperationResult(&res, nil, err)
+			return res
+		}
+	}
+
+	var (
+		log     []undo
+		touched = map[string]bool{}
+	)
+	res.Results = make([]protocol.Result, len(op.Ops))
+	for i, txOp := range op.Ops {
+		if txOp.Type == protocol.TX || txOp.Type == protocol.SAVE {
+			s.rollback(log)
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(constants.NestedTxErr)
+			if txOp.Type == protocol.SAVE {
+				res.Message = []byte(constants.TxSaveErr)
+			}
+			res.Results = nil
+			return res
+		}
+
+		if txOp.Type.Recorded() {
+			log = s.snapshot(log, touched, txOp)
+		}
+
+		res.Results[i] = s.processRequest(txOp)
+		if failed(res.Results[i]) && txOp.Type.Recorded() {
+			s.rollback(log)
+			return aborted(txOp, i, res.Results[i])
+		}
+	}
+	return res
+}
+
+func (s *Server) checkVersion(key []byte, expected uint64) error {
+	_, version, err := s.kv.GetVersion(key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrWrongType) {
+		return err
+	}
+
+	if version != expected {
+		return fmt.Errorf("%w: "+constants.WatchConflictErr, storage.ErrConflict, key, version, expected)
+	}
+	return nil
+}
+
+func failed(res protocol.Result) bool {
+	if res.Status != protocol.SUCCESS {
+		return true
+	}
+
+	for _, keyRes := range res.Results {
+		if keyRes.Status != protocol.SUCCESS {
+			return true
+		}
+	}
+	return false
+}
+
+func aborted(op protocol.Operation, index int, res protocol.Result) protocol.Result {
+	for _, keyRes := range res.Results {
+		if keyRes.Status != protocol.SUCCESS {
+			res = keyRes
+			break
+		}
+	}
+
+	return protocol.Result{
+		Status:  res.Status,
+		Message: []byte(fmt.Sprintf(constants.TxAbortedErr, op.Type, index, res.Message)),
+	}
+}
+
+func (s *Server) snapshot(log []undo, touched map[string]bool, op protocol.Operation) []undo {
+	for _, key := range writes(op) {
+		if touched[string(key)] {
+			continue
+		}
+		touched[string(key)] = true
+
+		entry := undo{key: key}
+		val, version, err := s.kv.GetVersion(key)
+		entry.version = version
+		switch {
+		case err == nil:
+			entry.value, entry.exists = val, true
+		case errors.Is(err, storage.ErrWrongType):
+			err = s.kv.ViewObject(key, func(obj storage.Object) error {
+				entry.object = obj.Clone()
+				return nil
+			})
+			entry.exists = err == nil
+		}
+
+		if entry.exists {
+			entry.ttl, _ = s.kv.TTL(key)
+		}
+		log = append(log, entry)
+	}
+	return log
+}
+
+func writes(op protocol.Operation) [][]byte {
+	switch op.Type {
+	case protocol.MSET, protocol.MDEL:
+		return op.Keys
+	default:
+		return [][]byte{op.Key}
+	}
+}
+
+func (s *Server) rollback(log []undo) {
+	for i := len(log) - 1; i >= 0; i-- {
+		entry := log[i]
+		var err error
+		if entry.exists {
+			err = s.kv.Restore(entry.key, entry.value, entry.object, entry.ttl, entry.version)
+		} else {
+			err = s.kv.Del(entry.key)
+		}
+
+		if err != nil {
+			s.logger.Printf("rolling back key %s: %v", entry.key, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func txServer() *Server {
+	return &Server{
+		kv:     storage.NewCacheMap(),
+		ok:     constants.Ok(),
+		logger: log.New(os.Stdout, "", 0),
+	}
+}
+
+func set(key, val string) protocol.Operation {
+	return protocol.Operation{Type: protocol.SET, Key: []byte(key), Value: []byte(val)}
+}
+
+func get(key string) protocol.Operation {
+	return protocol.Operation{Type: protocol.GET, Key: []byte(key)}
+}
+
+func TestTransactionCommits(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(protocol.Operation{
+		Type: protocol.TX,
+		Ops: []protocol.Operation{
+			set("a", "1"),
+			{Type: protocol.INCR, Key: []byte("a")},
+			get("missing"),
+			get("a"),
+		},
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Len(t, res.Results, 4)
+	assert.Equal(t, "2", string(res.Results[1].Message))
+	assert.Equal(t, protocol.NOT_FOUND, res.Results[2].Status, "failed reads do not abort")
+	assert.Equal(t, "2", string(res.Results[3].Message))
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	assert.NoError(t, server.kv.Set([]byte("a"), []byte("old")))
+	assert.NoError(t, server.kv.SetWithTTL([]byte("b"), []byte("text"), time.Minute))
+
+	res := server.processRequest(protocol.Operation{
+		Type: protocol.TX,
+		Ops: []protocol.Operation{
+			set("a", "new"),
+			{Type: protocol.DELETE, Key: []byte("b")},
+			{Type: protocol.MSET, Keys: [][]byte{[]byte("c")}, Values: [][]byte{[]byte("1")}},
+			set("b", "text"),
+			{Type: protocol.INCR, Key: []byte("b")},
+		},
+	})
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+	assert.Nil(t, res.Results)
+
+	val, err := server.kv.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(val))
+	ttl, err := server.kv.TTL([]byte("b"))
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0), "restored keys keep their ttl")
+	_, err = server.kv.Get([]byte("c"))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestTransactionWatch(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	version, err := server.kv.SetIf([]byte("a"), []byte("1"), 0, storage.IfAbsent)
+	assert.NoError(t, err)
+
+	watch := func(versions ...uint64) protocol.Result {
+		return server.processRequest(protocol.Operation{
+			Type:     protocol.TX,
+			Ops:      []protocol.Operation{set("b", "1")},
+			Keys:     [][]byte{[]byte("a"), []byte("absent")},
+			Versions: versions,
+		})
+	}
+
+	assert.Equal(t, protocol.CONFLICT, watch(version+1, 0).Status)
+	assert.Equal(t, protocol.CONFLICT, watch(version, 1).Status)
+	assert.Equal(t, protocol.INVALID_ARGUMENT, watch(version).Status)
+	_, err = server.kv.Get([]byte("b"))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	assert.Equal(t, protocol.SUCCESS, watch(version, 0).Status)
+	_, err = server.kv.Get([]byte("b"))
+	assert.NoError(t, err)
+}
+
+func TestTransactionRollbackKeepsVersion(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	assert.NoError(t, server.kv.SetWithTTL([]byte("a"), []byte("1"), time.Minute))
+	assert.Equal(t, protocol.SUCCESS, server.processRequest(hset("h", "f", "v")).Status)
+	_, versionA, err := server.kv.GetVersion([]byte("a"))
+	assert.NoError(t, err)
+	_, versionH, _ := server.kv.GetVersion([]byte("h"))
+
+	res := server.processRequest(protocol.Operation{
+		Type: protocol.TX,
+		Ops:  []protocol.Operation{set("a", "2"), hset("h", "f", "w"), {Type: protocol.INCR, Key: []byte("h")}},
+	})
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+
+	_, version, err := server.kv.GetVersion([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, versionA, version, "rolled back writes keep the watched version")
+	_, version, _ = server.kv.GetVersion([]byte("h"))
+	assert.Equal(t, versionH, version)
+
+	res = server.processRequest(protocol.Operation{
+		Type:     protocol.TX,
+		Ops:      []protocol.Operation{set("b", "1")},
+		Keys:     [][]byte{[]byte("a"), []byte("h")},
+		Versions: []uint64{versionA, versionH},
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+}
+
+func TestTransactionInvalid(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(protocol.Operation{
+		Type: protocol.TX,
+		Ops:  []protocol.Operation{set("a", "1"), {Type: protocol.TX}},
+	})
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	_, err := server.kv.Get([]byte("a"))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	res = server.processRequest(protocol.Operation{
+		Type: protocol.TX,
+		Ops:  make([]protocol.Operation, constants.MaxRequestBatch+1),
+	})
+	assert.Equal(t, protocol.BATCH_TOO_LARGE, res.Status)
+}
+
+func TestTransactionReusedSession(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	sess := newSession()
+	mget := protocol.Operation{Type: protocol.MGET, Keys: [][]byte{[]byte("a")}}
+	out, _ := server.handle(sess, frame(t, protocol.Operation{Type: protocol.TX, Ops: []protocol.Operation{mget}}))
+	assert.Equal(t, protocol.SUCCESS, readResponses(t, out)[0].Results[0].Status)
+
+	out, _ = server.handle(sess, frame(t, protocol.Operation{
+		Type: protocol.TX,
+		Ops:  []protocol.Operation{{Type: protocol.MGET}},
+	}))
+	res := readResponses(t, out)[0].Results[0]
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Empty(t, res.Results[0].Results, "nested operations start clean")
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/kevindweb/cache/internal/constants"
+	"github.com/kevindweb/cache/internal/protocol"
+	"github.com/kevindweb/cache/internal/storage"
+)
+
+func (s *Server) processZSet(op protocol.Operation) protocol.Result {
+	res := protocol.Result{}
+	switch op.Type {
+	case protocol.ZADD, protocol.ZINCRBY:
+		if len(op.Keys) == 0 || len(op.Keys) != len(op.Scores) {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.MismatchedScoresErr, len(op.Keys), len(op.Scores)))
+			return res
+		}
+
+		if op.Type == protocol.ZINCRBY && len(op.Keys) != 1 {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.SingleMemberErr, op.Type, len(op.Keys)))
+			return res
+		}
+
+		if !validScores(&res, op.Scores, false) {
+			return res
+		}
+
+		if op.Type == protocol.ZINCRBY {
+			score, err := storage.ZIncrBy(s.kv, op.Key, op.Keys[0], op.Scores[0])
+			handleOperationResult(&res, formatScore(score), err)
+			return res
+		}
+
+		added, version, err := storage.ZAdd(s.kv, op.Key, op.Keys, op.Scores)
+		handleOperationResult(&res, []byte(strconv.Itoa(added)), err)
+		res.Version = version
+	case protocol.ZREM:
+		removed, err := storage.ZRem(s.kv, op.Key, op.Keys)
+		handleOperationResult(&res, []byte(strconv.Itoa(removed)), err)
+	case protocol.ZSCORE:
+		score, err := storage.ZScore(s.kv, op.Key, op.Member)
+		handleOperationResult(&res, formatScore(score), err)
+	case protocol.ZRANGE:
+		members, scores, err := storage.ZRange(s.kv, op.Key, op.Start, op.Stop)
+		handleOperationResult(&res, s.ok, err)
+		res.Values = interleave(members, scores)
+	case protocol.ZRANGEBYSCORE:
+		if len(op.Scores) != 2 {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.ScoreBoundsErr, len(op.Scores)))
+			return res
+		}
+
+		if !validScores(&res, op.Scores, true) {
+			return res
+		}
+
+		members, scores, err := storage.ZRangeByScore(s.kv, op.Key, op.Scores[0], op.Scores[1])
+		handleOperationResult(&res, s.ok, err)
+		res.Values = interleave(members, scores)
+	default:
+		res.Status = protocol.UNKNOWN_OPERATION
+		res.Message = []byte(fmt.Sprintf(constants.UndefinedOpErr, op.Type))
+	}
+	return res
+}
+
+// validScores rejects NaN scores, only range bounds may be infinite.
+func validScores(res *protocol.Result, scores []float64, bounds bool) bool {
+	for _, score := range scores {
+		if math.IsNaN(score) || (!bounds && math.IsInf(score, 0)) {
+			res.Status = protocol.INVALID_ARGUMENT
+			res.Message = []byte(fmt.Sprintf(constants.InvalidScoreErr, score))
+			return false
+		}
+	}
+	return true
+}
+
+func formatScore(score float64) []byte {
+	return strconv.AppendFloat(nil, score, 'f', -1, 64)
+}
+
+func interleave(members [][]byte, scores []float64) [][]byte {
+	values := make([][]byte, 0, 2*len(members))
+	for i := range members {
+		values = append(values, members[i], formatScore(scores[i]))
+	}
+	return values
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/kevindweb/cache/internal/protocol"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func zadd(typ protocol.OperationType, key string, memberScores ...string) protocol.Operation {
+	op := protocol.Operation{Type: typ, Key: []byte(key)}
+	for i := 0; i < len(memberScores); i += 2 {
+		op.Keys = append(op.Keys, []byte(memberScores[i]))
+		score, _ := strconv.ParseFloat(memberScores[i+1], 64)
+		op.Scores = append(op.Scores, score)
+	}
+	return op
+}
+
+func TestProcessZSet(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	res := server.processRequest(zadd(protocol.ZADD, "board", "ada", "30", "bob", "10", "cy", "20.5"))
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, "3", string(res.Message))
+	assert.NotZero(t, res.Version)
+
+	res = server.processRequest(zadd(protocol.ZINCRBY, "board", "bob", "15"))
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, "25", string(res.Message))
+
+	res = server.processRequest(protocol.Operation{Type: protocol.ZRANGE, Key: []byte("board"), Start: 0, Stop: -1})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, [][]byte{
+		[]byte("cy"), []byte("20.5"), []byte("bob"), []byte("25"), []byte("ada"), []byte("30"),
+	}, res.Values)
+
+	res = server.processRequest(protocol.Operation{
+		Type:   protocol.ZRANGEBYSCORE,
+		Key:    []byte("board"),
+		Scores: []float64{25, math.Inf(1)},
+	})
+	assert.Equal(t, protocol.SUCCESS, res.Status)
+	assert.Equal(t, [][]byte{[]byte("bob"), []byte("25"), []byte("ada"), []byte("30")}, res.Values)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.ZSCORE, Key: []byte("board"), Member: []byte("cy")})
+	assert.Equal(t, "20.5", string(res.Message))
+	res = server.processRequest(protocol.Operation{Type: protocol.ZSCORE, Key: []byte("board"), Member: []byte("dee")})
+	assert.Equal(t, protocol.NOT_FOUND, res.Status)
+
+	res = server.processRequest(protocol.Operation{
+		Type: protocol.ZREM,
+		Key:  []byte("board"),
+		Keys: [][]byte{[]byte("cy"), []byte("dee")},
+	})
+	assert.Equal(t, "1", string(res.Message))
+
+	res = server.processRequest(get("board"))
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+	res = server.processRequest(zadd(protocol.ZADD, "board", "ada", "inf"))
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	res = server.processRequest(zadd(protocol.ZADD, "board", "ada", "NaN"))
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	res = server.processRequest(zadd(protocol.ZINCRBY, "board", "ada", "1", "bob", "1"))
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+	res = server.processRequest(protocol.Operation{
+		Type:   protocol.ZRANGEBYSCORE,
+		Key:    []byte("board"),
+		Scores: []float64{0},
+	})
+	assert.Equal(t, protocol.INVALID_ARGUMENT, res.Status)
+}
+
+func TestTransactionRollsBackZSet(t *testing.T) {
+	t.Parallel()
+	server := txServer()
+	server.processRequest(zadd(protocol.ZADD, "board", "ada", "1"))
+
+	res := server.processRequest(protocol.Operation{Type: protocol.TX, Ops: []protocol.Operation{
+		zadd(protocol.ZINCRBY, "board", "ada", "5"),
+		zadd(protocol.ZADD, "board", "bob", "2"),
+		set("str", "v"),
+		zadd(protocol.ZADD, "str", "m", "1"),
+	}})
+	assert.Equal(t, protocol.WRONG_TYPE, res.Status)
+
+	res = server.processRequest(protocol.Operation{Type: protocol.ZRANGE, Key: []byte("board"), Start: 0, Stop: -1})
+	assert.Equal(t, [][]byte{[]byte("ada"), []byte("1")}, res.Values)
+}
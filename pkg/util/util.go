@@ -18,22 +18,24 @@ func StartDefaultClientServer() (*client.Client, *server.Server, error) {
 }
 
 func StartUniqueClientServer() (*client.Client, *server.Server, error) {
+	return StartUniqueClientServerOptions(server.Options{}, client.Options{})
+}
+
+func StartUniqueClientServerOptions(
+	serverOptions server.Options, clientOptions client.Options,
+) (*client.Client, *server.Server, error) {
 	port := util.GetUniquePort()
-	serverOptions := server.Options{
-		Host:    constants.DefaultHost,
-		Port:    port,
-		Network: constants.DefaultNetwork,
-	}
+	serverOptions.Host = constants.DefaultHost
+	serverOptions.Port = port
+	serverOptions.Network = constants.DefaultNetwork
 	s, err := server.StartOptions(serverOptions)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	clientOptions := client.Options{
-		Host:    constants.DefaultHost,
-		Port:    port,
-		Network: constants.DefaultNetwork,
-	}
+	clientOptions.Host = constants.DefaultHost
+	clientOptions.Port = port
+	clientOptions.Network = constants.DefaultNetwork
 	c, err := client.StartOptions(clientOptions)
 	return c, s, err
 }
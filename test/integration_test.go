@@ -1,16 +1,25 @@
 package test
 
 import (
+	"context"
+	"math"
 	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/kevindweb/cache/internal/constants"
+	internalutil "github.com/kevindweb/cache/internal/util"
 	"github.com/kevindweb/cache/pkg/client"
 	"github.com/kevindweb/cache/pkg/server"
 	"github.com/kevindweb/cache/pkg/util"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/evio"
 )
 
 func cleanup(t *testing.T, client *client.Client, server *server.Server) {
@@ -52,6 +61,37 @@ func TestSetGetDelParallel(t *testing.T) {
 	client, server, err := util.StartUniqueClientServer()
 	assert.NoError(t, err, "unable to start client and server")
 	defer cleanup(t, client, server)
+	setGetParallel(t, client)
+}
+
+func TestSetGetDelParallelTunedClient(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServerOptions(
+		server.Options{},
+		client.Options{
+			PoolSize:    4,
+			BatchWindow: 50 * time.Microsecond,
+			MaxBatch:    16,
+		},
+	)
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+	setGetParallel(t, client)
+}
+
+func TestSetGetDelParallelMultiLoop(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServerOptions(server.Options{
+		Loops:       4,
+		LoadBalance: evio.RoundRobin,
+	}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+	setGetParallel(t, client)
+}
+
+func setGetParallel(t *testing.T, client *client.Client) {
+	t.Helper()
 
 	keys := []string{}
 	values := []string{}
@@ -87,6 +127,132 @@ func TestSetGetDelParallel(t *testing.T) {
 	wg.Wait()
 }
 
+func TestBinarySafeValues(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+
+	key := []byte{0, 1, 2, '-'}
+	for _, val := range [][]byte{[]byte("-leading dash"), {0, 0xff, 0}, {}} {
+		err = client.SetBytes(key, val)
+		assert.NoError(t, err)
+		got, getErr := client.GetBytes(key)
+		assert.NoError(t, getErr)
+		assert.Equal(t, len(val), len(got))
+		assert.Equal(t, string(val), string(got))
+	}
+
+	err = client.DelBytes(key)
+	assert.NoError(t, err)
+	_, err = client.GetBytes(key)
+	assert.Error(t, err)
+}
+
+func TestContextRequests(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	testKey := uuid.NewString()
+	expectedVal := uuid.NewString()
+	assert.NoError(t, client.PingCtx(ctx))
+	assert.NoError(t, client.SetCtx(ctx, testKey, expectedVal))
+	gotVal, err := client.GetCtx(ctx, testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVal, gotVal)
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	err = client.DelCtx(cancelled, testKey)
+	assert.ErrorIs(t, err, context.Canceled)
+	gotVal, err = client.Get(testKey)
+	assert.NoError(t, err, "cancelled delete should never reach the server")
+	assert.Equal(t, expectedVal, gotVal)
+}
+
+func TestLargeValue(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+
+	testKey := uuid.NewString()
+	expectedVal := strings.Repeat(uuid.NewString(), 1<<15)
+	err = client.Set(testKey, expectedVal)
+	assert.NoError(t, err)
+	gotVal, err := client.Get(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVal, gotVal)
+}
+
+func TestSetExExpires(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+
+	testKey := uuid.NewString()
+	err = client.Set(testKey, uuid.NewString())
+	assert.NoError(t, err)
+	ttl, err := client.TTL(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.NoExpiration, ttl)
+
+	ttl = 50 * time.Millisecond
+	err = client.SetEx(testKey, uuid.NewString(), ttl)
+	assert.NoError(t, err)
+	gotTTL, err := client.TTL(testKey)
+	assert.NoError(t, err)
+	assert.True(t, gotTTL > 0 && gotTTL <= ttl)
+
+	time.Sleep(2 * ttl)
+	_, err = client.Get(testKey)
+	assert.Error(t, err)
+	_, err = client.TTL(testKey)
+	assert.Error(t, err)
+}
+
+func TestMaxMemoryNoEviction(t *testing.T) {
+	t.Parallel()
+	client, server, err := util.StartUniqueClientServerOptions(server.Options{
+		MaxMemory: 100,
+		Eviction:  server.NoEviction,
+	}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, client, server)
+
+	testKey := uuid.NewString()
+	err = client.Set(testKey, uuid.NewString())
+	assert.NoError(t, err)
+	err = client.Set(uuid.NewString(), uuid.NewString())
+	assert.Error(t, err, "second key should exceed max memory")
+	_, err = client.Get(testKey)
+	assert.NoError(t, err)
+}
+
+func TestTypedErrors(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServerOptions(server.Options{
+		MaxMemory: 100,
+		Eviction:  server.NoEviction,
+	}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	_, err = c.Get(uuid.NewString())
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	assert.ErrorIs(t, c.Expire(uuid.NewString(), time.Second), client.ErrNotFound)
+
+	assert.NoError(t, c.Set(uuid.NewString(), uuid.NewString()))
+	err = c.Set(uuid.NewString(), uuid.NewString())
+	assert.ErrorIs(t, err, client.ErrOutOfMemory)
+	assert.NotErrorIs(t, err, client.ErrNotFound)
+}
+
 func TestAfterCleanup(t *testing.T) {
 	t.Parallel()
 	client, server, err := util.StartUniqueClientServer()
@@ -99,3 +265,839 @@ func TestAfterCleanup(t *testing.T) {
 	assert.Error(t, err, "set should have failed when server is down")
 	cleanupClient(t, client)
 }
+
+func TestReconnectAfterServerRestart(t *testing.T) {
+	t.Parallel()
+	port := internalutil.GetUniquePort()
+	serverOptions := server.Options{Port: port}
+	s, err := server.StartOptions(serverOptions)
+	assert.NoError(t, err)
+
+	c, err := client.StartOptions(client.Options{Port: port, PoolSize: 2})
+	assert.NoError(t, err)
+	defer cleanupClient(t, c)
+
+	cleanupServer(t, s)
+	assert.Error(t, c.Set(uuid.New().String(), "value"), "set should fail while server is down")
+
+	time.Sleep(100 * time.Millisecond)
+	s, err = server.StartOptions(serverOptions)
+	assert.NoError(t, err)
+	defer cleanupServer(t, s)
+
+	testKey := uuid.New().String()
+	assert.Eventually(t, func() bool {
+		return c.Set(testKey, "value") == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	val, err := c.Get(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val)
+
+	reconnected := false
+	for _, health := range c.Health() {
+		reconnected = reconnected || (health.Connected && health.Reconnects > 0)
+	}
+	assert.True(t, reconnected)
+}
+
+func TestSnapshotRestart(t *testing.T) {
+	t.Parallel()
+	port := internalutil.GetUniquePort()
+	serverOptions := server.Options{Port: port, SnapshotPath: filepath.Join(t.TempDir(), "dump.snap")}
+	s, err := server.StartOptions(serverOptions)
+	assert.NoError(t, err)
+
+	c, err := client.StartOptions(client.Options{Port: port, PoolSize: 2})
+	assert.NoError(t, err)
+	defer cleanupClient(t, c)
+
+	assert.NoError(t, c.Set("saved", "before"))
+	assert.NoError(t, c.Save())
+	assert.NoError(t, c.SetEx("stopped", "value", time.Minute))
+	_, err = c.HSet("hash", map[string]string{"field": "value"})
+	assert.NoError(t, err)
+
+	// stopping saves the writes made after the explicit save
+	cleanupServer(t, s)
+	time.Sleep(100 * time.Millisecond)
+	s, err = server.StartOptions(serverOptions)
+	assert.NoError(t, err)
+	defer cleanupServer(t, s)
+
+	assert.Eventually(t, func() bool {
+		return c.Ping() == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	val, err := c.Get("saved")
+	assert.NoError(t, err)
+	assert.Equal(t, "before", val)
+	ttl, err := c.TTL("stopped")
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, 50*time.Second)
+	val, err = c.HGet("hash", "field")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val)
+}
+
+func TestAppendLogRestart(t *testing.T) {
+	t.Parallel()
+	port := internalutil.GetUniquePort()
+	serverOptions := server.Options{
+		Port:          port,
+		AppendLogPath: filepath.Join(t.TempDir(), "ops.log"),
+		AppendLogSync: server.SyncAlways,
+	}
+	s, err := server.StartOptions(serverOptions)
+	assert.NoError(t, err)
+
+	c, err := client.StartOptions(client.Options{Port: port, PoolSize: 2})
+	assert.NoError(t, err)
+	defer cleanupClient(t, c)
+
+	assert.NoError(t, c.Set("key", "first"))
+	assert.NoError(t, c.Set("key", "second"))
+	assert.NoError(t, c.SetEx("temp", "value", time.Minute))
+	_, err = c.RPush("list", "a", "b", "c")
+	assert.NoError(t, err)
+	_, err = c.LPop("list")
+	assert.NoError(t, err)
+
+	cleanupServer(t, s)
+	time.Sleep(100 * time.Millisecond)
+	s, err = server.StartOptions(serverOptions)
+	assert.NoError(t, err)
+	defer cleanupServer(t, s)
+
+	assert.Eventually(t, func() bool {
+		return c.Ping() == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	val, err := c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", val)
+	ttl, err := c.TTL("temp")
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, 50*time.Second)
+	values, err := c.LRange("list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, values)
+}
+
+func TestReplication(t *testing.T) {
+	t.Parallel()
+	leaderPort, replicaPort := internalutil.GetUniquePort(), internalutil.GetUniquePort()
+	leader, err := server.StartOptions(server.Options{Port: leaderPort, Loops: 2})
+	assert.NoError(t, err)
+	defer cleanupServer(t, leader)
+
+	writer, err := client.StartOptions(client.Options{Port: leaderPort, PoolSize: 2})
+	assert.NoError(t, err)
+	defer cleanupClient(t, writer)
+	assert.Eventually(t, func() bool {
+		return writer.Ping() == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.NoError(t, writer.Set("before", "snapshot"))
+
+	replica, err := server.StartOptions(server.Options{
+		Port:      replicaPort,
+		ReplicaOf: "localhost:" + strconv.Itoa(leaderPort),
+	})
+	assert.NoError(t, err)
+	defer cleanupServer(t, replica)
+
+	reader, err := client.StartOptions(client.Options{Port: replicaPort, PoolSize: 2})
+	assert.NoError(t, err)
+	defer cleanupClient(t, reader)
+	assert.Eventually(t, func() bool {
+		info, err := reader.Replication()
+		return err == nil && info.Connected
+	}, 5*time.Second, 20*time.Millisecond)
+
+	val, err := reader.Get("before")
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshot", val)
+
+	assert.NoError(t, writer.Set("after", "streamed"))
+	_, err = writer.RPush("list", "a", "b")
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		values, err := reader.LRange("list", 0, -1)
+		return err == nil && len(values) == 2
+	}, 5*time.Second, 20*time.Millisecond)
+	val, err = reader.Get("after")
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed", val)
+
+	err = reader.Set("after", "rejected")
+	assert.ErrorIs(t, err, client.ErrReadOnly)
+
+	info, err := reader.Replication()
+	assert.NoError(t, err)
+	assert.Equal(t, server.RoleReplica, info.Role)
+	assert.Equal(t, uint64(2), info.Offset)
+	assert.Less(t, info.Lag, time.Second)
+
+	info, err = writer.Replication()
+	assert.NoError(t, err)
+	assert.Equal(t, server.RoleLeader, info.Role)
+	assert.Equal(t, uint64(2), info.Offset)
+	assert.Equal(t, 1, info.Replicas)
+}
+
+func startNode(t *testing.T) (string, *client.Client) {
+	t.Helper()
+	port := internalutil.GetUniquePort()
+	s, err := server.StartOptions(server.Options{Port: port})
+	assert.NoError(t, err)
+	t.Cleanup(func() { cleanupServer(t, s) })
+
+	c, err := client.StartOptions(client.Options{Port: port, PoolSize: 1})
+	assert.NoError(t, err)
+	t.Cleanup(func() { cleanupClient(t, c) })
+	return "localhost:" + strconv.Itoa(port), c
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+	direct := map[string]*client.Client{}
+	addrs := make([]string, 3)
+	for i := range addrs {
+		var c *client.Client
+		addrs[i], c = startNode(t)
+		direct[addrs[i]] = c
+	}
+
+	cluster, err := client.StartCluster(client.ClusterOptions{
+		Addrs: addrs,
+		Node:  client.Options{PoolSize: 2},
+	})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, cluster.Stop())
+	}()
+	assert.NoError(t, cluster.Ping())
+
+	keys := make([]string, 300)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	errs, err := cluster.MSet(keys, keys)
+	assert.NoError(t, err)
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// every key lives only on the node it hashes to
+	owners := map[string]string{}
+	for _, key := range keys {
+		addr, _, err := cluster.Node(key)
+		assert.NoError(t, err)
+		owners[key] = addr
+		val, err := direct[addr].Get(key)
+		assert.NoError(t, err)
+		assert.Equal(t, key, val)
+	}
+	for addr, c := range direct {
+		values, err := c.MGet(keys...)
+		assert.NoError(t, err)
+		for i, res := range values {
+			assert.Equal(t, owners[keys[i]] == addr, res.Err == nil, keys[i])
+		}
+	}
+
+	added, c := startNode(t)
+	direct[added] = c
+	assert.NoError(t, cluster.AddNode(added))
+	assert.Error(t, cluster.AddNode(added))
+	assert.Len(t, cluster.Nodes(), 4)
+
+	// keys the new node did not take are still found where they were
+	moved := 0
+	results, err := cluster.MGet(keys...)
+	assert.NoError(t, err)
+	for i, key := range keys {
+		addr, _, _ := cluster.Node(key)
+		if addr == added {
+			moved++
+			assert.ErrorIs(t, results[i].Err, client.ErrNotFound)
+			continue
+		}
+		assert.Equal(t, owners[key], addr)
+		assert.Equal(t, key, results[i].Value)
+	}
+	assert.Greater(t, moved, 0)
+	assert.Less(t, moved, len(keys)/2)
+
+	assert.NoError(t, cluster.RemoveNode(added))
+	assert.Error(t, cluster.RemoveNode(added))
+	for _, key := range keys {
+		val, err := cluster.Get(key)
+		assert.NoError(t, err)
+		assert.Equal(t, key, val)
+	}
+}
+
+func TestMultiKey(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	// more keys than fit in one batch are split across operations
+	numKeys := constants.MaxRequestBatch*2 + 50
+	keys := make([]string, numKeys)
+	values := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = uuid.NewString()
+		values[i] = uuid.NewString()
+	}
+
+	errs, err := c.MSet(keys, values)
+	assert.NoError(t, err)
+	for _, keyErr := range errs {
+		assert.NoError(t, keyErr)
+	}
+
+	missing := uuid.NewString()
+	results, err := c.MGet(append([]string{missing}, keys...)...)
+	assert.NoError(t, err)
+	assert.Len(t, results, numKeys+1)
+	assert.ErrorIs(t, results[0].Err, client.ErrNotFound)
+	for i, res := range results[1:] {
+		assert.NoError(t, res.Err)
+		assert.Equal(t, values[i], res.Value)
+	}
+
+	errs, err = c.MDel(keys...)
+	assert.NoError(t, err)
+	assert.Len(t, errs, numKeys)
+	_, err = c.Get(keys[numKeys-1])
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestIncrParallel(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServerOptions(server.Options{Loops: 4}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key := uuid.NewString()
+	workers, incrs := 10, 100
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < incrs; j++ {
+				_, incrErr := c.Incr(key)
+				assert.NoError(t, incrErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := c.IncrBy(key, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(workers*incrs), n)
+
+	n, err = c.Decr(key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(workers*incrs-1), n)
+
+	f, err := c.IncrByFloat(key, 0.25)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(workers*incrs)-0.75, f)
+
+	_, err = c.Incr(key)
+	assert.ErrorIs(t, err, client.ErrWrongType)
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServerOptions(server.Options{Loops: 4}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key := uuid.NewString()
+	_, err = c.SetXX(key, "0")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	_, err = c.SetNX(key, "0")
+	assert.NoError(t, err)
+	_, err = c.SetNX(key, "0")
+	assert.ErrorIs(t, err, client.ErrConflict)
+
+	// each worker retries its read-modify-write until no one else wrote
+	workers, updates := 8, 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < updates; j++ {
+				for {
+					val, version, getErr := c.GetVersion(key)
+					assert.NoError(t, getErr)
+					n, _ := strconv.Atoi(val)
+					_, casErr := c.CompareAndSwap(key, version, strconv.Itoa(n+1))
+					if casErr == nil {
+						break
+					}
+					assert.ErrorIs(t, casErr, client.ErrConflict)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	val, err := c.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(workers*updates), val)
+}
+
+func TestTransactionIsolation(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServerOptions(server.Options{Loops: 4}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	from, to := uuid.NewString(), uuid.NewString()
+	total := 100
+	_, err = c.MSet([]string{from, to}, []string{strconv.Itoa(total), "0"})
+	assert.NoError(t, err)
+
+	// readers must never see a transfer half applied
+	done := make(chan bool)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			results, getErr := c.MGet(from, to)
+			assert.NoError(t, getErr)
+			a, _ := strconv.Atoi(results[0].Value)
+			b, _ := strconv.Atoi(results[1].Value)
+			assert.Equal(t, total, a+b)
+		}
+	}()
+
+	var transfers sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		transfers.Add(1)
+		go func() {
+			defer transfers.Done()
+			for j := 0; j < 10; j++ {
+				tx := c.Tx()
+				tx.IncrBy(from, -1)
+				tx.IncrBy(to, 1)
+				_, txErr := tx.Exec()
+				assert.NoError(t, txErr)
+			}
+		}()
+	}
+	transfers.Wait()
+	close(done)
+	wg.Wait()
+
+	results, err := c.MGet(from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", results[0].Value)
+	assert.Equal(t, strconv.Itoa(total), results[1].Value)
+}
+
+func TestTransactionWatchAndRollback(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key, text := uuid.NewString(), uuid.NewString()
+	version, err := c.SetNX(key, "1")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Set(text, "text"))
+
+	tx := c.Tx()
+	tx.Watch(key, version)
+	tx.Incr(key)
+	tx.Incr(text)
+	_, err = tx.Exec()
+	assert.ErrorIs(t, err, client.ErrWrongType)
+	val, err := c.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", val, "the first increment was rolled back")
+
+	_, err = c.SetXX(key, "5")
+	assert.NoError(t, err)
+	tx = c.Tx()
+	tx.Watch(key, version)
+	tx.Del(key)
+	_, err = tx.Exec()
+	assert.ErrorIs(t, err, client.ErrConflict)
+
+	_, version, err = c.GetVersion(key)
+	assert.NoError(t, err)
+	tx = c.Tx()
+	tx.Watch(key, version)
+	tx.Del(key)
+	results, err := tx.Exec()
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestTransactionWatchHash(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key, other := uuid.NewString(), uuid.NewString()
+	_, err = c.HSet(key, map[string]string{"name": "ada"})
+	assert.NoError(t, err)
+	_, version, err := c.GetVersion(key)
+	assert.ErrorIs(t, err, client.ErrWrongType)
+	assert.NotZero(t, version)
+
+	done := make(chan error)
+	go func() {
+		_, hsetErr := c.HSet(key, map[string]string{"age": "36"})
+		done <- hsetErr
+	}()
+	assert.NoError(t, <-done)
+
+	tx := c.Tx()
+	tx.Watch(key, version)
+	tx.Set(other, "val")
+	_, err = tx.Exec()
+	assert.ErrorIs(t, err, client.ErrConflict)
+	_, err = c.Get(other)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	_, version, err = c.GetVersion(key)
+	assert.ErrorIs(t, err, client.ErrWrongType)
+	tx = c.Tx()
+	tx.Watch(key, version)
+	tx.Set(other, "val")
+	_, err = tx.Exec()
+	assert.NoError(t, err)
+}
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key, counter := uuid.NewString(), uuid.NewString()
+	pipe := c.Pipeline()
+	pipe.Set(key, "val")
+	pipe.Get(key)
+	pipe.Incr(counter)
+	pipe.IncrBy(counter, 10)
+	pipe.Del(key)
+	pipe.Get(key)
+	results, err := pipe.Exec()
+	assert.NoError(t, err)
+	assert.Len(t, results, 6)
+	assert.Equal(t, "val", results[1].Value)
+	assert.Equal(t, "1", results[2].Value)
+	assert.Equal(t, "11", results[3].Value)
+	assert.NoError(t, results[4].Err)
+	assert.ErrorIs(t, results[5].Err, client.ErrNotFound)
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	prefix := uuid.NewString() + ":"
+	want := []string{}
+	for i := 0; i < 250; i++ {
+		key := prefix + strconv.Itoa(i)
+		want = append(want, key)
+		assert.NoError(t, c.Set(key, "val"))
+	}
+	assert.NoError(t, c.Set(uuid.NewString(), "val"))
+
+	keys := []string{}
+	it := c.Scan(prefix+"*", 32)
+	for it.Next() {
+		keys = append(keys, it.Key())
+		// writes between pages never repeat or skip the remaining keys
+		assert.NoError(t, c.Set(uuid.NewString(), "val"))
+	}
+	assert.NoError(t, it.Err())
+	assert.ElementsMatch(t, want, keys)
+
+	keys, err = c.Keys(prefix + "1?")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 10)
+
+	_, err = c.Keys("[")
+	assert.ErrorIs(t, err, client.ErrInvalidArgument)
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key := uuid.NewString()
+	added, err := c.HSet(key, map[string]string{"name": "ada", "age": "36"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = c.HSet(key, map[string]string{"age": "37", "city": "london"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	val, err := c.HGet(key, "age")
+	assert.NoError(t, err)
+	assert.Equal(t, "37", val)
+	_, err = c.HGet(key, "missing")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	fields, err := c.HGetAll(key)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "ada", "age": "37", "city": "london"}, fields)
+
+	removed, err := c.HDel(key, "name", "age", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	_, err = c.Get(key)
+	assert.ErrorIs(t, err, client.ErrWrongType)
+	str := uuid.NewString()
+	assert.NoError(t, c.Set(str, "val"))
+	_, err = c.HSet(str, map[string]string{"f": "v"})
+	assert.ErrorIs(t, err, client.ErrWrongType)
+
+	_, err = c.HDel(key, "city")
+	assert.NoError(t, err)
+	fields, err = c.HGetAll(key)
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestZSet(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key := uuid.NewString()
+	added, err := c.ZAdd(key, map[string]float64{"ada": 30, "bob": 10, "cy": 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	score, err := c.ZIncrBy(key, "bob", 25.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 35.5, score)
+	score, err = c.ZScore(key, "cy")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(20), score)
+	_, err = c.ZScore(key, "missing")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	top, err := c.ZRange(key, -2, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []client.ZMember{{Member: "ada", Score: 30}, {Member: "bob", Score: 35.5}}, top)
+
+	members, err := c.ZRangeByScore(key, math.Inf(-1), 30)
+	assert.NoError(t, err)
+	assert.Equal(t, []client.ZMember{{Member: "cy", Score: 20}, {Member: "ada", Score: 30}}, members)
+
+	removed, err := c.ZRem(key, "ada", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = c.Get(key)
+	assert.ErrorIs(t, err, client.ErrWrongType)
+	_, err = c.HSet(key, map[string]string{"f": "v"})
+	assert.ErrorIs(t, err, client.ErrWrongType)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+
+	key := uuid.NewString()
+	n, err := c.RPush(key, "b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = c.LPush(key, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	values, err := c.LRange(key, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, values)
+
+	val, err := c.RPop(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", val)
+	n, err = c.LLen(key)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = c.Get(key)
+	assert.ErrorIs(t, err, client.ErrWrongType)
+
+	for range values[:2] {
+		_, err = c.LPop(key)
+		assert.NoError(t, err)
+	}
+	_, err = c.LPop(key)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestBlockingPop(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServer()
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+	blockingPop(t, c)
+}
+
+func TestBlockingPopMultiLoop(t *testing.T) {
+	t.Parallel()
+	c, s, err := util.StartUniqueClientServerOptions(server.Options{
+		Loops:       4,
+		LoadBalance: evio.RoundRobin,
+	}, client.Options{})
+	assert.NoError(t, err, "unable to start client and server")
+	defer cleanup(t, c, s)
+	blockingPop(t, c)
+}
+
+func blockingPop(t *testing.T, c *client.Client) {
+	t.Helper()
+	key := uuid.NewString()
+	start := time.Now()
+	_, err := c.BLPop(key, 50*time.Millisecond)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// consumers wait longer than the worker read timeout without tying up
+	// the connections other requests use
+	consumers := 3
+	popped := make(chan string, consumers)
+	for i := 0; i < consumers; i++ {
+		go func() {
+			val, popErr := c.BLPop(key, 5*time.Second)
+			assert.NoError(t, popErr)
+			popped <- val
+		}()
+	}
+
+	time.Sleep(1200 * time.Millisecond)
+	assert.NoError(t, c.Set(uuid.NewString(), "val"))
+	for i := 0; i < consumers; i++ {
+		_, err = c.RPush(key, "job"+strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+
+	jobs := []string{}
+	for i := 0; i < consumers; i++ {
+		jobs = append(jobs, <-popped)
+	}
+	assert.ElementsMatch(t, []string{"job0", "job1", "job2"}, jobs)
+}
+
+func TestClusterMode(t *testing.T) {
+	t.Parallel()
+	ports := []int{internalutil.GetUniquePort(), internalutil.GetUniquePort()}
+	addrs := []string{"localhost:" + strconv.Itoa(ports[0]), "localhost:" + strconv.Itoa(ports[1])}
+	ranges := []server.SlotRange{
+		{Start: 0, End: 8191, Addr: addrs[0]},
+		{Start: 8192, End: 16383, Addr: addrs[1]},
+	}
+
+	direct := make([]*client.Client, len(ports))
+	for i, port := range ports {
+		s, err := server.StartOptions(server.Options{Port: port, ClusterAddr: addrs[i], ClusterSlots: ranges})
+		assert.NoError(t, err)
+		t.Cleanup(func() { cleanupServer(t, s) })
+
+		direct[i], err = client.StartOptions(client.Options{Port: port, PoolSize: 1})
+		assert.NoError(t, err)
+		t.Cleanup(func() { cleanupClient(t, direct[i]) })
+	}
+
+	c, err := client.StartClusterMode(client.ClusterModeOptions{
+		Addrs: addrs[:1],
+		Node:  client.Options{PoolSize: 2},
+	})
+	assert.NoError(t, err)
+	defer cleanupClient(t, c)
+
+	slotMap, _, err := c.ClusterSlots()
+	assert.NoError(t, err)
+	assert.Equal(t, ranges, slotMap)
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	errs, err := c.MSet(keys, keys)
+	assert.NoError(t, err)
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// every key lives on one node, the other redirects it
+	served := 0
+	for _, key := range keys {
+		val, err := direct[0].Get(key)
+		if err == nil {
+			assert.Equal(t, key, val)
+			served++
+			_, err = direct[1].Get(key)
+		}
+		assert.ErrorIs(t, err, client.ErrMoved)
+	}
+	assert.Greater(t, served, 0)
+	assert.Less(t, served, len(keys))
+
+	// move the slot of foo to the first node, a new key is asked for there
+	// while the slot migrates and existing keys stay on the second node
+	assert.NoError(t, c.Set("foo", "before"))
+	slot := 12182
+	assert.NoError(t, direct[1].SetSlot(slot, slot, client.SlotMigrating, addrs[0]))
+	assert.NoError(t, direct[0].SetSlot(slot, slot, client.SlotImporting, addrs[1]))
+	assert.NoError(t, c.Set("{foo}new", "asked"))
+	_, err = direct[0].Get("{foo}new")
+	assert.ErrorIs(t, err, client.ErrMoved)
+	val, err := c.Get("{foo}new")
+	assert.NoError(t, err)
+	assert.Equal(t, "asked", val)
+	val, err = c.Get("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "before", val)
+
+	for _, node := range direct {
+		assert.NoError(t, node.SetSlot(slot, slot, client.SlotNode, addrs[0]))
+	}
+	val, err = c.Get("{foo}new")
+	assert.NoError(t, err)
+	assert.Equal(t, "asked", val)
+
+	slotMap, _, err = c.ClusterSlots()
+	assert.NoError(t, err)
+	assert.Equal(t, []server.SlotRange{
+		{Start: 0, End: 8191, Addr: addrs[0]},
+		{Start: 8192, End: uint16(slot - 1), Addr: addrs[1]},
+		{Start: uint16(slot), End: uint16(slot), Addr: addrs[0]},
+		{Start: uint16(slot + 1), End: 16383, Addr: addrs[1]},
+	}, slotMap)
+}